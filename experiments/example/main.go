@@ -56,6 +56,8 @@ func main() {
 
 	// Initialize logger (and log some basic information: experiment name, UUID generated above etc).
 	logger.Initialize(appName, uid)
+	// Nothing is left for swan-reap when experiment finishes without errors.
+	defer executor.TruncateArtifactManifest()
 
 	// Connect to metadata database (Cassandra is the default database).
 	// Besides experiment results and platform metrics (we use Snap to gather them) we save certain deta about experiment configuration and environment (metadata).
@@ -18,6 +18,7 @@ import (
 	"github.com/intelsdi-x/swan/experiments/krico/api"
 	"github.com/intelsdi-x/swan/experiments/krico/workloads"
 	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/experiment"
 	"github.com/intelsdi-x/swan/pkg/experiment/logger"
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity/validate"
@@ -48,6 +49,8 @@ func main() {
 
 	// Initialize logger.
 	logger.Initialize(appName, experimentID)
+	// Nothing is left for swan-reap when experiment finishes without errors.
+	defer executor.TruncateArtifactManifest()
 
 	// Connect to metadata database.
 	metaData, err := metadata.NewDefault(experimentID)
@@ -30,7 +30,8 @@ func CollectingMetricsForCachingWorkload(experimentID string) {
 	//	Prepare executor.
 	workloadExecutorConfig := executor.DefaultOpenstackConfig(auth)
 	workloadExecutorConfig.Image = "krico_memcached"
-	workloadExecutor := executor.NewOpenstack(&workloadExecutorConfig)
+	workloadExecutor, err := newOpenstackExecutor(&workloadExecutorConfig)
+	errutil.CheckWithContext(err, "Cannot prepare Memcached executor!")
 
 	//	Prepare launcher.
 	workloadLauncher := memcached.New(workloadExecutor, workloadConfig)
@@ -39,6 +40,9 @@ func CollectingMetricsForCachingWorkload(experimentID string) {
 	workloadHandle, err := workloadLauncher.Launch()
 	errutil.CheckWithContext(err, "Cannot launch Memcached!")
 
+	//	Stop workload in the end of experiment.
+	defer workloadHandle.Stop()
+
 	//
 	//	Load generator
 	//
@@ -134,7 +138,8 @@ func ClassifyCachingWorkload(experimentID string) string {
 	//	Prepare executor.
 	workloadExecutorConfig := executor.DefaultOpenstackConfig(auth)
 	workloadExecutorConfig.Image = "krico_redis"
-	workloadExecutor := executor.NewOpenstack(&workloadExecutorConfig)
+	workloadExecutor, err := newOpenstackExecutor(&workloadExecutorConfig)
+	errutil.CheckWithContext(err, "Cannot prepare Redis executor!")
 
 	//	Prepare launcher.
 	workloadLauncher := redis.New(workloadExecutor, workloadConfig)
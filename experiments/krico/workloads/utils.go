@@ -9,6 +9,7 @@ import (
 	"github.com/intelsdi-x/swan/pkg/experiment"
 	"github.com/libvirt/libvirt-go"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -21,13 +22,29 @@ var (
 	loadGeneratorAddress = conf.NewStringFlag("loadgenerator_address", "IP address of load generator node.", "127.0.0.0")
 )
 
+// openstackPoolKey identifies pool of instances of the same image and flavor (flavor name is derived from its resources).
+type openstackPoolKey struct {
+	image            string
+	vcpus, ram, disk int
+}
+
+// String returns user-friendly description of instances of the pool.
+func (key openstackPoolKey) String() string {
+	return fmt.Sprintf("%q (%d VCPUs, %d MB RAM, %d GB disk)", key.image, key.vcpus, key.ram, key.disk)
+}
+
+// openstackPools holds pools of instances of each image and flavor, when instances are pooled (executor.OpenstackPoolSizeFlag).
+var openstackPools = map[openstackPoolKey]*executor.OpenstackPool{}
+
 // RunCollectingMetrics runs metric gathering experiment for each type of workload.
 func RunCollectingMetrics(experimentID string) {
+	defer deleteOpenstackPools()
 	CollectingMetricsForCachingWorkload(experimentID)
 }
 
 // RunWorkloadsClassification runs classification experiment for each type of workload. Return instances id.
 func RunWorkloadsClassification(experimentID string) []string {
+	defer deleteOpenstackPools()
 	var instances []string
 
 	instances = append(instances, ClassifyCachingWorkload(experimentID))
@@ -35,6 +52,36 @@ func RunWorkloadsClassification(experimentID string) []string {
 	return instances
 }
 
+// newOpenstackExecutor returns executor booting instance described by config for every task or, when instances are pooled,
+// executor handing out instances of the pool of config image and flavor. Pool is booted on first use and reused by following workloads.
+func newOpenstackExecutor(config *executor.OpenstackConfig) (executor.Executor, error) {
+	if executor.OpenstackPoolSizeFlag.Value() == 0 {
+		return executor.NewOpenstack(config), nil
+	}
+
+	key := openstackPoolKey{config.Image, config.Flavor.VCPUs, config.Flavor.RAM, config.Flavor.Disk}
+	pool, ok := openstackPools[key]
+	if !ok {
+		var err error
+		pool, err = executor.NewOpenstackPool(config, executor.DefaultOpenstackPoolConfig())
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot boot pool of %s instances!", key)
+		}
+		openstackPools[key] = pool
+	}
+	return pool.Executor(config), nil
+}
+
+// deleteOpenstackPools deletes instances of all the pools.
+func deleteOpenstackPools() {
+	for key, pool := range openstackPools {
+		if err := pool.Delete(); err != nil {
+			logrus.Errorf("Cannot delete pool of %s instances: %s", key, err.Error())
+		}
+		delete(openstackPools, key)
+	}
+}
+
 // StartSnapService starts Snap Telemetry Framework.
 func StartSnapService(address string) error {
 
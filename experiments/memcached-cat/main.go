@@ -61,6 +61,8 @@ func main() {
 
 	// Initialize logger.
 	logger.Initialize(appName, uid)
+	// Nothing is left for swan-reap when experiment finishes without errors.
+	defer executor.TruncateArtifactManifest()
 
 	// Connect to metadata database
 	metaData, err := metadata.NewDefault(uid)
@@ -326,7 +328,7 @@ func main() {
 					if err != nil {
 						logrus.Errorf("Experiment failed (%s): %+v", phaseName, err)
 						if stopOnError {
-							os.Exit(experiment.ExSoftware)
+							executor.Exit(experiment.ExSoftware)
 						}
 					}
 					totalIteration++
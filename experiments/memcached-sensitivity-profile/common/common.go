@@ -17,6 +17,7 @@ package common
 import (
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity"
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity/validate"
 	"github.com/intelsdi-x/swan/pkg/workloads/memcached"
 	"github.com/intelsdi-x/swan/pkg/workloads/mutilate"
@@ -39,6 +40,12 @@ var (
 		"Addresses where Mutilate Agents will be launched, separated by commas (e.g: \"192.168.1.1,192.168.1.2\" Agents generate actual load on Memcached.",
 		[]string{},
 	)
+
+	mutilateKubernetesAgentsFlag = conf.NewIntFlag(
+		"experiment_mutilate_kubernetes_agents",
+		"Number of Mutilate Agent pods run in parallel as Kubernetes Job in Kubernetes-native mode (\"kubernetes_native\" flag). Master only is run when 0.",
+		1,
+	)
 )
 
 // PrepareDefaultMutilateGenerator returns Mutilate load generator targeted at Memcached on default IP (from IPFlag).
@@ -48,19 +55,46 @@ func PrepareDefaultMutilateGenerator() (executor.LoadGenerator, error) {
 	return PrepareMutilateGenerator(memcachedIP, memcachedPort)
 }
 
-// PrepareMutilateGenerator creates new LoadGenerator based on mutilate.
-func PrepareMutilateGenerator(memcachedIP string, memcachedPort int) (executor.LoadGenerator, error) {
+// PrepareDryRunMutilateGenerator returns Mutilate load generator targeted at Memcached on default IP (from IPFlag)
+// that records commands instead of executing them.
+func PrepareDryRunMutilateGenerator(recorder *executor.DryRunRecorder) executor.LoadGenerator {
+	mutilateConfig := newMutilateConfig(memcached.IPFlag.Value(), memcached.PortFlag.Value())
+
+	agentsLoadGeneratorExecutors := []executor.Executor{}
+	for _, agent := range mutilateAgentsFlag.Value() {
+		agentsLoadGeneratorExecutors = append(agentsLoadGeneratorExecutors, executor.NewDryRun(recorder, agent))
+	}
+
+	return mutilate.NewCluster(
+		executor.NewDryRun(recorder, mutilateMasterFlag.Value()),
+		agentsLoadGeneratorExecutors,
+		mutilateConfig)
+}
+
+func newMutilateConfig(memcachedIP string, memcachedPort int) mutilate.Config {
 	mutilateConfig := mutilate.DefaultMutilateConfig()
 	mutilateConfig.MemcachedHost = memcachedIP
 	mutilateConfig.MemcachedPort = memcachedPort
 	mutilateConfig.LatencyPercentile = mutilatePercentileFlag.Value()
+	return mutilateConfig
+}
+
+// PrepareMutilateGenerator creates new LoadGenerator based on mutilate.
+func PrepareMutilateGenerator(memcachedIP string, memcachedPort int) (executor.LoadGenerator, error) {
+	mutilateConfig := newMutilateConfig(memcachedIP, memcachedPort)
+
+	// Starting master and agents is retried on transient failures (e.g. SSH connection failure).
+	// Load generator tasks are stopped by the experiment, so no deadline is enforced on them.
+	retryConfig := executor.DefaultRetryConfig()
+	retryConfig.Deadline = 0
 
 	agentsLoadGeneratorExecutors := []executor.Executor{}
 
-	masterLoadGeneratorExecutor, err := executor.NewShell(mutilateMasterFlag.Value())
+	masterShell, err := executor.NewShell(mutilateMasterFlag.Value())
 	if err != nil {
 		return nil, err
 	}
+	masterLoadGeneratorExecutor := executor.NewRetryExecutor(masterShell, retryConfig)
 
 	// Pack agents.
 	for _, agent := range mutilateAgentsFlag.Value() {
@@ -68,7 +102,7 @@ func PrepareMutilateGenerator(memcachedIP string, memcachedPort int) (executor.L
 		if err != nil {
 			return nil, err
 		}
-		agentsLoadGeneratorExecutors = append(agentsLoadGeneratorExecutors, remoteExecutor)
+		agentsLoadGeneratorExecutors = append(agentsLoadGeneratorExecutors, executor.NewRetryExecutor(remoteExecutor, retryConfig))
 	}
 	logrus.Debugf("Added %d mutilate agent(s) to mutilate cluster", len(agentsLoadGeneratorExecutors))
 
@@ -87,3 +121,25 @@ func PrepareMutilateGenerator(memcachedIP string, memcachedPort int) (executor.L
 
 	return mutilateLoadGenerator, nil
 }
+
+// PrepareKubernetesMutilateGenerator returns Mutilate load generator run in Kubernetes cluster and targeted at Memcached
+// exposed by Service at serviceAddress. Agents are run as Kubernetes Job with parallelism.
+func PrepareKubernetesMutilateGenerator(serviceAddress string, memcachedPort int) (executor.LoadGenerator, error) {
+	mutilateConfig := newMutilateConfig(serviceAddress, memcachedPort)
+
+	masterLoadGeneratorExecutor, agentsExecutor, err := sensitivity.BuildKubernetesLoadGeneratorExecutors(mutilateKubernetesAgentsFlag.Value(), mutilateConfig.AgentPort)
+	if err != nil {
+		return nil, err
+	}
+
+	agentsLoadGeneratorExecutors := []executor.Executor{}
+	if agentsExecutor != nil {
+		agentsLoadGeneratorExecutors = append(agentsLoadGeneratorExecutors, agentsExecutor)
+	}
+	logrus.Debugf("Mutilate cluster will run %d agent pod(s) in Kubernetes", mutilateKubernetesAgentsFlag.Value())
+
+	return mutilate.NewCluster(
+		masterLoadGeneratorExecutor,
+		agentsLoadGeneratorExecutors,
+		mutilateConfig), nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/intelsdi-x/swan/experiments/memcached-sensitivity-profile/common"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/experiment/hosttuning"
 	"github.com/intelsdi-x/swan/pkg/experiment/logger"
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity"
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity/validate"
@@ -33,6 +34,7 @@ import (
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 	_ "github.com/intelsdi-x/swan/pkg/utils/unshare"
 	"github.com/intelsdi-x/swan/pkg/utils/uuid"
+	"github.com/intelsdi-x/swan/pkg/workloads/memcached"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -46,11 +48,20 @@ func main() {
 	experimentStart := time.Now()
 	experiment.Configure()
 
+	// Print experiment plan without launching anything when requested.
+	if sensitivity.DryRunFlag.Value() {
+		err := printDryRunPlan()
+		errutil.CheckWithContext(err, "Cannot prepare experiment plan")
+		return
+	}
+
 	// Generate an experiment ID and start the metadata session.
 	uid := uuid.New()
 
 	// Initialize logger.
 	logger.Initialize(appName, uid)
+	// Nothing is left for swan-reap when experiment finishes without errors.
+	defer executor.TruncateArtifactManifest()
 
 	metaData, err := metadata.NewDefault(uid)
 
@@ -60,8 +71,24 @@ func main() {
 	err = metadata.RecordRuntimeEnv(metaData, experimentStart)
 	errutil.CheckWithContext(err, "Cannot save runtime environment in Cassandra Metadata Database")
 
-	// Validate preconditions.
-	validate.OS()
+	preflightConfig := validate.DefaultPreflightConfig(sensitivity.Memcached)
+
+	// Apply host tuning profile and make sure it is reverted when experiment finishes.
+	tuningProfile, err := hosttuning.NewProfileFromFlag(preflightConfig.HPThreads)
+	errutil.CheckWithContext(err, "Cannot prepare host tuning profile")
+	hostTuning := hosttuning.NewManager(tuningProfile)
+	err = hostTuning.Apply()
+	errutil.CheckWithContext(err, "Cannot apply host tuning profile")
+	defer hostTuning.Restore()
+	err = hostTuning.Record(metaData)
+	errutil.CheckWithContext(err, "Cannot save host tuning profile in metadata database")
+
+	// Validate preconditions and store the report alongside experiment metadata.
+	preflightReport := validate.Preflight(preflightConfig)
+	preflightReport.Log()
+	err = preflightReport.Record(metaData)
+	errutil.CheckWithContext(err, "Cannot save pre-flight validation report in metadata database")
+	errutil.CheckWithContext(preflightReport.Err(validate.StrictFlag.Value()), "Environment is not ready for the experiment")
 
 	// Launch Kubernetes cluster.
 	if experiment.ShouldLaunchKubernetesCluster() {
@@ -76,13 +103,23 @@ func main() {
 
 	factory := sensitivity.NewDefaultWorkloadFactory()
 
+	// Load generator. In Kubernetes-native mode it is run in the cluster and reaches memcached through Service.
+	var loadGenerator executor.LoadGenerator
+	if sensitivity.RunKubernetesNative() {
+		hpService, err := sensitivity.NewHighPriorityService(memcached.PortFlag.Value())
+		errutil.CheckWithContext(err, "Cannot expose memcached through Kubernetes Service")
+		defer hpService.Delete()
+		factory.SetHighPriorityAddress(hpService.Address())
+		loadGenerator, err = common.PrepareKubernetesMutilateGenerator(hpService.Address(), memcached.PortFlag.Value())
+		errutil.CheckWithContext(err, "cannot prepare load generator")
+	} else {
+		loadGenerator, err = common.PrepareDefaultMutilateGenerator()
+		errutil.CheckWithContext(err, "cannot prepare load generator")
+	}
+
 	hpLauncher, err := factory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, tuningTags)
 	errutil.CheckWithContext(err, "cannot prepare memcached")
 
-	// Load generator.
-	loadGenerator, err := common.PrepareDefaultMutilateGenerator()
-	errutil.CheckWithContext(err, "cannot prepare load generator")
-
 	// Retrieve peak load from flags and overwrite it when required.
 	load := sensitivity.PeakLoadFlag.Value()
 	if load == sensitivity.RunTuningPhase {
@@ -99,6 +136,7 @@ func main() {
 	loadPoints := sensitivity.LoadPointsCountFlag.Value()
 	repetitions := sensitivity.RepetitionsFlag.Value()
 	loadDuration := sensitivity.LoadDurationFlag.Value()
+	retryConfig := executor.DefaultRetryConfig()
 
 	// Record metadata.
 	records := map[string]string{
@@ -142,7 +180,7 @@ func main() {
 
 					hpLauncher, err := factory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, snapTags)
 					errutil.CheckWithContext(err, "cannot prepare memcached")
-					hpHandle, err := hpLauncher.Launch()
+					hpHandle, err := executor.NewRetryLauncher(hpLauncher, retryConfig).Launch()
 					if err != nil {
 						return errors.Wrapf(err, "cannot launch memcached in %s", phaseName)
 					}
@@ -158,7 +196,7 @@ func main() {
 					// Launch BE tasks when we are not in baseline.
 					var beHandle executor.TaskHandle
 					if beLauncher != nil {
-						beHandle, err := beLauncher.Launch()
+						beHandle, err := executor.NewRetryLauncher(beLauncher, retryConfig).Launch()
 						if err != nil {
 							return errors.Wrapf(err, "cannot launch aggressor %q, in phase %q", beLauncher, phaseName)
 						}
@@ -228,18 +266,34 @@ func main() {
 				err := executeRepetition()
 
 				// Collecting all the errors that might have been encountered.
-				errColl := &errcollection.ErrorCollection{}
-				errColl.Add(err)
+				// Timeouts and infrastructure errors are told apart from workload failures before errors are combined.
+				errs := []error{err}
 				for _, th := range processes {
-					errColl.Add(th.Stop())
+					errs = append(errs, th.Stop())
+				}
+				// QoS class, cgroup and termination reason of pods tell whether Kubernetes interfered with the phase.
+				if recordErr := sensitivity.RecordPodStatuses(metaData, phaseName, processes...); recordErr != nil {
+					logrus.Errorf("Cannot record statuses of pods in phase %q: %q", phaseName, recordErr.Error())
+				}
+				errColl := &errcollection.ErrorCollection{}
+				failure := "workload failure"
+				for _, e := range errs {
+					errColl.Add(e)
+					if executor.IsTimeout(e) {
+						failure = "task deadline exceeded"
+					} else if executor.IsEvicted(e) && failure != "task deadline exceeded" {
+						failure = "pod eviction"
+					} else if executor.IsRetryable(e) && failure == "workload failure" {
+						failure = "infrastructure failure"
+					}
 				}
 
 				// If any error was found then we should log details and terminate the experiment if stopOnError is set.
 				err = errColl.GetErrIfAny()
 				if err != nil {
-					logrus.Errorf("Experiment failed (%s): %q", phaseName, err.Error())
+					logrus.Errorf("Experiment failed (%s) with %s: %q", phaseName, failure, err.Error())
 					if stopOnError {
-						os.Exit(experiment.ExSoftware)
+						executor.Exit(experiment.ExSoftware)
 					}
 				}
 			}
@@ -247,3 +301,53 @@ func main() {
 	}
 	logrus.Infof("Experiment %s with uid %s has ended in %s", appName, uid, time.Since(experimentStart).String())
 }
+
+// printDryRunPlan goes through all the experiment phases using DryRun executors and prints
+// decorated commands, target hosts, load and estimated runtime.
+func printDryRunPlan() error {
+	recorder := executor.NewDryRunRecorder()
+	factory := sensitivity.NewWorkloadFactory(sensitivity.NewDryRunExecutorFactory(recorder))
+	// Snap daemon is not required to print the plan.
+	factory.DisableMetricsSessions()
+	loadGenerator := common.PrepareDryRunMutilateGenerator(recorder)
+
+	loadPoints := sensitivity.LoadPointsCountFlag.Value()
+	plan := sensitivity.DryRunPlan{
+		Name:       appName,
+		PeakLoad:   sensitivity.PeakLoadFlag.Value(),
+		LoadPoints: loadPoints,
+	}
+
+	for _, bestEffortWorkloadName := range sensitivity.AggressorsFlag.Value() {
+		for loadPoint := 0; loadPoint < loadPoints; loadPoint++ {
+			phaseQPS := int(plan.PeakLoad / loadPoints * (loadPoint + 1))
+			for repetition := 0; repetition < sensitivity.RepetitionsFlag.Value(); repetition++ {
+				hpLauncher, err := factory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, nil)
+				if err != nil {
+					return err
+				}
+				beLauncher, err := factory.BuildDefaultBestEffortLauncher(bestEffortWorkloadName, nil)
+				if err != nil {
+					return err
+				}
+
+				commands, err := sensitivity.DryRunRepetition(recorder, hpLauncher, beLauncher, loadGenerator, phaseQPS, sensitivity.LoadDurationFlag.Value())
+				if err != nil {
+					return err
+				}
+
+				plan.Phases = append(plan.Phases, sensitivity.DryRunPhase{
+					Name:       fmt.Sprintf("Aggressor %s; load point %d; repetition %d", bestEffortWorkloadName, loadPoint, repetition),
+					LoadPoint:  loadPoint,
+					Repetition: repetition,
+					QPS:        phaseQPS,
+					Duration:   sensitivity.LoadDurationFlag.Value(),
+					Commands:   commands,
+				})
+			}
+		}
+	}
+
+	plan.Print(os.Stdout)
+	return nil
+}
@@ -58,6 +58,8 @@ func main() {
 
 	// Initialize logger.
 	logger.Initialize(appName, uid)
+	// Nothing is left for swan-reap when experiment finishes without errors.
+	defer executor.TruncateArtifactManifest()
 
 	// connect to metadata database
 	metaData, err := metadata.NewDefault(uid)
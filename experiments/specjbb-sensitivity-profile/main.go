@@ -51,6 +51,8 @@ func main() {
 	// Generate an experiment ID and start the metadata session.
 	uid := uuid.New() // Initialize logger.
 	logger.Initialize(appName, uid)
+	// Nothing is left for swan-reap when experiment finishes without errors.
+	defer executor.TruncateArtifactManifest()
 	// Create metadata associated with experiment
 	metaData, err := metadata.NewDefault(uid)
 	errutil.Check(err)
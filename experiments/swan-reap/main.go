@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/utils/errutil"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	defaultConfig = executor.DefaultReaperConfig()
+
+	hostsFlag             = conf.NewStringSliceFlag("reap_hosts", "Addresses of hosts where cgroups and resctrl groups are cleaned, separated by commas. Remote hosts are accessed with SSH (see remote_ssh_* flags).", defaultConfig.Hosts)
+	prefixFlag            = conf.NewStringFlag("reap_prefix", "Prefix of cgroups, resctrl groups and Kubernetes pods to be removed.", defaultConfig.Prefix)
+	cgroupRootFlag        = conf.NewStringFlag("reap_cgroup_root", "Mount point of cgroup controllers.", defaultConfig.CgroupRoot)
+	resctrlRootFlag       = conf.NewStringFlag("reap_resctrl_root", "Mount point of resctrl filesystem.", defaultConfig.ResctrlRoot)
+	kubernetesAddressFlag = conf.NewStringFlag("reap_kubernetes_address", "Address of Kubernetes API server (pods are not removed when empty).", defaultConfig.KubernetesAddress)
+	removeFlag            = conf.NewBoolFlag("reap_remove", "Remove discovered artifacts. Artifacts are only listed by default.", false)
+)
+
+// swan-reap removes artifacts left behind by experiments after dirty shutdown:
+// processes, containers, systemd units and output directories recorded by executors in artifact manifest,
+// Kubernetes pods, cgroups and resctrl groups.
+func main() {
+	experiment.Configure()
+
+	config := defaultConfig
+	config.Hosts = hostsFlag.Value()
+	config.Prefix = prefixFlag.Value()
+	config.CgroupRoot = cgroupRootFlag.Value()
+	config.ResctrlRoot = resctrlRootFlag.Value()
+	config.ManifestFile = executor.ArtifactManifestFlag.Value()
+	config.KubernetesAddress = kubernetesAddressFlag.Value()
+	if config.ManifestFile == "" {
+		logrus.Warnf("Processes, containers, systemd units and output directories are not looked for: set %s to artifact manifest of the experiment", "ARTIFACT_MANIFEST")
+	}
+
+	artifacts, err := executor.NewReaper(config).Discover()
+	if err != nil {
+		logrus.Errorf("Some artifacts could not be discovered: %s", err.Error())
+	}
+
+	for _, artifact := range artifacts {
+		fmt.Println(artifact)
+	}
+	fmt.Printf("%d artifacts found\n", len(artifacts))
+
+	if !removeFlag.Value() {
+		if len(artifacts) > 0 {
+			fmt.Printf("Run with %s=true to remove them\n", "REAP_REMOVE")
+		}
+		return
+	}
+
+	err = executor.Reap(artifacts)
+	errutil.CheckWithContext(err, "Some artifacts could not be removed")
+	fmt.Printf("%d artifacts removed\n", len(artifacts))
+}
@@ -189,6 +189,33 @@ func TestKubernetesExecutor(t *testing.T) {
 			So(output, ShouldContain, "This is England")
 		})
 
+		Convey("Paused pod should not make progress until it is resumed", func() {
+			taskHandle, err := k8sexecutor.Execute("i=0; while true; do i=$((i+1)); echo $i; sleep 0.1; done")
+			So(err, ShouldBeNil)
+			defer executor.StopAndEraseOutput(taskHandle)
+
+			outputSize := func() int64 {
+				stdout, err := taskHandle.StdoutFile()
+				So(err, ShouldBeNil)
+				defer stdout.Close()
+				info, err := stdout.Stat()
+				So(err, ShouldBeNil)
+				return info.Size()
+			}
+
+			time.Sleep(time.Second)
+			So(taskHandle.Pause(), ShouldBeNil)
+			// Let the lines printed before the pod was paused reach the output file.
+			time.Sleep(2 * time.Second)
+			pausedSize := outputSize()
+			time.Sleep(2 * time.Second)
+			So(outputSize(), ShouldEqual, pausedSize)
+
+			So(taskHandle.Resume(), ShouldBeNil)
+			time.Sleep(2 * time.Second)
+			So(outputSize(), ShouldBeGreaterThan, pausedSize)
+		})
+
 		Convey("Long running pod is not deadlocked when deleted externally", func() {
 			executorConfig.PodName = "mypod"
 			k8sexecutor, err := executor.NewKubernetes(executorConfig)
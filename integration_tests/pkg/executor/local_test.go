@@ -18,6 +18,7 @@ import (
 	"os/exec"
 	"os/user"
 	"testing"
+	"time"
 
 	. "github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/isolation"
@@ -58,6 +59,46 @@ func TestLocal(t *testing.T) {
 		})
 	})
 
+	Convey("When local task is paused", t, func() {
+		task, err := NewLocal().Execute("sleep 1 && echo resumed")
+		So(err, ShouldBeNil)
+		defer task.EraseOutput()
+		defer task.Stop()
+
+		So(task.Pause(), ShouldBeNil)
+
+		Convey("It should not terminate until it is resumed", func() {
+			terminated, err := task.Wait(2 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeFalse)
+
+			So(task.Resume(), ShouldBeNil)
+			terminated, err = task.Wait(5 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+
+			exitCode, err := task.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+		})
+	})
+
+	Convey("When local task has children ignoring SIGTERM", t, func() {
+		task, err := NewLocal().Execute("trap '' TERM; setsid sleep 100 & sleep 100")
+		So(err, ShouldBeNil)
+		defer task.EraseOutput()
+		time.Sleep(100 * time.Millisecond)
+
+		Convey("Stop should kill whole process tree and report it", func() {
+			So(task.Stop(), ShouldBeNil)
+			So(task.Status(), ShouldEqual, TERMINATED)
+			So(GetStopStage(task), ShouldEqual, StoppedForcefully)
+
+			output, _ := exec.Command("pgrep", "-f", "sleep 100").Output()
+			So(string(output), ShouldBeEmpty)
+		})
+	})
+
 	Convey("While using Local Shell using cgroups", t, func() {
 		user, err := user.Current()
 		if err != nil {
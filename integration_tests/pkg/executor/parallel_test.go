@@ -28,6 +28,14 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 )
 
+// exitDecorator makes command exit with given code.
+// Exit is delayed, so failing clone is not reported as failed to start by Local executor.
+type exitDecorator int
+
+func (code exitDecorator) Decorate(command string) string {
+	return fmt.Sprintf("sleep 0.5; %s; exit %d", command, code)
+}
+
 func TestParallel(t *testing.T) {
 	file, err := ioutil.TempFile(".", "parallel")
 	if err != nil {
@@ -35,27 +43,27 @@ func TestParallel(t *testing.T) {
 	}
 	defer os.Remove(file.Name())
 
-	Convey("When using Parallel to decorate local executor", t, func() {
-		parallel := executor.NewLocalIsolated(executor.NewParallel(5))
+	Convey("When using Parallel with local executor", t, func() {
+		parallel := executor.NewParallel(executor.NewLocal(), 5)
 		Convey("Process should be executed 5 times", func() {
 			cmdStr := fmt.Sprintf("tailf %s", file.Name())
 			task, err := parallel.Execute(cmdStr)
+			So(err, ShouldBeNil)
+			So(task, ShouldNotBeNil)
 			defer task.EraseOutput()
 			defer task.Stop()
 
-			So(err, ShouldBeNil)
-			So(task, ShouldNotBeNil)
-			// NOTE: We have to wait a bit for parallel to launch commands, though.
 			isStopped, err := task.Wait(1000 * time.Millisecond)
 			So(err, ShouldBeNil)
 			So(isStopped, ShouldBeFalse)
+			So(task.(*executor.ParallelTaskHandle).Clones(), ShouldHaveLength, 5)
 
 			cmd := exec.Command("pgrep", "-f", cmdStr)
 			output, err := cmd.CombinedOutput()
 			So(err, ShouldBeNil)
 
 			pids := strings.Split(strings.TrimSpace(string(output)), "\n")
-			So(len(pids), ShouldBeGreaterThan, 0)
+			So(len(pids), ShouldBeGreaterThanOrEqualTo, 5)
 			Convey("When I stop parallel process", func() {
 				err = task.Stop()
 
@@ -73,4 +81,36 @@ func TestParallel(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("When clones of parallel task exit with different codes", t, func() {
+		parallel := executor.NewParallelWithDecorators(executor.NewLocal(), exitDecorator(0), exitDecorator(3), exitDecorator(0))
+		task, err := parallel.Execute("echo clone")
+		So(err, ShouldBeNil)
+		defer task.EraseOutput()
+
+		isStopped, err := task.Wait(0)
+		So(err, ShouldBeNil)
+		So(isStopped, ShouldBeTrue)
+
+		Convey("Exit code of every clone should be available", func() {
+			exitCodes, err := task.(*executor.ParallelTaskHandle).ExitCodes()
+			So(err, ShouldBeNil)
+			So(exitCodes, ShouldResemble, []int{0, 3, 0})
+
+			exitCode, err := task.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 3)
+		})
+
+		Convey("Every clone should have its own output", func() {
+			for _, clone := range task.(*executor.ParallelTaskHandle).Clones() {
+				stdout, err := clone.StdoutFile()
+				So(err, ShouldBeNil)
+				output, err := ioutil.ReadAll(stdout)
+				stdout.Close()
+				So(err, ShouldBeNil)
+				So(string(output), ShouldEqual, "clone\n")
+			}
+		})
+	})
 }
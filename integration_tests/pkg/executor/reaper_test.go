@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"syscall"
+	"testing"
+
+	. "github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReaper(t *testing.T) {
+	Convey("When artifacts are left behind on local machine", t, func() {
+		root, err := ioutil.TempDir("", "reaper")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		for _, directory := range []string{
+			"cgroup/cpuset/swan/hp",
+			"cgroup/cpuset/system",
+			"resctrl/swan-be",
+			"resctrl/info",
+			"output/local_memcached_123",
+			"output/results",
+		} {
+			So(os.MkdirAll(path.Join(root, directory), 0755), ShouldBeNil)
+		}
+
+		recorded := startProcessGroup("sleep", "300")
+		defer recorded.Process.Kill()
+		notRecorded := startProcessGroup("sleep", "301")
+		defer notRecorded.Process.Kill()
+
+		manifest := path.Join(root, "swan_artifacts.json")
+		content := fmt.Sprintf(`{"kind":"process","host":"127.0.0.1","name":"sleep 300","pid":%d}`+"\n", recorded.Process.Pid) +
+			fmt.Sprintf(`{"kind":"output directory","host":"127.0.0.1","name":%q}`+"\n", path.Join(root, "output/local_memcached_123"))
+		So(ioutil.WriteFile(manifest, []byte(content), 0644), ShouldBeNil)
+
+		config := DefaultReaperConfig()
+		config.ManifestFile = manifest
+		config.CgroupRoot = path.Join(root, "cgroup")
+		config.ResctrlRoot = path.Join(root, "resctrl")
+		reaper := NewReaper(config)
+
+		Convey("Only recorded artifacts and artifacts with Swan names should be discovered", func() {
+			artifacts, err := reaper.Discover()
+			So(err, ShouldBeNil)
+			So(artifacts, ShouldHaveLength, 4)
+			So(artifacts[0].Kind, ShouldEqual, ProcessArtifact)
+			So(artifacts[0].Name, ShouldEqual, fmt.Sprintf("sleep 300 (pid %d)", recorded.Process.Pid))
+			So(artifacts[1].Kind, ShouldEqual, CgroupArtifact)
+			So(artifacts[1].Name, ShouldEqual, path.Join(root, "cgroup/cpuset/swan"))
+			So(artifacts[2].Kind, ShouldEqual, ResctrlArtifact)
+			So(artifacts[3].Kind, ShouldEqual, OutputDirectoryArtifact)
+			So(artifacts[3].Name, ShouldEqual, path.Join(root, "output/local_memcached_123"))
+
+			Convey("And only they should be removed", func() {
+				So(Reap(artifacts), ShouldBeNil)
+				So(mustList(root, "cgroup/cpuset"), ShouldResemble, []string{"system"})
+				So(mustList(root, "resctrl"), ShouldResemble, []string{"info"})
+				So(mustList(root, "output"), ShouldResemble, []string{"results"})
+
+				recorded.Wait()
+				So(recorded.ProcessState.Exited(), ShouldBeFalse)
+				So(notRecorded.Process.Signal(syscall.Signal(0)), ShouldBeNil)
+
+				Convey("And dropped from the manifest", func() {
+					artifacts, err := reaper.Discover()
+					So(err, ShouldBeNil)
+					So(artifacts, ShouldHaveLength, 0)
+				})
+			})
+		})
+	})
+}
+
+// startProcessGroup starts process that is a process group leader, like processes launched by Local executor.
+func startProcessGroup(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	So(cmd.Start(), ShouldBeNil)
+	return cmd
+}
+
+func mustList(root, directory string) (names []string) {
+	infos, err := ioutil.ReadDir(path.Join(root, directory))
+	So(err, ShouldBeNil)
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names
+}
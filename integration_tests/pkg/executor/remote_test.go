@@ -15,7 +15,11 @@
 package executor
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
+	"time"
 
 	. "github.com/intelsdi-x/swan/pkg/executor"
 	. "github.com/smartystreets/goconvey/convey"
@@ -35,5 +39,48 @@ func TestRemote(t *testing.T) {
 		Convey("And while using Remote Shell, the generic Executor test should pass", func() {
 			testExecutor(t, remote)
 		})
+
+		Convey("Files should be uploaded and downloaded", func() {
+			directory, err := ioutil.TempDir("", "remote")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(directory)
+
+			source := path.Join(directory, "source")
+			So(ioutil.WriteFile(source, []byte("swan"), 0755), ShouldBeNil)
+
+			uploaded := path.Join(directory, "remote", "uploaded")
+			So(remote.(Remote).Upload(source, uploaded), ShouldBeNil)
+			info, err := os.Stat(uploaded)
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, 0755)
+
+			downloaded := path.Join(directory, "local", "downloaded")
+			So(remote.(Remote).Download(uploaded, downloaded), ShouldBeNil)
+			content, err := ioutil.ReadFile(downloaded)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "swan")
+		})
+
+		Convey("Connection should be healthy", func() {
+			So(remote.(Remote).HealthCheck(), ShouldBeNil)
+		})
+
+		Convey("Many tasks should be multiplexed over pooled connections", func() {
+			var handles []TaskHandle
+			for i := 0; i < 25; i++ {
+				handle, err := remote.Execute("sleep 1")
+				So(err, ShouldBeNil)
+				handles = append(handles, handle)
+			}
+			for _, handle := range handles {
+				terminated, err := handle.Wait(10 * time.Second)
+				So(err, ShouldBeNil)
+				So(terminated, ShouldBeTrue)
+				exitCode, err := handle.ExitCode()
+				So(err, ShouldBeNil)
+				So(exitCode, ShouldEqual, 0)
+				So(handle.EraseOutput(), ShouldBeNil)
+			}
+		})
 	})
 }
@@ -0,0 +1,156 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArtifactManifestFlag is a path to the file where executors record artifacts they create.
+var ArtifactManifestFlag = conf.NewStringFlag("artifact_manifest", "File where experiment executors record processes, containers, systemd units and output directories they create, "+
+	"so that swan-reap removes only those. When empty, experiment records them in "+artifactManifestName+" in its logs directory.", "")
+
+// artifactManifestName is a name of the manifest created in experiment logs directory.
+const artifactManifestName = "swan_artifacts.json"
+
+// localHost is the host name under which artifacts created on experiment host are recorded.
+const localHost = "127.0.0.1"
+
+// recordedArtifact is a single manifest entry (manifest has one JSON object per line).
+type recordedArtifact struct {
+	Kind string `json:"kind"`
+	Host string `json:"host"`
+	// Name is a path, container name, unit name or command of a process.
+	Name string `json:"name"`
+	// PID of process group leader. Only known for local processes.
+	PID int `json:"pid,omitempty"`
+}
+
+var (
+	manifestMutex sync.Mutex
+	// manifestPath is empty until experiment enables recording, so tools (e.g. swan-reap) do not record their own commands.
+	manifestPath string
+)
+
+// EnableArtifactManifest makes executors record artifacts they create in ArtifactManifestFlag file
+// or in manifest in experimentDirectory when the flag is empty. Path of the manifest is returned.
+func EnableArtifactManifest(experimentDirectory string) string {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+	manifestPath = ArtifactManifestFlag.Value()
+	if manifestPath == "" {
+		manifestPath = path.Join(experimentDirectory, artifactManifestName)
+	}
+	return manifestPath
+}
+
+// TruncateArtifactManifest removes all the entries from the manifest. It should be called after experiment
+// was shut down cleanly, when recorded artifacts no longer exist.
+func TruncateArtifactManifest() error {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+	if manifestPath == "" {
+		return nil
+	}
+	err := os.Truncate(manifestPath, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return errors.Wrapf(err, "cannot truncate artifact manifest %q", manifestPath)
+}
+
+// recordArtifact appends artifact to the manifest. Failure is logged only as it must not stop the experiment.
+func recordArtifact(artifact recordedArtifact) {
+	line, err := json.Marshal(artifact)
+	if err != nil {
+		log.Warnf("Cannot encode %s %q for artifact manifest: %s", artifact.Kind, artifact.Name, err.Error())
+		return
+	}
+
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+	path := manifestPath
+	if path == "" {
+		return
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, outputFilePrivileges)
+	if err != nil {
+		log.Warnf("Cannot open artifact manifest %q: %s", path, err.Error())
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		log.Warnf("Cannot record %s %q in artifact manifest %q: %s", artifact.Kind, artifact.Name, path, err.Error())
+	}
+}
+
+// readArtifactManifest returns entries of the manifest and its size. Missing manifest is empty.
+func readArtifactManifest(path string) ([]recordedArtifact, int, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot read artifact manifest %q", path)
+	}
+
+	var artifacts []recordedArtifact
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var artifact recordedArtifact
+		if err := json.Unmarshal(scanner.Bytes(), &artifact); err != nil {
+			return nil, 0, errors.Wrapf(err, "cannot decode artifact manifest %q entry %q", path, scanner.Text())
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, len(content), scanner.Err()
+}
+
+// pruneArtifactManifest rewrites the manifest with given entries, keeping entries appended after first size bytes were read.
+func pruneArtifactManifest(path string, artifacts []recordedArtifact, size int) error {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot read artifact manifest %q", path)
+	}
+
+	var buffer bytes.Buffer
+	for _, artifact := range artifacts {
+		line, err := json.Marshal(artifact)
+		if err != nil {
+			return errors.Wrapf(err, "cannot encode %s %q", artifact.Kind, artifact.Name)
+		}
+		buffer.Write(append(line, '\n'))
+	}
+	if len(content) > size {
+		buffer.Write(content[size:])
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, buffer.Bytes(), outputFilePrivileges), "cannot write artifact manifest %q", path)
+}
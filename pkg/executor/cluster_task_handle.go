@@ -72,6 +72,40 @@ func (m *ClusterTaskHandle) Stop() (err error) {
 	return errCollection.GetErrIfAny()
 }
 
+// Pause suspends the master and all the agents.
+func (m *ClusterTaskHandle) Pause() error {
+	var errCollection errcollection.ErrorCollection
+	errCollection.Add(m.master.Pause())
+	for _, handle := range m.agents {
+		errCollection.Add(handle.Pause())
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// Resume continues the agents and then the master.
+func (m *ClusterTaskHandle) Resume() error {
+	var errCollection errcollection.ErrorCollection
+	for _, handle := range m.agents {
+		errCollection.Add(handle.Resume())
+	}
+	errCollection.Add(m.master.Resume())
+	return errCollection.GetErrIfAny()
+}
+
+// StopStage returns the stage that terminated the master.
+func (m *ClusterTaskHandle) StopStage() StopStage {
+	return GetStopStage(m.master)
+}
+
+// PodStatuses implements PodStatusReporter interface. Statuses of pods of the master and all the agents are returned.
+func (m *ClusterTaskHandle) PodStatuses() []PodStatus {
+	statuses := GetPodStatuses(m.master)
+	for _, agent := range m.agents {
+		statuses = append(statuses, GetPodStatuses(agent)...)
+	}
+	return statuses
+}
+
 // Status returns the state of the master.
 func (m *ClusterTaskHandle) Status() TaskState {
 	return m.master.Status()
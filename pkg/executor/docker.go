@@ -0,0 +1,242 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/utils/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	dockerImageFlag       = conf.NewStringFlag("docker_image", "Name of the container image used by Docker executor. It needs to be available locally or downloadable.", defaultContainerImage)
+	dockerStopTimeoutFlag = conf.NewDurationFlag("docker_stop_timeout", "Time given to Docker to kill the container when task is stopped.", 10*time.Second)
+)
+
+// DockerConfig describes container started by Docker executor.
+type DockerConfig struct {
+	// Binary is a path to docker client.
+	Binary string
+	// NamePrefix is a prefix of random generated container name.
+	NamePrefix string
+	Image      string
+	// CPUSet limits CPUs available in the container (--cpuset-cpus). Not limited when empty.
+	CPUSet isolation.IntSet
+	// CPUQuota limits number of CPUs available in the container (--cpus). Not limited when 0.
+	CPUQuota float64
+	// MemoryLimit in bytes (--memory). Not limited when 0.
+	MemoryLimit int64
+	HostNetwork bool
+	Privileged  bool
+	// Decorators are applied to the command inside container.
+	Decorators isolation.Decorators
+	// Volumes are mounted into container (--volume), e.g. "/tmp:/tmp".
+	Volumes []string
+}
+
+// DefaultDockerConfig returns DockerConfig with safe defaults.
+func DefaultDockerConfig() DockerConfig {
+	return DockerConfig{
+		Binary:      "docker",
+		NamePrefix:  "swan",
+		Image:       dockerImageFlag.Value(),
+		CPUSet:      isolation.NewIntSet(),
+		HostNetwork: false,
+		Privileged:  false,
+		Decorators:  isolation.Decorators{},
+	}
+}
+
+// Docker executor runs tasks in containers on local host using Docker client.
+// Container stdout and stderr are written to the usual executor output files.
+type Docker struct {
+	config DockerConfig
+}
+
+// NewDocker returns Docker executor.
+func NewDocker(config DockerConfig) Executor {
+	return Docker{config: config}
+}
+
+// String returns user-friendly name of executor.
+func (docker Docker) String() string {
+	return fmt.Sprintf("Docker Executor using image %q", docker.config.Image)
+}
+
+// Execute runs the command in a new container.
+func (docker Docker) Execute(command string) (TaskHandle, error) {
+	containerName := fmt.Sprintf("%s-%s", docker.config.NamePrefix, uuid.New()[:8])
+	dockerCommand := docker.runCommand(containerName, command)
+
+	log.Debugf("Docker Executor: starting %q in container %q", command, containerName)
+	handle, err := NewLocal().Execute(dockerCommand)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot start container %q", containerName)
+	}
+	recordArtifact(recordedArtifact{Kind: ContainerArtifact, Host: localHost, Name: containerName})
+
+	taskHandle := &dockerTaskHandle{
+		TaskHandle:    handle,
+		binary:        docker.config.Binary,
+		containerName: containerName,
+		command:       command,
+	}
+	RegisterTaskHandle(taskHandle)
+	return taskHandle, nil
+}
+
+// runCommand builds docker client command line. Container is removed when it exits.
+func (docker Docker) runCommand(containerName, command string) string {
+	arguments := []string{docker.config.Binary, "run", "--rm", "--name", containerName}
+
+	if !docker.config.CPUSet.Empty() {
+		arguments = append(arguments, "--cpuset-cpus", docker.config.CPUSet.AsRangeString())
+	}
+	if docker.config.CPUQuota > 0 {
+		arguments = append(arguments, "--cpus", fmt.Sprintf("%g", docker.config.CPUQuota))
+	}
+	if docker.config.MemoryLimit > 0 {
+		arguments = append(arguments, "--memory", fmt.Sprintf("%d", docker.config.MemoryLimit))
+	}
+	if docker.config.HostNetwork {
+		arguments = append(arguments, "--network", "host")
+	}
+	if docker.config.Privileged {
+		arguments = append(arguments, "--privileged")
+	}
+	for _, volume := range docker.config.Volumes {
+		arguments = append(arguments, "--volume", volume)
+	}
+
+	decoratedCommand := docker.config.Decorators.Decorate(command)
+	arguments = append(arguments, docker.config.Image, "sh", "-c", shellQuote(decoratedCommand))
+
+	return strings.Join(arguments, " ")
+}
+
+// shellQuote wraps argument in single quotes, so it is passed to the container unchanged.
+func shellQuote(argument string) string {
+	return "'" + strings.Replace(argument, "'", `'\''`, -1) + "'"
+}
+
+// dockerTaskHandle controls docker client process and the container it started.
+type dockerTaskHandle struct {
+	TaskHandle
+	binary        string
+	containerName string
+	command       string
+
+	mutex     sync.Mutex
+	stopStage StopStage
+}
+
+// Stop sends SIGTERM to the container, kills it when it does not exit within grace period
+// and waits for docker client to exit.
+func (handle *dockerTaskHandle) Stop() error {
+	if handle.Status() == TERMINATED {
+		return nil
+	}
+
+	policy := DefaultStopPolicy()
+	policy.KillTimeout = dockerStopTimeoutFlag.Value()
+	stage, err := policy.Stop(handle.TaskHandle,
+		func() error { return handle.signal("TERM") },
+		func() error { return handle.signal("KILL") })
+	if err != nil {
+		log.Warnf("Docker Executor: cannot stop container %q, removing it: %s", handle.containerName, err.Error())
+		// Stopping docker client would leave the container running, so container is removed instead.
+		if err := handle.run("rm", "--force"); err != nil {
+			return err
+		}
+		if terminated, _ := handle.TaskHandle.Wait(dockerStopTimeoutFlag.Value()); !terminated {
+			return errors.Errorf("docker client has not exited after container %q was removed", handle.containerName)
+		}
+		stage = StoppedForcefully
+	}
+
+	handle.mutex.Lock()
+	handle.stopStage = stage
+	handle.mutex.Unlock()
+	return nil
+}
+
+// StopStage implements StopStageReporter interface.
+func (handle *dockerTaskHandle) StopStage() StopStage {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+	return handle.stopStage
+}
+
+func (handle *dockerTaskHandle) signal(signal string) error {
+	output, err := exec.Command(handle.binary, "kill", "--signal", signal, handle.containerName).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "cannot send SIG%s to container %q: %s", signal, handle.containerName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Pause freezes all processes in the container.
+func (handle *dockerTaskHandle) Pause() error {
+	return handle.run("pause")
+}
+
+// Resume thaws all processes in the container.
+func (handle *dockerTaskHandle) Resume() error {
+	return handle.run("unpause")
+}
+
+func (handle *dockerTaskHandle) run(command string, options ...string) error {
+	arguments := append(append([]string{command}, options...), handle.containerName)
+	output, err := exec.Command(handle.binary, arguments...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "cannot %s container %q: %s", command, handle.containerName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Resize implements Resizable interface by updating resource limits of the container.
+func (handle *dockerTaskHandle) Resize(resources Resources) error {
+	arguments := []string{"update"}
+	if !resources.CPUSet.Empty() {
+		arguments = append(arguments, "--cpuset-cpus", resources.CPUSet.AsRangeString())
+	}
+	if resources.CPUQuota > 0 {
+		arguments = append(arguments, "--cpus", fmt.Sprintf("%g", resources.CPUQuota))
+	}
+	if resources.MemoryLimit > 0 {
+		// Swap limit must not be lower than memory limit, so it is lifted.
+		arguments = append(arguments, "--memory", fmt.Sprintf("%d", resources.MemoryLimit), "--memory-swap", "-1")
+	}
+	arguments = append(arguments, handle.containerName)
+
+	output, err := exec.Command(handle.binary, arguments...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "cannot update container %q: %s", handle.containerName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// String returns user-friendly name of the task.
+func (handle *dockerTaskHandle) String() string {
+	return fmt.Sprintf("Docker container %q running %q", handle.containerName, handle.command)
+}
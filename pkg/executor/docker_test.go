@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDockerRunCommand(t *testing.T) {
+	Convey("When using Docker executor with default configuration", t, func() {
+		config := DefaultDockerConfig()
+		config.Image = "swan"
+		docker := NewDocker(config).(Docker)
+
+		Convey("Container should be run without limits", func() {
+			So(docker.runCommand("swan-1", "memcached -p 11211"), ShouldEqual, "docker run --rm --name swan-1 swan sh -c 'memcached -p 11211'")
+		})
+
+		Convey("Quotes in command should be escaped", func() {
+			So(docker.runCommand("swan-1", "echo 'foo'"), ShouldEqual, `docker run --rm --name swan-1 swan sh -c 'echo '\''foo'\'''`)
+		})
+	})
+
+	Convey("When using Docker executor with resource limits and decorators", t, func() {
+		config := DefaultDockerConfig()
+		config.Image = "swan"
+		config.CPUSet = isolation.NewIntSet(1, 2)
+		config.CPUQuota = 1.5
+		config.MemoryLimit = 1024
+		config.HostNetwork = true
+		config.Privileged = true
+		config.Decorators = isolation.Decorators{isolation.Taskset{CPUList: isolation.NewIntSet(1)}}
+		docker := NewDocker(config).(Docker)
+
+		Convey("All the limits should be passed to docker and decorators should be applied inside container", func() {
+			So(docker.runCommand("swan-1", "memcached"), ShouldEqual,
+				"docker run --rm --name swan-1 --cpuset-cpus 1,2 --cpus 1.5 --memory 1024 --network host --privileged swan sh -c 'taskset -c 1 memcached'")
+		})
+	})
+}
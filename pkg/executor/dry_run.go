@@ -0,0 +1,207 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/utils/netutil"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DryRunCommand describes a single command that would have been executed.
+type DryRunCommand struct {
+	// Executor is the name of executor that recorded the command.
+	Executor string
+	// Address is the host where command would have been run.
+	Address string
+	// Command is the fully decorated command.
+	Command string
+}
+
+// DryRunRecorder collects commands recorded by DryRun executors.
+// It is safe for concurrent use.
+type DryRunRecorder struct {
+	mutex    sync.Mutex
+	commands []DryRunCommand
+}
+
+// NewDryRunRecorder returns an empty DryRunRecorder.
+func NewDryRunRecorder() *DryRunRecorder {
+	return &DryRunRecorder{}
+}
+
+func (r *DryRunRecorder) record(command DryRunCommand) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.commands = append(r.commands, command)
+}
+
+// Commands returns all commands recorded so far.
+func (r *DryRunRecorder) Commands() []DryRunCommand {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]DryRunCommand{}, r.commands...)
+}
+
+// Flush returns all commands recorded so far and clears the recorder.
+func (r *DryRunRecorder) Flush() []DryRunCommand {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	commands := r.commands
+	r.commands = nil
+	return commands
+}
+
+// DryRun is an executor that records decorated commands instead of executing them.
+// Returned task handles do not represent any process: they are running until
+// Stop or Wait is called and then terminate with exit code 0 and empty output.
+type DryRun struct {
+	recorder          *DryRunRecorder
+	address           string
+	commandDecorators isolation.Decorators
+}
+
+// NewDryRun returns a DryRun executor pretending to run commands on given address.
+func NewDryRun(recorder *DryRunRecorder, address string, decorators ...isolation.Decorator) DryRun {
+	return DryRun{
+		recorder:          recorder,
+		address:           address,
+		commandDecorators: decorators,
+	}
+}
+
+// IsListening checks if task is listening on given address using isListening function.
+// Tasks recorded by DryRun executor are always reported as listening, because no process is run,
+// so launchers do not need to be aware of dry run mode. Tasks launched after their readiness probe
+// succeeded (see ProbedTask) are listening as well, even if address is not reachable from this host.
+func IsListening(task TaskHandle, address string, timeout time.Duration, isListening netutil.IsListeningFunction) bool {
+	for _, wrapped := range unwrapTask(task) {
+		if _, recorded := wrapped.(*dryRunTaskHandle); recorded {
+			return true
+		}
+		if probed, ok := wrapped.(ProbedTask); ok && probed.ReadinessProbed() {
+			return true
+		}
+	}
+	return isListening(address, timeout)
+}
+
+// String returns user-friendly name of executor.
+func (d DryRun) String() string {
+	return fmt.Sprintf("Dry Run Executor pointing at %s", d.address)
+}
+
+// Execute records decorated command and returns task handle that does nothing.
+func (d DryRun) Execute(command string) (TaskHandle, error) {
+	decorated := d.commandDecorators.Decorate(command)
+	log.Debugf("Dry Run Executor: recording %q on %q", decorated, d.address)
+
+	d.recorder.record(DryRunCommand{
+		Executor: d.String(),
+		Address:  d.address,
+		Command:  decorated,
+	})
+
+	return &dryRunTaskHandle{
+		command:    decorated,
+		address:    d.address,
+		terminated: make(chan struct{}),
+	}, nil
+}
+
+// dryRunTaskHandle implements TaskHandle interface.
+type dryRunTaskHandle struct {
+	command string
+	address string
+
+	terminated chan struct{}
+	once       sync.Once
+}
+
+func (th *dryRunTaskHandle) terminate() {
+	th.once.Do(func() {
+		close(th.terminated)
+	})
+}
+
+// Stop marks the task as terminated.
+func (th *dryRunTaskHandle) Stop() error {
+	th.terminate()
+	return nil
+}
+
+// Wait marks the task as terminated and returns immediately.
+func (th *dryRunTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	th.terminate()
+	return true, nil
+}
+
+// Pause does nothing as no process is running.
+func (th *dryRunTaskHandle) Pause() error {
+	return nil
+}
+
+// Resume does nothing as no process is running.
+func (th *dryRunTaskHandle) Resume() error {
+	return nil
+}
+
+// Status returns a state of the task.
+func (th *dryRunTaskHandle) Status() TaskState {
+	select {
+	case <-th.terminated:
+		return TERMINATED
+	default:
+		return RUNNING
+	}
+}
+
+// ExitCode returns 0 when task is terminated.
+func (th *dryRunTaskHandle) ExitCode() (int, error) {
+	if th.Status() != TERMINATED {
+		return -1, errors.Errorf("task %q is not terminated", th.command)
+	}
+	return 0, nil
+}
+
+// StdoutFile returns a handle to empty file.
+func (th *dryRunTaskHandle) StdoutFile() (*os.File, error) {
+	return openFile(os.DevNull)
+}
+
+// StderrFile returns a handle to empty file.
+func (th *dryRunTaskHandle) StderrFile() (*os.File, error) {
+	return openFile(os.DevNull)
+}
+
+// EraseOutput does nothing as there is no output.
+func (th *dryRunTaskHandle) EraseOutput() error {
+	return nil
+}
+
+// Address returns address where task would have been run.
+func (th *dryRunTaskHandle) Address() string {
+	return th.address
+}
+
+func (th *dryRunTaskHandle) String() string {
+	return fmt.Sprintf("Dry run %q on %q", th.command, th.address)
+}
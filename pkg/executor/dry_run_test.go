@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDryRun(t *testing.T) {
+	Convey("When using DryRun executor with taskset decorator", t, func() {
+		recorder := NewDryRunRecorder()
+		decorator := isolation.Taskset{CPUList: isolation.NewIntSet(1, 2)}
+		dryRun := NewDryRun(recorder, "10.0.0.1", decorator)
+
+		handle, err := dryRun.Execute("memcached -p 11211")
+		So(err, ShouldBeNil)
+
+		Convey("Decorated command should be recorded", func() {
+			commands := recorder.Commands()
+			So(commands, ShouldHaveLength, 1)
+			So(commands[0].Address, ShouldEqual, "10.0.0.1")
+			So(commands[0].Command, ShouldEqual, "taskset -c 1,2 memcached -p 11211")
+		})
+
+		Convey("Recorded task should be reported as listening without checking address", func() {
+			checked := false
+			isListening := func(address string, timeout time.Duration) bool {
+				checked = true
+				return false
+			}
+			So(IsListening(handle, "10.0.0.1:11211", time.Second, isListening), ShouldBeTrue)
+			So(checked, ShouldBeFalse)
+		})
+
+		Convey("Pod launched after its readiness probe succeeded should be reported as listening without checking address", func() {
+			checked := false
+			isListening := func(address string, timeout time.Duration) bool {
+				checked = true
+				return false
+			}
+			So(IsListening(&k8sTaskHandle{readinessProbed: true}, "10.2.0.1:11211", time.Second, isListening), ShouldBeTrue)
+			So(checked, ShouldBeFalse)
+			So(IsListening(&k8sTaskHandle{}, "10.2.0.1:11211", time.Second, isListening), ShouldBeFalse)
+			So(checked, ShouldBeTrue)
+		})
+
+		Convey("Flush should clear recorded commands", func() {
+			So(recorder.Flush(), ShouldHaveLength, 1)
+			So(recorder.Commands(), ShouldBeEmpty)
+		})
+
+		Convey("Task should be running until stopped", func() {
+			So(handle.Status(), ShouldEqual, RUNNING)
+			_, err := handle.ExitCode()
+			So(err, ShouldNotBeNil)
+
+			So(handle.Stop(), ShouldBeNil)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+			So(handle.Address(), ShouldEqual, "10.0.0.1")
+		})
+
+		Convey("Wait should terminate task immediately", func() {
+			terminated, err := handle.Wait(0)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+
+			stdout, err := handle.StdoutFile()
+			So(err, ShouldBeNil)
+			defer stdout.Close()
+			stat, err := stdout.Stat()
+			So(err, ShouldBeNil)
+			So(stat.Size(), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Values of freezer.state attribute of cgroup freezer controller.
+const (
+	frozenState = "FROZEN"
+	thawedState = "THAWED"
+)
+
+// freezeProcess freezes (or thaws) the process pid and its children.
+// Freezer cgroup of the process is used when it was created by Swan and only the process tree of pid uses it.
+// Otherwise SIGSTOP (or SIGCONT) is sent to the process group, which might be noticed by the process
+// (e.g. by its parent waiting for it).
+func freezeProcess(pid int, freeze bool) error {
+	state, signal := thawedState, syscall.SIGCONT
+	if freeze {
+		state, signal = frozenState, syscall.SIGSTOP
+	}
+
+	cgroups, err := processCgroups(pid)
+	if err == nil {
+		var ownership cgroupOwnership
+		ownership, err = taskCgroupOwnership(cgroups, "freezer", pid)
+		if err == nil && (!ownership.createdBySwan || !ownership.exclusive) {
+			err = errors.Errorf("freezer cgroup %q is not dedicated to the task", ownership.directory)
+		}
+		if err == nil {
+			err = writeCgroupFile(cgroups, "freezer", "freezer.state", state)
+			if err == nil {
+				return nil
+			}
+		}
+	}
+	log.Debugf("Cannot use freezer cgroup of process %d (%s), sending %s to its process group", pid, err.Error(), signal)
+
+	err = syscall.Kill(-pid, signal)
+	if err != nil {
+		return errors.Wrapf(err, "cannot send %s to process group %d", signal, pid)
+	}
+	return nil
+}
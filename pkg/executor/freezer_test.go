@@ -0,0 +1,87 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFreezeProcess(t *testing.T) {
+	Convey("When process is run in dedicated freezer cgroup", t, func() {
+		root, err := ioutil.TempDir("", "freezer")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		oldProcRoot, oldCgroupRoot := procRoot, cgroupRoot
+		procRoot, cgroupRoot = filepath.Join(root, "proc"), filepath.Join(root, "cgroup")
+		defer func() { procRoot, cgroupRoot = oldProcRoot, oldCgroupRoot }()
+
+		So(os.MkdirAll(filepath.Join(procRoot, "42"), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(procRoot, "42", "cgroup"), []byte("7:freezer:/swan/be\n"), 0644), ShouldBeNil)
+		freezerState := filepath.Join(cgroupRoot, "freezer", "swan", "be", "freezer.state")
+		So(os.MkdirAll(filepath.Dir(freezerState), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(filepath.Dir(freezerState), "cgroup.procs"), []byte("42\n"), 0644), ShouldBeNil)
+
+		Convey("Freezer state should be changed instead of sending signals", func() {
+			So(freezeProcess(42, true), ShouldBeNil)
+			state, err := ioutil.ReadFile(freezerState)
+			So(err, ShouldBeNil)
+			So(string(state), ShouldEqual, frozenState)
+
+			So(freezeProcess(42, false), ShouldBeNil)
+			state, err = ioutil.ReadFile(freezerState)
+			So(err, ShouldBeNil)
+			So(string(state), ShouldEqual, thawedState)
+		})
+	})
+
+	Convey("When freezer cgroup of process is shared with other processes", t, func() {
+		root, err := ioutil.TempDir("", "freezer")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		oldProcRoot, oldCgroupRoot := procRoot, cgroupRoot
+		procRoot, cgroupRoot = filepath.Join(root, "proc"), filepath.Join(root, "cgroup")
+		defer func() { procRoot, cgroupRoot = oldProcRoot, oldCgroupRoot }()
+
+		cmd := exec.Command("sleep", "300")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		So(cmd.Start(), ShouldBeNil)
+		defer cmd.Wait()
+		defer cmd.Process.Kill()
+		pid := strconv.Itoa(cmd.Process.Pid)
+
+		So(os.MkdirAll(filepath.Join(procRoot, pid), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(procRoot, pid, "cgroup"), []byte("7:freezer:/swan/be\n"), 0644), ShouldBeNil)
+		freezerState := filepath.Join(cgroupRoot, "freezer", "swan", "be", "freezer.state")
+		So(os.MkdirAll(filepath.Dir(freezerState), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(filepath.Dir(freezerState), "cgroup.procs"), []byte("1\n"+pid+"\n"), 0644), ShouldBeNil)
+
+		Convey("Process group should be stopped with signals instead of freezing the cgroup", func() {
+			So(freezeProcess(cmd.Process.Pid, true), ShouldBeNil)
+			So(freezeProcess(cmd.Process.Pid, false), ShouldBeNil)
+			_, err := os.Stat(freezerState)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
@@ -24,14 +24,16 @@ Kubernetes executor under the hood is using these are three components:
 	- responsible for monitoring state of Pod and passing to information to taskHandle,
 	- also in case of failure or part of cleaning up or when asked directly by taskHandle - deletes pod,
 - copier:
-	- Resides in setupLogs() function
-	- It is responsible for copying logs from streamed kubernetes response
-	- Closes logsCopyFinished channel when logs finishes streaming or failed to create stream
+	- Started by setupLogs() function, one for every container of the pod
+	- It is responsible for streaming logs of the container to output files as they appear
+	  (see containerLogStreamer); broken streams are reopened and lines already written are skipped
+	- logsCopyFinished channel is closed when all copiers finish streaming or fail to create stream
 
 
 Actually pod transitions by those phases which maps to those handles:
 - Pending: do nothing, just log,
 - Running and Ready: calls whenPodReady() handler
+- Running with task container terminated (pod with sidecars): calls whenPodFinished() handler and deletePod() action.
 - Success or Failed: calls whenPodFinishes() handler and more importantly deletePod() action.
 - Deleted: whenPodDeleted - to signal taskHandler
 
@@ -56,11 +58,16 @@ Additionally every handler or action is protected by sync.Once to make sure that
 package executor
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
@@ -72,7 +79,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api"
@@ -83,13 +92,21 @@ import (
 
 const (
 	defaultContainerImage = "intelsdi/swan"
+
+	// hugepages2MiResource is not defined by vendored Kubernetes API.
+	hugepages2MiResource = v1.ResourceName("hugepages-2Mi")
+	hugepageSize2Mi      = 2 * 1024 * 1024
 )
 
 var (
-	kubeconfigFlag                = conf.NewStringFlag("kubernetes_kubeconfig", "(optional) Absolute path to the kubeconfig file. Overrides pod configuration passed through flags. ", "")
+	// KubeconfigFlag is a path to kubeconfig used to reach the cluster. Cluster launcher bootstrapping cluster with kubeadm stores admin kubeconfig there.
+	KubeconfigFlag                = conf.NewStringFlag("kubernetes_kubeconfig", "(optional) Absolute path to the kubeconfig file. Overrides pod configuration passed through flags. ", "")
 	kubernetesPrivilegedPodsFlag  = conf.NewBoolFlag("kubernetes_privileged_pods", "Kubernetes containers will be run as privileged.", false)
 	kubernetesPodLunchTimeoutFlag = conf.NewDurationFlag("kubernetes_pod_launch_timeout", "Kubernetes Pod launch timeout.", 30*time.Second)
 	kubernetesContainerImageFlag  = conf.NewStringFlag("kubernetes_container_image", "Name of the container image to be used. It needs to be available locally or downloadable.", defaultContainerImage)
+	kubernetesPodTemplateFlag     = conf.NewStringFlag("kubernetes_pod_template", "(optional) Path to YAML or JSON pod manifest used as a base of every pod (e.g. to add volumes, security settings or init containers required by the cluster).", "")
+	kubernetesServiceAccountFlag  = conf.NewStringFlag("kubernetes_service_account", "(optional) Name of service account pods are run as.", "")
+	kubernetesRuntimeClassFlag    = conf.NewStringFlag("kubernetes_runtime_class", "(optional) Name of RuntimeClass selecting container runtime of pods (requires Kubernetes 1.12 or newer).", "")
 )
 
 // KubernetesConfig describes the necessary information to connect to a Kubernetes cluster.
@@ -117,6 +134,40 @@ type KubernetesConfig struct {
 	Privileged     bool
 	HostNetwork    bool
 	LaunchTimeout  time.Duration
+
+	// Hugepages2MiRequest is amount of 2MiB hugepages memory in bytes (requested and limited). Not requested when 0.
+	Hugepages2MiRequest int64
+	// ExtendedResources are requested and limited counts of node-level extended resources (e.g. RDT classes
+	// advertised by a device plugin), keyed by the resource name.
+	ExtendedResources map[string]int64
+
+	// Volumes are added to the pod and mounted in the container according to VolumeMounts.
+	Volumes      []v1.Volume
+	VolumeMounts []v1.VolumeMount
+	// Env are environment variables of the container.
+	Env []v1.EnvVar
+	// Labels are added to the pod (e.g. to be selected by a Service). Label "name" is reserved for pod name.
+	Labels map[string]string
+	// ReadinessProbe of the task container is run by kubelet and Execute returns after it succeeds, so readiness
+	// of pods which are not reachable from the host running the experiment (e.g. on pod network) can be checked.
+	ReadinessProbe *v1.Probe
+	// Affinity constraints pod scheduling (node affinity, pod affinity and anti-affinity).
+	Affinity *v1.Affinity
+	// Tolerations allow scheduling pods on tainted nodes.
+	Tolerations        []v1.Toleration
+	ServiceAccountName string
+	// RuntimeClassName selects container runtime. Default runtime is used when empty.
+	RuntimeClassName string
+	// CgroupDriver is a cgroup driver of kubelet ("cgroupfs" or "systemd"). It determines cgroup paths of pods reported in PodStatus.
+	CgroupDriver string
+	// Sidecars are additional containers run in the pod along with the task (e.g. metrics exporters or log shippers).
+	// Task is finished when its container terminates; sidecars are stopped then.
+	Sidecars []v1.Container
+	// Job makes executor run tasks as Kubernetes Jobs instead of bare pods. Pods are used when nil.
+	Job *KubernetesJobConfig
+	// PodTemplateFile is a path to YAML or JSON pod manifest used as a base of every pod.
+	// Name, namespace, command and fields configured above take precedence over the template.
+	PodTemplateFile string
 }
 
 // LaunchTimedOutError is the error type returned when launching new pods exceed
@@ -149,38 +200,59 @@ func DefaultKubernetesConfig() KubernetesConfig {
 		Privileged:     kubernetesPrivilegedPodsFlag.Value(),
 		HostNetwork:    false,
 		LaunchTimeout:  kubernetesPodLunchTimeoutFlag.Value(),
+		CgroupDriver:   "cgroupfs",
+
+		ServiceAccountName: kubernetesServiceAccountFlag.Value(),
+		RuntimeClassName:   kubernetesRuntimeClassFlag.Value(),
+		PodTemplateFile:    kubernetesPodTemplateFlag.Value(),
 	}
 }
 
 type k8s struct {
 	config    KubernetesConfig
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 }
 
 // NewKubernetes returns an executor which lets the user run commands in pods in a
 // kubernetes cluster.
 func NewKubernetes(config KubernetesConfig) (Executor, error) {
-	k8s := &k8s{
-		config: config,
+	clientset, err := NewKubernetesClientset(config.Address)
+	if err != nil {
+		return nil, err
 	}
 
-	var err error
-	kubeConfigPath := kubeconfigFlag.Value()
+	return NewKubernetesWithClientset(config, clientset), nil
+}
+
+// NewKubernetesWithClientset returns Kubernetes executor talking to the cluster through provided clientset
+// (e.g. the fake one from k8sfake package used in tests).
+func NewKubernetesWithClientset(config KubernetesConfig, clientset kubernetes.Interface) Executor {
+	return &k8s{
+		config:    config,
+		clientset: clientset,
+	}
+}
+
+// NewKubernetesClientset connects to API server at address or uses kubeconfig file when provided.
+func NewKubernetesClientset(address string) (clientset *kubernetes.Clientset, err error) {
+	kubeConfigPath := KubeconfigFlag.Value()
 	if kubeConfigPath == "" {
-		k8s.clientset, err = kubernetes.NewForConfig(&rest.Config{
-			Host: config.Address,
+		clientset, err = kubernetes.NewForConfig(&rest.Config{
+			Host: address,
 		})
 	} else {
 		var kubeconfig *rest.Config
 		kubeconfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
-		k8s.clientset, err = kubernetes.NewForConfig(kubeconfig)
+		if err == nil {
+			clientset, err = kubernetes.NewForConfig(kubeconfig)
+		}
 	}
 
 	if err != nil {
-		return nil, errors.Wrapf(err, "can't initilize kubernetes clientset for host '%s'", config.Address)
+		return nil, errors.Wrapf(err, "can't initilize kubernetes clientset for host '%s'", address)
 	}
 
-	return k8s, nil
+	return clientset, nil
 }
 
 // containerResources helper to create ResourceRequirements for the container.
@@ -204,6 +276,16 @@ func (k8s *k8s) containerResources() v1.ResourceRequirements {
 		resourceListLimits[v1.ResourceMemory] = *resource.NewQuantity(k8s.config.MemoryLimit, resource.DecimalSI)
 	}
 
+	// Hugepages and extended resources cannot be overcommitted, so requests and limits must be equal.
+	if k8s.config.Hugepages2MiRequest > 0 {
+		resourceListRequests[hugepages2MiResource] = *resource.NewQuantity(k8s.config.Hugepages2MiRequest, resource.BinarySI)
+		resourceListLimits[hugepages2MiResource] = *resource.NewQuantity(k8s.config.Hugepages2MiRequest, resource.BinarySI)
+	}
+	for name, count := range k8s.config.ExtendedResources {
+		resourceListRequests[v1.ResourceName(name)] = *resource.NewQuantity(count, resource.DecimalSI)
+		resourceListLimits[v1.ResourceName(name)] = *resource.NewQuantity(count, resource.DecimalSI)
+	}
+
 	return v1.ResourceRequirements{
 		Requests: resourceListRequests,
 		Limits:   resourceListLimits,
@@ -229,52 +311,146 @@ func (k8s *k8s) generatePodName() string {
 }
 
 // newPod is a helper to build in-memory structure representing pod
-// before sending it as request to API server. It can returns also
-// error if cannot generate Pod name.
+// before sending it as request to API server. Pod is based on template
+// from KubernetesConfig.PodTemplateFile when provided. It returns error
+// if the template cannot be read.
 func (k8s *k8s) newPod(command string) (*v1.Pod, error) {
+	if k8s.config.Hugepages2MiRequest%hugepageSize2Mi != 0 {
+		return nil, errors.Errorf("hugepages-2Mi request of %d bytes is not a multiple of 2MiB", k8s.config.Hugepages2MiRequest)
+	}
+
+	pod := &v1.Pod{}
+	if k8s.config.PodTemplateFile != "" {
+		template, err := readPodTemplate(k8s.config.PodTemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		pod = &template.Pod
+	}
 
-	resources := k8s.containerResources()
 	podName := k8s.generatePodName()
+	pod.ObjectMeta.Name = podName
+	pod.ObjectMeta.GenerateName = ""
+	pod.ObjectMeta.Namespace = k8s.config.Namespace
+	if pod.ObjectMeta.Labels == nil {
+		pod.ObjectMeta.Labels = map[string]string{}
+	}
+	for key, value := range k8s.config.Labels {
+		pod.ObjectMeta.Labels[key] = value
+	}
+	pod.ObjectMeta.Labels["name"] = podName
 
-	var zero int64
-	return &v1.Pod{
-		TypeMeta: metav1.TypeMeta{},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      podName,
-			Namespace: k8s.config.Namespace,
-			Labels:    map[string]string{"name": podName},
-		},
-		Spec: v1.PodSpec{
-			NodeName:                      k8s.config.NodeName,
-			DNSPolicy:                     "Default",
-			RestartPolicy:                 "Never",
-			HostNetwork:                   k8s.config.HostNetwork,
-			TerminationGracePeriodSeconds: &zero,
-			Containers: []v1.Container{
-				{
-					Name:            k8s.config.ContainerName,
-					Image:           k8s.config.ContainerImage,
-					Command:         []string{"sh", "-c", command},
-					Resources:       resources,
-					ImagePullPolicy: v1.PullIfNotPresent, // Default because swan image is not published yet.
-					SecurityContext: &v1.SecurityContext{Privileged: &k8s.config.Privileged},
-				},
-			},
-		},
-	}, nil
+	spec := &pod.Spec
+	if k8s.config.NodeName != "" {
+		spec.NodeName = k8s.config.NodeName
+	}
+	if spec.DNSPolicy == "" {
+		spec.DNSPolicy = "Default"
+	}
+	// Executor tasks are never restarted.
+	spec.RestartPolicy = "Never"
+	spec.HostNetwork = spec.HostNetwork || k8s.config.HostNetwork
+	if spec.TerminationGracePeriodSeconds == nil {
+		var zero int64
+		spec.TerminationGracePeriodSeconds = &zero
+	}
+	spec.Volumes = append(spec.Volumes, k8s.config.Volumes...)
+	spec.Tolerations = append(spec.Tolerations, k8s.config.Tolerations...)
+	if k8s.config.Affinity != nil {
+		spec.Affinity = k8s.config.Affinity
+	}
+	if k8s.config.ServiceAccountName != "" {
+		spec.ServiceAccountName = k8s.config.ServiceAccountName
+	}
+
+	// The first container of the template is used as a base of the task container,
+	// other containers are run as sidecars.
+	container := v1.Container{}
+	var sidecars []v1.Container
+	if len(spec.Containers) > 0 {
+		container = spec.Containers[0]
+		sidecars = spec.Containers[1:]
+	}
+	container.Name = k8s.config.ContainerName
+	if k8s.config.ContainerImage != "" {
+		container.Image = k8s.config.ContainerImage
+	}
+	container.Command = []string{"sh", "-c", command}
+	container.Args = nil
+	container.Resources = mergeResources(container.Resources, k8s.containerResources())
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = v1.PullIfNotPresent // Default because swan image is not published yet.
+	}
+	if container.SecurityContext == nil {
+		container.SecurityContext = &v1.SecurityContext{}
+	}
+	privileged := k8s.config.Privileged || (container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged)
+	container.SecurityContext.Privileged = &privileged
+	container.Env = append(container.Env, k8s.config.Env...)
+	container.VolumeMounts = append(container.VolumeMounts, k8s.config.VolumeMounts...)
+	if k8s.config.ReadinessProbe != nil {
+		container.ReadinessProbe = k8s.config.ReadinessProbe
+	}
+	spec.Containers = append([]v1.Container{container}, sidecars...)
+	spec.Containers = append(spec.Containers, k8s.config.Sidecars...)
+
+	return pod, nil
+}
+
+// runtimeClassName returns RuntimeClass of pods from KubernetesConfig or pod template.
+func (k8s *k8s) runtimeClassName() (string, error) {
+	if k8s.config.RuntimeClassName != "" || k8s.config.PodTemplateFile == "" {
+		return k8s.config.RuntimeClassName, nil
+	}
+	template, err := readPodTemplate(k8s.config.PodTemplateFile)
+	if err != nil {
+		return "", err
+	}
+	return template.RuntimeClassName, nil
+}
+
+// createPod schedules the pod in the cluster.
+func (k8s *k8s) createPod(pod *v1.Pod) (*v1.Pod, error) {
+	runtimeClassName, err := k8s.runtimeClassName()
+	if err != nil {
+		return nil, err
+	}
+	if runtimeClassName == "" {
+		return k8s.clientset.CoreV1().Pods(k8s.config.Namespace).Create(pod)
+	}
+
+	body, err := manifestWithFields(pod, "v1", "Pod", map[string]interface{}{"spec.runtimeClassName": runtimeClassName})
+	if err != nil {
+		return nil, err
+	}
+	created := &v1.Pod{}
+	err = k8s.clientset.CoreV1().RESTClient().Post().
+		Namespace(k8s.config.Namespace).
+		Resource("pods").
+		Body(body).
+		Do().
+		Into(created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 // Execute creates a pod and runs the provided command in it. When the command completes, the pod
 // is stopped i.e. the container is not restarted automatically.
 func (k8s *k8s) Execute(command string) (TaskHandle, error) {
-	podsAPI := k8s.clientset.Pods(k8s.config.Namespace)
+	if k8s.config.Job != nil {
+		return k8s.executeJob(command)
+	}
+
+	podsAPI := k8s.clientset.CoreV1().Pods(k8s.config.Namespace)
 	command = k8s.config.Decorators.Decorate(command)
 
 	// This is a workaround for kubernetes #31446
 	// Make sure that at least one line of text is outputted from pod, to unblock .GetLogs() on apiserver call
 	// with streamed response (when follow=true). Check kubernetes #31446 issue for more details.
 	// https://github.com/kubernetes/kubernetes/pull/31446
-	wrappedCommand := "echo;" + command
+	wrappedCommand := "echo;" + wrapPodCommand(command)
 
 	// See http://kubernetes.io/docs/api-reference/v1/definitions/ for definition of the pod manifest.
 	podManifest, err := k8s.newPod(wrappedCommand)
@@ -288,11 +464,11 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 
 	log.Debugf("Starting '%s' pod=%s node=%s QoSclass=%s on kubernetes", command, podManifest.ObjectMeta.Name, podManifest.Spec.NodeName, apiPod.Status.QOSClass)
 
-	pod, err := podsAPI.Create(podManifest)
+	pod, err := k8s.createPod(podManifest)
 	if err != nil {
 		log.Errorf("K8s executor: cannot schedule pod %q with namespace %q", k8s.config.PodName, k8s.config.Namespace)
-		return nil, errors.Wrapf(err, "cannot schedule pod %q with namespace %q",
-			k8s.config.PodName, k8s.config.Namespace)
+		return nil, Retryable(errors.Wrapf(err, "cannot schedule pod %q with namespace %q",
+			k8s.config.PodName, k8s.config.Namespace))
 	}
 
 	// Prepare local files
@@ -315,6 +491,10 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 
 	taskHandle := &k8sTaskHandle{
 		podName:         pod.Name,
+		podsAPI:         podsAPI,
+		versionAPI:      k8s.clientset.Discovery(),
+		apiAddress:      k8s.config.Address,
+		namespace:       k8s.config.Namespace,
 		command:         command,
 		stdoutFilePath:  stdoutFileName,
 		stderrFilePath:  stderrFileName,
@@ -322,13 +502,18 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 		stopped:         make(chan struct{}),
 		requestDelete:   make(chan struct{}, 1),
 		exitCodeChannel: make(chan int, 1),
+		podStatus:       newPodStatus(pod, k8s.config.ContainerName, k8s.config.CgroupDriver),
+		readinessProbed: pod.Spec.Containers[0].ReadinessProbe != nil,
 	}
 
 	taskWatcher := &k8sWatcher{
-		podsAPI:    podsAPI,
-		pod:        pod,
-		taskHandle: taskHandle,
-		command:    wrappedCommand,
+		podsAPI:       podsAPI,
+		podLogs:       newPodLogsFunc(k8s.clientset, k8s.config.Namespace),
+		pod:           pod,
+		taskHandle:    taskHandle,
+		command:       wrappedCommand,
+		containerName: k8s.config.ContainerName,
+		cgroupDriver:  k8s.config.CgroupDriver,
 
 		stdoutFilePath: stdoutFileName,
 		stderrFilePath: stderrFileName,
@@ -372,18 +557,42 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 		}
 		break
 	case <-time.After(100 * time.Millisecond):
+		RegisterTaskHandle(taskHandle)
 		return taskHandle, nil
 
 	}
 	// It has been determined that task failed, waiting for Kubernetes to officially acknowledge it.
 	taskHandle.Wait(0)
+	if taskWatcher.launchTimedOut {
+		removeDirectory(outputDirectory)
+		return nil, Retryable(&LaunchTimedOutError{
+			errorMessage: fmt.Sprintf("pod %q has not been started within %s", pod.Name, k8s.config.LaunchTimeout),
+		})
+	}
 	err = checkIfProcessFailedToExecute(command, k8s.String(), taskHandle)
 	if err != nil {
+		if !started {
+			// Pod has not been scheduled or started in time; it might succeed when cluster is less busy.
+			return nil, Retryable(err)
+		}
 		return nil, err
 	}
+	RegisterTaskHandle(taskHandle)
 	return taskHandle, nil
 }
 
+// ProbedTask is optionally implemented by TaskHandles of tasks whose readiness is checked by the cluster.
+type ProbedTask interface {
+	// ReadinessProbed returns true when the task has been launched after its readiness probe succeeded.
+	ReadinessProbed() bool
+}
+
+// ContainerOutput is optionally implemented by TaskHandles of tasks running in multi-container pods.
+type ContainerOutput interface {
+	// ContainerOutputFile returns output of the sidecar container with given name.
+	ContainerOutputFile(container string) (*os.File, error)
+}
+
 // k8sTaskHandle implements the TaskHandle interface
 type k8sTaskHandle struct {
 	stopped       chan struct{}
@@ -399,6 +608,19 @@ type k8sTaskHandle struct {
 
 	podName   string
 	podHostIP string
+	podsAPI   corev1.PodInterface
+	// versionAPI tells whether API server supports in-place pod resize.
+	versionAPI discovery.ServerVersionInterface
+	// apiAddress and namespace are used by kubectl to reach the pod.
+	apiAddress string
+	namespace  string
+
+	mutex         sync.Mutex
+	stoppedByUser bool
+	// podStatus is updated by watcher on every pod event.
+	podStatus PodStatus
+	// readinessProbed is set when task container has readiness probe, so the task was ready when launched.
+	readinessProbed bool
 
 	// Command requested by user. This is how this TaskHandle presents.
 	command string
@@ -432,9 +654,91 @@ func (th *k8sTaskHandle) Stop() error {
 	<-th.stopped
 	log.Debugf("K8s task handle: pod %q stopped", th.podName)
 
+	th.mutex.Lock()
+	th.stoppedByUser = true
+	th.mutex.Unlock()
+
+	return nil
+}
+
+// StopStage implements StopStageReporter interface.
+// Kubelet kills containers which do not exit within grace period, so exit code tells which stage terminated the task.
+func (th *k8sTaskHandle) StopStage() StopStage {
+	th.mutex.Lock()
+	stoppedByUser := th.stoppedByUser
+	th.mutex.Unlock()
+	if !stoppedByUser {
+		return NotStopped
+	}
+
+	exitCode, err := th.ExitCode()
+	if err == nil && exitCode == 128+int(syscall.SIGKILL) {
+		return StoppedForcefully
+	}
+	return StoppedGracefully
+}
+
+// ReadinessProbed implements ProbedTask interface.
+func (th *k8sTaskHandle) ReadinessProbed() bool {
+	return th.readinessProbed
+}
+
+// PodStatuses implements PodStatusReporter interface.
+func (th *k8sTaskHandle) PodStatuses() []PodStatus {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	return []PodStatus{th.podStatus}
+}
+
+func (th *k8sTaskHandle) setPodStatus(status PodStatus) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	th.podStatus = status
+}
+
+// Pause sends SIGSTOP to all processes in the pod (using kubectl exec).
+// Container init process is not stopped, but it is only a shell waiting for the command (see wrapPodCommand).
+func (th *k8sTaskHandle) Pause() error {
+	return th.signal("STOP")
+}
+
+// Resume sends SIGCONT to all processes in the pod.
+func (th *k8sTaskHandle) Resume() error {
+	return th.signal("CONT")
+}
+
+func (th *k8sTaskHandle) signal(signal string) error {
+	if th.isTerminated() {
+		return errors.Errorf("cannot send SIG%s to pod %q: task is terminated", signal, th.podName)
+	}
+
+	arguments := []string{"--namespace", th.namespace}
+	if KubeconfigFlag.Value() != "" {
+		arguments = append(arguments, "--kubeconfig", KubeconfigFlag.Value())
+	} else {
+		arguments = append(arguments, "--server", th.apiAddress)
+	}
+	// Signal sent to -1 reaches all processes in container PID namespace except init and kill itself.
+	arguments = append(arguments, "exec", th.podName, "--", "kill", "-"+signal, "-1")
+
+	output, err := exec.Command("kubectl", arguments...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "cannot send SIG%s to pod %q: %s", signal, th.podName, strings.TrimSpace(string(output)))
+	}
 	return nil
 }
 
+// wrapPodCommand runs command as a child of a shell which stays container init process (PID 1).
+// Signals sent to -1 never reach init of the PID namespace, so without the wrapper the workload would be init
+// and could not be paused. SIGTERM received on pod deletion is forwarded to the workload and its exit code is returned.
+func wrapPodCommand(command string) string {
+	return "sh -c " + shellQuote(command) + " & pid=$!; " +
+		"trap 'kill -TERM $pid 2>/dev/null' TERM INT; " +
+		// wait returns early when trap is executed, so it is repeated until the workload is reaped.
+		"while :; do wait $pid; status=$?; kill -0 $pid 2>/dev/null || break; done; " +
+		"exit $status"
+}
+
 // Status returns the current task state in terms of RUNNING or TERMINATED.
 func (th *k8sTaskHandle) Status() TaskState {
 	if th.isTerminated() {
@@ -493,6 +797,83 @@ func (th *k8sTaskHandle) EraseOutput() error {
 	return removeDirectory(outputDir)
 }
 
+// podResizeMinorVersion is the first minor version of Kubernetes 1.x serving pods/resize subresource enabled by default.
+const podResizeMinorVersion = 33
+
+// Resize implements Resizable interface by updating resources of pod containers in place through pods/resize subresource.
+// It requires Kubernetes 1.33 or newer; CPU set cannot be changed.
+// Requests equal to limits are updated along with them, so QoS class of the pod is preserved.
+func (th *k8sTaskHandle) Resize(resources Resources) error {
+	if !resources.CPUSet.Empty() {
+		return errors.Errorf("CPU set of pod %q cannot be changed", th.podName)
+	}
+	if err := checkPodResizeSupported(th.versionAPI); err != nil {
+		return errors.Wrapf(err, "cannot resize pod %q", th.podName)
+	}
+
+	pod, err := th.podsAPI.Get(th.podName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cannot get pod %q", th.podName)
+	}
+
+	var containers []map[string]interface{}
+	for _, container := range pod.Spec.Containers {
+		requirements := container.Resources
+		if requirements.Limits == nil {
+			requirements.Limits = v1.ResourceList{}
+		}
+		if resources.CPUQuota > 0 {
+			setResource(&requirements, v1.ResourceCPU, *resource.NewMilliQuantity(int64(resources.CPUQuota*1000), resource.DecimalSI))
+		}
+		if resources.MemoryLimit > 0 {
+			setResource(&requirements, v1.ResourceMemory, *resource.NewQuantity(resources.MemoryLimit, resource.DecimalSI))
+		}
+		containers = append(containers, map[string]interface{}{"name": container.Name, "resources": requirements})
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"containers": containers}})
+	if err != nil {
+		return errors.Wrapf(err, "cannot encode resources of pod %q", th.podName)
+	}
+
+	_, err = th.podsAPI.Patch(th.podName, types.StrategicMergePatchType, patch, "resize")
+	if err != nil {
+		return errors.Wrapf(err, "cannot resize pod %q", th.podName)
+	}
+	return nil
+}
+
+// checkPodResizeSupported returns error when API server is older than Kubernetes 1.33 which introduced pods/resize subresource.
+// Older servers either reject changes of container resources or apply them only after the pod is restarted.
+func checkPodResizeSupported(versionAPI discovery.ServerVersionInterface) error {
+	if versionAPI == nil {
+		return errors.New("Kubernetes version is unknown, in-place pod resize is unsupported")
+	}
+	info, err := versionAPI.ServerVersion()
+	if err != nil {
+		return errors.Wrap(err, "cannot get Kubernetes version")
+	}
+	major, majorErr := strconv.Atoi(strings.TrimRight(info.Major, "+"))
+	minor, minorErr := strconv.Atoi(strings.TrimRight(info.Minor, "+"))
+	if majorErr != nil || minorErr != nil {
+		return errors.Errorf("cannot parse Kubernetes version %q, in-place pod resize is unsupported", info.GitVersion)
+	}
+	if major < 1 || (major == 1 && minor < podResizeMinorVersion) {
+		return errors.Errorf("in-place pod resize is unsupported by Kubernetes %s.%s (requires 1.%d or newer)", info.Major, info.Minor, podResizeMinorVersion)
+	}
+	return nil
+}
+
+// setResource sets limit of resource. Request is changed as well when it was equal to the limit (Guaranteed QoS)
+// or would exceed the new limit.
+func setResource(requirements *v1.ResourceRequirements, name v1.ResourceName, quantity resource.Quantity) {
+	limit, limited := requirements.Limits[name]
+	requirements.Limits[name] = quantity
+	request, requested := requirements.Requests[name]
+	if requested && ((limited && request.Cmp(limit) == 0) || request.Cmp(quantity) > 0) {
+		requirements.Requests[name] = quantity
+	}
+}
+
 func (th *k8sTaskHandle) String() string {
 	return fmt.Sprintf("Kubernetes pod named %q with command %q on %q", th.podName, th.command, th.Address())
 }
@@ -508,6 +889,11 @@ func (th *k8sTaskHandle) StdoutFile() (*os.File, error) {
 	return openFile(th.stdoutFilePath)
 }
 
+// ContainerOutputFile implements ContainerOutput interface.
+func (th *k8sTaskHandle) ContainerOutputFile(container string) (*os.File, error) {
+	return openFile(containerOutputFilePath(th.stdoutFilePath, container))
+}
+
 // StderrFile returns a file handle to the stderr file for the pod.
 // NOTE: StderrFile will block until stderr file is available.
 // For kubernetes there is not stderr stream - return stdout stream.
@@ -517,6 +903,7 @@ func (th *k8sTaskHandle) StderrFile() (*os.File, error) {
 
 type k8sWatcher struct {
 	podsAPI corev1.PodInterface
+	podLogs podLogsFunc
 	pod     *v1.Pod
 
 	stdoutFilePath string
@@ -533,12 +920,18 @@ type k8sWatcher struct {
 	taskHandle *k8sTaskHandle
 
 	command string
+	// containerName is a name of the container running the task; other containers of the pod are sidecars.
+	containerName string
+	// cgroupDriver of kubelet is used to determine pod cgroup path.
+	cgroupDriver string
 
 	// one time events
 	oncePodReady, oncePodFinished, oncePodDeleted sync.Once
 	// one time actions
 	onceDeletePod, onceSetupLogs sync.Once
 	hasBeenRunning               bool
+	// launchTimedOut is set when the pod was deleted, because it has not been started within launch timeout.
+	launchTimedOut bool
 }
 
 // watch creates instance of TaskHandle and is responsible for keeping it in-sync with k8s cluster
@@ -580,13 +973,19 @@ func (kw *k8sWatcher) watch(timeout time.Duration) error {
 
 				switch event.Type {
 				case watch.Added, watch.Modified:
+					kw.taskHandle.setPodStatus(newPodStatus(pod, kw.containerName, kw.cgroupDriver))
 					switch pod.Status.Phase {
 
 					case v1.PodPending:
 						continue
 
 					case v1.PodRunning:
-						if k8sports.IsPodReady(pod) {
+						if kw.taskFinished(pod) {
+							// Pod with sidecars keeps running after the task container terminates.
+							kw.whenPodReady()
+							kw.whenPodFinished(pod)
+							kw.deletePod()
+						} else if k8sports.IsPodReady(pod) {
 							kw.whenPodReady()
 						} else {
 							log.Debug("K8s task watcher: Running but not ready")
@@ -625,6 +1024,7 @@ func (kw *k8sWatcher) watch(timeout time.Duration) error {
 					continue
 				}
 				log.Errorf("Kubernetes Executor: pod %s has not been created: timeout after %f seconds.", kw.pod.Name, timeout.Seconds())
+				kw.launchTimedOut = true
 				kw.deletePod()
 			}
 		}
@@ -649,7 +1049,8 @@ func (kw *k8sWatcher) whenPodReady() {
 // Additionally call whenPodReady handler to setupLogs and mark pod as running.
 func (kw *k8sWatcher) whenPodFinished(pod *v1.Pod) {
 	kw.oncePodFinished.Do(func() {
-		if pod.Status.Phase == v1.PodFailed {
+		exitCode := kw.exitCode(pod)
+		if pod.Status.Phase == v1.PodFailed || exitCode != 0 {
 			close(kw.failed)
 
 			if pod.Status.Message != "" {
@@ -657,14 +1058,48 @@ func (kw *k8sWatcher) whenPodFinished(pod *v1.Pod) {
 			}
 		}
 		kw.setupLogs()
-		kw.setExitCode(pod)
+		kw.setExitCode(pod, exitCode)
 		log.Debug("K8s task watcher: pod finished")
 	})
 }
 
-// setExitCode retrieves exit code from the cluster and sends it to task handle.
+// taskFinished returns true when the task container terminated (sidecars might still be running).
+func (kw *k8sWatcher) taskFinished(pod *v1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == kw.containerName {
+			return status.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// exitCode returns exit code of the task container. When the task succeeded, but one of sidecars
+// failed on its own before, exit code of the sidecar is returned.
+func (kw *k8sWatcher) exitCode(pod *v1.Pod) int {
+	exitCode := -1
+	sidecarExitCode := 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated == nil {
+			continue
+		}
+		if status.Name == kw.containerName || len(pod.Status.ContainerStatuses) == 1 {
+			exitCode = int(status.State.Terminated.ExitCode)
+			continue
+		}
+		if status.State.Terminated.ExitCode != 0 && sidecarExitCode == 0 {
+			log.Warnf("K8s task watcher: sidecar %q of pod %q failed with exit code %d", status.Name, pod.Name, status.State.Terminated.ExitCode)
+			sidecarExitCode = int(status.State.Terminated.ExitCode)
+		}
+	}
+	if exitCode == 0 {
+		return sidecarExitCode
+	}
+	return exitCode
+}
+
+// setExitCode sends exit code to task handle.
 // Used only by whenPodFinished handler.
-func (kw *k8sWatcher) setExitCode(pod *v1.Pod) {
+func (kw *k8sWatcher) setExitCode(pod *v1.Pod, exitCode int) {
 
 	// Send exit code and close channel.
 	sendExitCode := func(exitCode int) {
@@ -673,16 +1108,7 @@ func (kw *k8sWatcher) setExitCode(pod *v1.Pod) {
 		close(kw.exitCodeChannel)
 	}
 
-	// Look for an exit status from the container.
-	// If more than one container is present, the last takes precedence.
-	exitCode := -1
-	for _, status := range pod.Status.ContainerStatuses {
-		if status.State.Terminated == nil {
-			continue
-		}
-		exitCode = int(status.State.Terminated.ExitCode)
-	}
-	if pod.Status.Phase == v1.PodFailed {
+	if exitCode != 0 {
 
 		// Depending on how pod was stopped change log level and explanation.
 		switch exitCode {
@@ -719,8 +1145,12 @@ func (kw *k8sWatcher) deletePod() {
 	kw.onceDeletePod.Do(func() {
 
 		// Setting gracePeriodSeconds to zero will erase pod from API server and won't wait for it to exit.
-		// Setting it to 5 second leaves responsibility of deleting the pod to kubelet.
-		gracePeriodSeconds := int64(5)
+		// Non-zero grace period leaves responsibility of deleting the pod to kubelet, which sends SIGTERM
+		// and kills all container processes when grace period (see StopPolicy) expires.
+		gracePeriodSeconds := int64(DefaultStopPolicy().GracePeriod.Seconds())
+		if gracePeriodSeconds < 1 {
+			gracePeriodSeconds = 1
+		}
 		log.Debugf("deleting pod %q", kw.pod.Name)
 		err := kw.podsAPI.Delete(kw.pod.Name, &metav1.DeleteOptions{
 			GracePeriodSeconds: &gracePeriodSeconds,
@@ -732,50 +1162,69 @@ func (kw *k8sWatcher) deletePod() {
 	})
 }
 
-// setupLogs action creates log files and initializes goroutine that copies stream from kubernetes.
+// setupLogs action creates log files and initializes goroutines that stream logs of all the containers from kubernetes.
+// Output of the task container is copied to stdout and stderr files, output of sidecars to files named after them.
 func (kw *k8sWatcher) setupLogs() {
 	kw.onceSetupLogs.Do(func() {
 		log.Debugf("K8s task watcher: setting up logs for pod %q", kw.pod.Name)
 
-		// Wire up logs to task handle stdout.
-		logStream, err := kw.podsAPI.GetLogs(kw.pod.Name, &v1.PodLogOptions{Follow: true}).Stream()
-		if err != nil {
-			log.Warnf("K8s task watcher: cannot create log stream: %s ", err.Error())
+		var copiers sync.WaitGroup
+		for _, container := range kw.pod.Spec.Containers {
+			var destinations []string
+			if container.Name == kw.containerName {
+				destinations = []string{kw.stdoutFilePath, kw.stderrFilePath}
+			} else {
+				destinations = []string{containerOutputFilePath(kw.stdoutFilePath, container.Name)}
+			}
+
+			copiers.Add(1)
+			go func(container string, destinations []string) {
+				defer copiers.Done()
+				kw.copyLogs(container, destinations)
+			}(container.Name, destinations)
+		}
+
+		go func() {
+			copiers.Wait()
 			close(kw.logsCopyFinished)
+		}()
+	})
+}
+
+// copyLogs is a "copier" streaming logs of the container to destination files as they appear.
+func (kw *k8sWatcher) copyLogs(container string, destinations []string) {
+	var writers []io.Writer
+	for _, destination := range destinations {
+		file, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_SYNC, outputFilePrivileges)
+		if err != nil {
+			log.Errorf("K8s copier: cannot open file to copy logs: %s", err.Error())
 			return
 		}
-		log.Debugf("K8s task watcher: logs for pod %q set up", kw.pod.Name)
+		log.Debugf("K8s copier: destination file opened: %q", destination)
+		defer syncAndClose(file)
+		writers = append(writers, file)
+	}
 
-		// Start "copier" goroutine for copying logs api to local files.
-		go func() {
-			defer close(kw.logsCopyFinished)
+	streamer := &containerLogStreamer{
+		podsAPI:   kw.podsAPI,
+		podLogs:   kw.podLogs,
+		podName:   kw.pod.Name,
+		container: container,
+		output:    io.MultiWriter(writers...),
+	}
+	log.Debugf("K8s copier: starting streaming output of container %q", container)
+	err := streamer.stream()
+	if err != nil {
+		log.Errorf("K8s copier: failed to copy container log stream to task output: %s", err.Error())
+		return
+	}
 
-			stdoutFile, err := os.OpenFile(kw.stdoutFilePath, os.O_WRONLY|os.O_SYNC, outputFilePrivileges)
-			log.Debugf("K8s copier: stdout destination file opened: %q", kw.stdoutFilePath)
-			if err != nil {
-				log.Errorf("K8s copier: cannot open file to copy logs: %s", err.Error())
-				return
-			}
-			stderrFile, err := os.OpenFile(kw.stderrFilePath, os.O_WRONLY|os.O_SYNC, outputFilePrivileges)
-			log.Debugf("K8s task copier: stderr destination file opened: %q", kw.stderrFilePath)
-			if err != nil {
-				log.Errorf("K8s copier: cannot open file to copy logs: %s", err.Error())
-				return
-			}
-			defer syncAndClose(stdoutFile)
-			defer syncAndClose(stderrFile)
-
-			log.Debug("K8s copier: starting copying pod output")
-			_, err = io.Copy(io.MultiWriter(stdoutFile, stderrFile), logStream)
-			log.Debug("K8s copier: finished copying pod output")
-			if err != nil {
-				log.Errorf("K8s copier: failed to copy container log stream to task output: %s", err.Error())
-				return
-			}
+	log.Debugf("K8s copier: log copy and sync done for container %q of pod %q", container, kw.pod.Name)
+}
 
-			log.Debugf("K8s copier: log copy and sync done for pod %q", kw.pod.Name)
-		}()
-	})
+// containerOutputFilePath returns path of output file of sidecar container, placed next to task stdout file.
+func containerOutputFilePath(stdoutFilePath, container string) string {
+	return filepath.Join(filepath.Dir(stdoutFilePath), container+".log")
 }
 
 //NewRuntimeScheme creates instance of runtime.Scheme and registers default conversions.
@@ -0,0 +1,701 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/k8sports"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	batchclient "k8s.io/client-go/kubernetes/typed/batch/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+)
+
+// KubernetesJobConfig describes Kubernetes Job created for every task when set in KubernetesConfig.
+// Jobs retry failed pods, so they are suitable for batch Best Effort tasks.
+type KubernetesJobConfig struct {
+	// Completions is a number of pods that need to succeed.
+	Completions int32
+	// Parallelism is a maximum number of pods running at once.
+	Parallelism int32
+	// BackoffLimit is a number of retries before the job is considered failed (requires Kubernetes 1.8 or newer).
+	// Cluster default is used when negative.
+	BackoffLimit int32
+	// ActiveDeadline limits duration of the job. Not limited when 0.
+	ActiveDeadline time.Duration
+}
+
+// DefaultKubernetesJobConfig returns KubernetesJobConfig of job running single pod.
+func DefaultKubernetesJobConfig() KubernetesJobConfig {
+	return KubernetesJobConfig{
+		Completions:  1,
+		Parallelism:  1,
+		BackoffLimit: -1,
+	}
+}
+
+// jobNameLabel is set by Kubernetes on pods created by a job.
+const jobNameLabel = "job-name"
+
+// MultiPodTask is optionally implemented by TaskHandles of tasks run in many pods at once (jobs with parallelism).
+// Address() of such tasks does not identify all of them.
+type MultiPodTask interface {
+	// PodAddresses blocks until all the parallel pods of the task are ready (their readiness probe succeeded
+	// when configured) and returns their IP addresses.
+	PodAddresses() ([]string, error)
+}
+
+// GetMultiPodTask returns MultiPodTask implemented by the task or any of the tasks it wraps (see WrappedTask).
+func GetMultiPodTask(handle TaskHandle) (MultiPodTask, bool) {
+	for _, task := range unwrapTask(handle) {
+		if multiPodTask, ok := task.(MultiPodTask); ok {
+			return multiPodTask, true
+		}
+	}
+	return nil, false
+}
+
+// newJob builds Kubernetes Job running pods created by newPod.
+func (k8s *k8s) newJob(command string) (*batchv1.Job, error) {
+	pod, err := k8s.newPod(command)
+	if err != nil {
+		return nil, err
+	}
+
+	config := k8s.config.Job
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Labels:    map[string]string{"name": pod.Name},
+		},
+		Spec: batchv1.JobSpec{
+			Completions: &config.Completions,
+			Parallelism: &config.Parallelism,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: pod.Labels},
+				Spec:       pod.Spec,
+			},
+		},
+	}
+	if config.ActiveDeadline > 0 {
+		activeDeadlineSeconds := int64(config.ActiveDeadline.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+	}
+	return job, nil
+}
+
+// createJob submits the job to the cluster.
+func (k8s *k8s) createJob(job *batchv1.Job) (*batchv1.Job, error) {
+	// Backoff limit and runtime class are not a part of vendored Kubernetes API.
+	fields := map[string]interface{}{}
+	if k8s.config.Job.BackoffLimit >= 0 {
+		fields["spec.backoffLimit"] = k8s.config.Job.BackoffLimit
+	}
+	runtimeClassName, err := k8s.runtimeClassName()
+	if err != nil {
+		return nil, err
+	}
+	if runtimeClassName != "" {
+		fields["spec.template.spec.runtimeClassName"] = runtimeClassName
+	}
+
+	if len(fields) == 0 {
+		return k8s.clientset.BatchV1().Jobs(k8s.config.Namespace).Create(job)
+	}
+
+	body, err := manifestWithFields(job, "batch/v1", "Job", fields)
+	if err != nil {
+		return nil, err
+	}
+	created := &batchv1.Job{}
+	err = k8s.clientset.BatchV1().RESTClient().Post().
+		Namespace(k8s.config.Namespace).
+		Resource("jobs").
+		Body(body).
+		Do().
+		Into(created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// executeJob creates a job running the command and waits until its first pod is running.
+func (k8s *k8s) executeJob(command string) (TaskHandle, error) {
+	command = k8s.config.Decorators.Decorate(command)
+	jobManifest, err := k8s.newJob(command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create job manifest")
+	}
+
+	jobsAPI := k8s.clientset.BatchV1().Jobs(k8s.config.Namespace)
+	podsAPI := k8s.clientset.CoreV1().Pods(k8s.config.Namespace)
+	jobSelector := metav1.ListOptions{LabelSelector: fmt.Sprintf("name=%s", jobManifest.Name)}
+	podSelector := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", jobNameLabel, jobManifest.Name)}
+	jobWatcher, err := jobsAPI.Watch(jobSelector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create watcher over selector %q", jobSelector.LabelSelector)
+	}
+	podWatcher, err := podsAPI.Watch(podSelector)
+	if err != nil {
+		jobWatcher.Stop()
+		return nil, errors.Wrapf(err, "cannot create watcher over selector %q", podSelector.LabelSelector)
+	}
+	stopWatchers := func() {
+		jobWatcher.Stop()
+		podWatcher.Stop()
+	}
+
+	log.Debugf("Starting %q job=%s on kubernetes", command, jobManifest.Name)
+	job, err := k8s.createJob(jobManifest)
+	if err != nil {
+		stopWatchers()
+		return nil, Retryable(errors.Wrapf(err, "cannot create job %q in namespace %q", jobManifest.Name, k8s.config.Namespace))
+	}
+
+	outputDirectory, err := createOutputDirectory(command, "kubernetes")
+	if err != nil {
+		stopWatchers()
+		jobsAPI.Delete(job.Name, &metav1.DeleteOptions{})
+		return nil, err
+	}
+	stdoutFile, stderrFile, err := createExecutorOutputFiles(outputDirectory)
+	if err != nil {
+		stopWatchers()
+		jobsAPI.Delete(job.Name, &metav1.DeleteOptions{})
+		removeDirectory(outputDirectory)
+		return nil, err
+	}
+	stdoutFile.Close()
+	stderrFile.Close()
+
+	taskHandle := newK8sJobTaskHandle(job.Name, command, k8s.config, jobsAPI, podsAPI, newPodLogsFunc(k8s.clientset, k8s.config.Namespace))
+	taskHandle.stdoutFilePath = stdoutFile.Name()
+	taskHandle.stderrFilePath = stderrFile.Name()
+	go taskHandle.watch(jobWatcher, podWatcher, jobSelector, podSelector)
+
+	select {
+	case <-taskHandle.started:
+	case <-taskHandle.stopped:
+	case <-getTimeoutChan(k8s.config.LaunchTimeout):
+		taskHandle.Stop()
+		removeDirectory(outputDirectory)
+		return nil, Retryable(&LaunchTimedOutError{
+			errorMessage: fmt.Sprintf("pods of job %q have not been started within %s", job.Name, k8s.config.LaunchTimeout),
+		})
+	}
+
+	RegisterTaskHandle(taskHandle)
+	return taskHandle, nil
+}
+
+// jobPodsDeletionTimeout is a time to wait for deletion events of pods of deleted job, which carry final state of the pods.
+const jobPodsDeletionTimeout = 10 * time.Second
+
+// jobPod is a state of a pod of the job observed by the watcher.
+type jobPod struct {
+	created time.Time
+	status  PodStatus
+	hostIP  string
+	// exitCode of the task container; nil until it terminates.
+	exitCode *int
+	// killedOnDeletion is set when the task container was terminated because the pod was deleted.
+	killedOnDeletion bool
+	streaming        bool
+	deleted          bool
+}
+
+// k8sJobTaskHandle controls task run as Kubernetes Job. Output of all the pods of the job
+// (including retried ones) is streamed to task output files while they run.
+type k8sJobTaskHandle struct {
+	jobName       string
+	containerName string
+	cgroupDriver  string
+	command       string
+	jobsAPI       batchclient.JobInterface
+	podsAPI       corev1.PodInterface
+	podLogs       podLogsFunc
+	parallelism   int
+	launchTimeout time.Duration
+
+	stdoutFilePath string
+	stderrFilePath string
+
+	started       chan struct{}
+	stopped       chan struct{}
+	requestDelete chan struct{}
+
+	startOnce, deleteOnce sync.Once
+	// streams are goroutines copying logs of the pods to output files.
+	streams sync.WaitGroup
+
+	mutex         sync.Mutex
+	pods          map[string]*jobPod
+	succeeded     bool
+	exitCode      int
+	stoppedByUser bool
+	// watchErr is set when the job could not be watched anymore, so its state is unknown.
+	watchErr error
+}
+
+func newK8sJobTaskHandle(jobName, command string, config KubernetesConfig, jobsAPI batchclient.JobInterface, podsAPI corev1.PodInterface, podLogs podLogsFunc) *k8sJobTaskHandle {
+	return &k8sJobTaskHandle{
+		jobName:       jobName,
+		containerName: config.ContainerName,
+		cgroupDriver:  config.CgroupDriver,
+		command:       command,
+		jobsAPI:       jobsAPI,
+		podsAPI:       podsAPI,
+		podLogs:       podLogs,
+		parallelism:   parallelPods(config.Job),
+		launchTimeout: config.LaunchTimeout,
+		started:       make(chan struct{}),
+		stopped:       make(chan struct{}),
+		requestDelete: make(chan struct{}, 1),
+		pods:          map[string]*jobPod{},
+	}
+}
+
+// watch keeps task handle in sync with the job and its pods until the job and the pods are deleted.
+func (th *k8sJobTaskHandle) watch(jobWatcher, podWatcher watch.Interface, jobSelector, podSelector metav1.ListOptions) {
+	jobEvents, podEvents := jobWatcher.ResultChan(), podWatcher.ResultChan()
+	var podsDeletionTimeout <-chan time.Time
+	defer func() {
+		jobWatcher.Stop()
+		podWatcher.Stop()
+		th.finish()
+	}()
+
+	for {
+		if podsDeletionTimeout != nil && th.allPodsDeleted() {
+			return
+		}
+
+		select {
+		case event, ok := <-jobEvents:
+			if !ok {
+				log.Debugf("Job %s: watcher event channel was unexpectedly closed. Recreating.", th.jobName)
+				var err error
+				jobWatcher, err = th.jobsAPI.Watch(jobSelector)
+				if err != nil {
+					th.failWatch(errors.Wrapf(err, "cannot recreate watcher of job %q", th.jobName))
+					return
+				}
+				jobEvents = jobWatcher.ResultChan()
+				continue
+			}
+			job, ok := event.Object.(*batchv1.Job)
+			if !ok {
+				continue
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if finished, succeeded := jobFinished(job); finished {
+					th.mutex.Lock()
+					th.succeeded = succeeded
+					th.mutex.Unlock()
+					th.deleteJob()
+				}
+			case watch.Deleted:
+				// Pods are deleted before the job (foreground deletion), but their events might still be queued.
+				jobEvents = nil
+				podsDeletionTimeout = time.After(jobPodsDeletionTimeout)
+			case watch.Error:
+				log.Errorf("K8s job watcher: kubernetes job error event: %v", event.Object)
+			}
+
+		case event, ok := <-podEvents:
+			if !ok {
+				log.Debugf("Job %s: pod watcher event channel was unexpectedly closed. Recreating.", th.jobName)
+				var err error
+				podWatcher, err = th.podsAPI.Watch(podSelector)
+				if err != nil {
+					th.failWatch(errors.Wrapf(err, "cannot recreate watcher of pods of job %q", th.jobName))
+					return
+				}
+				podEvents = podWatcher.ResultChan()
+				continue
+			}
+			pod, ok := event.Object.(*v1.Pod)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				th.updatePod(pod, event.Type == watch.Deleted)
+			case watch.Error:
+				log.Errorf("K8s job watcher: kubernetes pod error event: %v", event.Object)
+			}
+
+		case <-th.requestDelete:
+			th.deleteJob()
+
+		case <-podsDeletionTimeout:
+			log.Warnf("K8s job watcher: pods of job %q have not been deleted within %s", th.jobName, jobPodsDeletionTimeout)
+			return
+		}
+	}
+}
+
+// updatePod records state of the pod. Job is started when any of its pods is running; logs of the pod
+// are streamed as soon as its task container is started.
+func (th *k8sJobTaskHandle) updatePod(pod *v1.Pod, deleted bool) {
+	th.mutex.Lock()
+	state, ok := th.pods[pod.Name]
+	if !ok {
+		state = &jobPod{created: pod.CreationTimestamp.Time}
+		th.pods[pod.Name] = state
+	}
+	state.status = newPodStatus(pod, th.containerName, th.cgroupDriver)
+	state.hostIP = pod.Status.HostIP
+	state.deleted = deleted
+	containerStarted := false
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != th.containerName {
+			continue
+		}
+		containerStarted = status.State.Running != nil || status.State.Terminated != nil
+		if status.State.Terminated != nil && state.exitCode == nil {
+			exitCode := int(status.State.Terminated.ExitCode)
+			state.exitCode = &exitCode
+			state.killedOnDeletion = pod.DeletionTimestamp != nil
+		}
+	}
+	startStreaming := containerStarted && !state.streaming
+	state.streaming = state.streaming || startStreaming
+	th.mutex.Unlock()
+
+	switch pod.Status.Phase {
+	case v1.PodRunning, v1.PodSucceeded, v1.PodFailed:
+		th.startOnce.Do(func() { close(th.started) })
+	}
+	if startStreaming {
+		th.streams.Add(1)
+		go func() {
+			defer th.streams.Done()
+			th.streamPodLogs(pod.Name)
+		}()
+	}
+}
+
+func (th *k8sJobTaskHandle) allPodsDeleted() bool {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	for _, pod := range th.pods {
+		if !pod.deleted {
+			return false
+		}
+	}
+	return true
+}
+
+// failWatch records error of the watcher and deletes the job, so it is not left running unobserved.
+func (th *k8sJobTaskHandle) failWatch(err error) {
+	log.Errorf("K8s job watcher: %s", err.Error())
+	th.mutex.Lock()
+	th.watchErr = err
+	th.mutex.Unlock()
+	th.deleteJob()
+}
+
+// jobFinished returns true when job is complete or failed.
+func jobFinished(job *batchv1.Job) (finished, succeeded bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, true
+		case batchv1.JobFailed:
+			log.Warnf("K8s job watcher: job failed: %s", condition.Message)
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// finish waits for output of all the pods to be copied, determines exit code and marks the task as terminated.
+// Exit code is 0 when the job succeeded, otherwise it is exit code of the last failed pod (read after the pods are deleted).
+func (th *k8sJobTaskHandle) finish() {
+	th.streams.Wait()
+
+	th.mutex.Lock()
+	exitCode := 0
+	if !th.succeeded || th.watchErr != nil {
+		exitCode = -1
+		for _, pod := range th.sortedPods() {
+			if pod.exitCode != nil && *pod.exitCode != 0 {
+				exitCode = *pod.exitCode
+			}
+		}
+	}
+	th.exitCode = exitCode
+	th.mutex.Unlock()
+
+	log.Debugf("K8s job watcher: job %q finished with exit code %d", th.jobName, exitCode)
+	close(th.stopped)
+}
+
+// sortedPods returns pods of the job in order of creation. Mutex must be held.
+func (th *k8sJobTaskHandle) sortedPods() []*jobPod {
+	var pods []*jobPod
+	for _, pod := range th.pods {
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].created.Before(pods[j].created)
+	})
+	return pods
+}
+
+// parallelPods returns number of pods of the job running at once.
+func parallelPods(config *KubernetesJobConfig) int {
+	if config.Completions < config.Parallelism {
+		return int(config.Completions)
+	}
+	return int(config.Parallelism)
+}
+
+// PodAddresses implements MultiPodTask interface. It waits up to launch timeout for the parallel pods
+// of the job to be ready and returns their IP addresses in order of creation.
+func (th *k8sJobTaskHandle) PodAddresses() ([]string, error) {
+	selector := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", jobNameLabel, th.jobName)}
+	timeout := getTimeoutChan(th.launchTimeout)
+	for {
+		pods, err := th.podsAPI.List(selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list pods of job %q", th.jobName)
+		}
+		addresses := readyPodAddresses(pods.Items)
+		if len(addresses) >= th.parallelism {
+			return addresses[:th.parallelism], nil
+		}
+
+		select {
+		case <-th.stopped:
+			return nil, errors.Errorf("job %q finished before all its %d pods were ready", th.jobName, th.parallelism)
+		case <-timeout:
+			return nil, Retryable(&LaunchTimedOutError{
+				errorMessage: fmt.Sprintf("only %d of %d pods of job %q have been ready within %s", len(addresses), th.parallelism, th.jobName, th.launchTimeout),
+			})
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// readyPodAddresses returns IP addresses of running and ready pods sorted by their creation time.
+func readyPodAddresses(pods []v1.Pod) (addresses []string) {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Time.Before(pods[j].CreationTimestamp.Time)
+	})
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodRunning && k8sports.IsPodReady(&pod) && pod.Status.PodIP != "" {
+			addresses = append(addresses, pod.Status.PodIP)
+		}
+	}
+	return addresses
+}
+
+// streamPodLogs appends output of the task container of the pod to task output files as it appears.
+// Output files are opened in append mode, so lines of parallel pods are not mixed.
+func (th *k8sJobTaskHandle) streamPodLogs(podName string) {
+	var writers []io.Writer
+	for _, path := range []string{th.stdoutFilePath, th.stderrFilePath} {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, outputFilePrivileges)
+		if err != nil {
+			log.Errorf("K8s job watcher: cannot open file to copy logs: %s", err.Error())
+			return
+		}
+		defer syncAndClose(file)
+		writers = append(writers, file)
+	}
+
+	streamer := &containerLogStreamer{
+		podsAPI:   th.podsAPI,
+		podLogs:   th.podLogs,
+		podName:   podName,
+		container: th.containerName,
+		output:    io.MultiWriter(writers...),
+	}
+	err := streamer.stream()
+	if err != nil {
+		log.Errorf("K8s job watcher: failed to copy logs of pod %q: %s", podName, err.Error())
+	}
+}
+
+// deleteJob deletes the job along with its pods.
+func (th *k8sJobTaskHandle) deleteJob() {
+	th.deleteOnce.Do(func() {
+		gracePeriodSeconds := int64(DefaultStopPolicy().GracePeriod.Seconds())
+		if gracePeriodSeconds < 1 {
+			gracePeriodSeconds = 1
+		}
+		propagationPolicy := metav1.DeletePropagationForeground
+		err := th.jobsAPI.Delete(th.jobName, &metav1.DeleteOptions{
+			GracePeriodSeconds: &gracePeriodSeconds,
+			PropagationPolicy:  &propagationPolicy,
+		})
+		if err != nil {
+			log.Warnf("unsuccessful attempt to delete job %q: %s", th.jobName, err.Error())
+		}
+	})
+}
+
+func (th *k8sJobTaskHandle) isTerminated() bool {
+	select {
+	case <-th.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop deletes the job and its pods and blocks until done.
+// Error is returned when the job could not be watched, so it is not known whether it has been deleted.
+func (th *k8sJobTaskHandle) Stop() error {
+	if th.isTerminated() {
+		return th.getWatchErr()
+	}
+
+	th.mutex.Lock()
+	th.stoppedByUser = true
+	th.mutex.Unlock()
+
+	select {
+	case th.requestDelete <- struct{}{}:
+	default:
+	}
+	<-th.stopped
+	return th.getWatchErr()
+}
+
+func (th *k8sJobTaskHandle) getWatchErr() error {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	return th.watchErr
+}
+
+// StopStage implements StopStageReporter interface. Job is stopped forcefully when task container
+// of any of its pods was killed with SIGKILL on deletion.
+func (th *k8sJobTaskHandle) StopStage() StopStage {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	if !th.stoppedByUser {
+		return NotStopped
+	}
+	for _, pod := range th.pods {
+		if pod.killedOnDeletion && *pod.exitCode == 128+int(syscall.SIGKILL) {
+			return StoppedForcefully
+		}
+	}
+	return StoppedGracefully
+}
+
+// Pause is not supported for jobs.
+func (th *k8sJobTaskHandle) Pause() error {
+	return errors.Errorf("job %q cannot be paused", th.jobName)
+}
+
+// Resume is not supported for jobs.
+func (th *k8sJobTaskHandle) Resume() error {
+	return errors.Errorf("job %q cannot be resumed", th.jobName)
+}
+
+// Status returns TERMINATED when the job is deleted.
+func (th *k8sJobTaskHandle) Status() TaskState {
+	if th.isTerminated() {
+		return TERMINATED
+	}
+	return RUNNING
+}
+
+// ExitCode returns 0 when the job succeeded or exit code of the last failed pod.
+func (th *k8sJobTaskHandle) ExitCode() (int, error) {
+	if !th.isTerminated() {
+		return 0, errors.New("task is still running")
+	}
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	return th.exitCode, th.watchErr
+}
+
+// Wait blocks until the job is deleted or timeout expires.
+func (th *k8sJobTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	select {
+	case <-th.stopped:
+		return true, th.getWatchErr()
+	case <-getTimeoutChan(timeout):
+		return false, nil
+	}
+}
+
+// EraseOutput deletes the directory where output files reside.
+func (th *k8sJobTaskHandle) EraseOutput() error {
+	return removeDirectory(filepath.Dir(th.stdoutFilePath))
+}
+
+// StdoutFile returns a file handle to the stdout file of the job.
+func (th *k8sJobTaskHandle) StdoutFile() (*os.File, error) {
+	return openFile(th.stdoutFilePath)
+}
+
+// StderrFile returns a file handle to the stderr file of the job (same content as stdout).
+func (th *k8sJobTaskHandle) StderrFile() (*os.File, error) {
+	return openFile(th.stderrFilePath)
+}
+
+// Address returns the host IP of the last scheduled pod of the job.
+func (th *k8sJobTaskHandle) Address() string {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	var hostIP string
+	for _, pod := range th.sortedPods() {
+		if pod.hostIP != "" {
+			hostIP = pod.hostIP
+		}
+	}
+	return hostIP
+}
+
+// PodStatuses implements PodStatusReporter interface. Statuses of all the pods of the job are returned in order of creation.
+func (th *k8sJobTaskHandle) PodStatuses() []PodStatus {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	var statuses []PodStatus
+	for _, pod := range th.sortedPods() {
+		statuses = append(statuses, pod.status)
+	}
+	return statuses
+}
+
+// String returns user-friendly name of the task.
+func (th *k8sJobTaskHandle) String() string {
+	return fmt.Sprintf("Kubernetes job named %q with command %q", th.jobName, th.command)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/k8sfake"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/pkg/api/v1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetesJob(t *testing.T) {
+	Convey("When Kubernetes executor runs tasks as jobs", t, func() {
+		jobConfig := DefaultKubernetesJobConfig()
+		jobConfig.Completions = 3
+		jobConfig.ActiveDeadline = time.Minute
+		config := DefaultKubernetesConfig()
+		config.PodNamePrefix = "swan-be"
+		config.Job = &jobConfig
+		podExecutor := &k8s{config, nil}
+
+		job, err := podExecutor.newJob("stress-ng")
+		So(err, ShouldBeNil)
+
+		Convey("Job should run pods built from executor configuration", func() {
+			So(job.Name, ShouldStartWith, "swan-be-")
+			So(job.Labels["name"], ShouldEqual, job.Name)
+			So(*job.Spec.Completions, ShouldEqual, 3)
+			So(*job.Spec.ActiveDeadlineSeconds, ShouldEqual, 60)
+			So(job.Spec.Template.Spec.RestartPolicy, ShouldEqual, v1.RestartPolicyNever)
+			So(job.Spec.Template.Spec.Containers[0].Command, ShouldResemble, []string{"sh", "-c", "stress-ng"})
+		})
+
+		Convey("Backoff limit should be added to job manifest", func() {
+			manifest, err := manifestWithFields(job, "batch/v1", "Job", map[string]interface{}{"spec.backoffLimit": 2})
+			So(err, ShouldBeNil)
+			So(string(manifest), ShouldContainSubstring, `"backoffLimit":2`)
+			So(string(manifest), ShouldContainSubstring, `"kind":"Job"`)
+		})
+
+		Convey("Job should be finished when it is complete or failed", func() {
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			finished, succeeded := jobFinished(job)
+			So(finished, ShouldBeTrue)
+			So(succeeded, ShouldBeFalse)
+		})
+	})
+}
+
+func TestKubernetesJobPodAddresses(t *testing.T) {
+	Convey("When Kubernetes job runs parallel pods", t, func() {
+		cluster := k8sfake.NewCluster()
+		cluster.AddNode("second-node", "127.0.0.2")
+		podsAPI := cluster.CoreV1().Pods(v1.NamespaceDefault)
+		handle := &k8sJobTaskHandle{
+			jobName:       "swan-agents",
+			podsAPI:       podsAPI,
+			parallelism:   parallelPods(&KubernetesJobConfig{Completions: 2, Parallelism: 3}),
+			launchTimeout: waitTimeout,
+			stopped:       make(chan struct{}),
+		}
+		So(handle.parallelism, ShouldEqual, 2)
+
+		for _, node := range []string{k8sfake.DefaultNodeName, "second-node"} {
+			_, err := podsAPI.Create(&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "swan-agents-" + node, Labels: map[string]string{jobNameLabel: "swan-agents"}},
+				Spec: v1.PodSpec{
+					NodeName:   node,
+					Containers: []v1.Container{{Name: "swan", ReadinessProbe: &v1.Probe{}}},
+				},
+			})
+			So(err, ShouldBeNil)
+		}
+		So(cluster.RunPod("swan-agents-"+k8sfake.DefaultNodeName), ShouldBeNil)
+		So(cluster.SetPodReady("swan-agents-"+k8sfake.DefaultNodeName, true), ShouldBeNil)
+
+		Convey("Addresses of all the pods should be returned when they are ready", func() {
+			So(cluster.RunPod("swan-agents-second-node"), ShouldBeNil)
+			So(cluster.SetPodReady("swan-agents-second-node", true), ShouldBeNil)
+
+			addresses, err := handle.PodAddresses()
+			So(err, ShouldBeNil)
+			So(addresses, ShouldHaveLength, 2)
+			So(addresses, ShouldContain, "127.0.0.1")
+			So(addresses, ShouldContain, "127.0.0.2")
+		})
+
+		Convey("Retryable error should be returned when some pods are not running in time", func() {
+			handle.launchTimeout = 200 * time.Millisecond
+
+			_, err := handle.PodAddresses()
+			So(err, ShouldNotBeNil)
+			So(IsRetryable(err), ShouldBeTrue)
+		})
+
+		Convey("Running pods should not be returned until their readiness probe succeeds", func() {
+			So(cluster.RunPod("swan-agents-second-node"), ShouldBeNil)
+			handle.launchTimeout = 200 * time.Millisecond
+
+			_, err := handle.PodAddresses()
+			So(err, ShouldNotBeNil)
+			So(IsRetryable(err), ShouldBeTrue)
+		})
+	})
+}
+
+func TestKubernetesJobWatch(t *testing.T) {
+	Convey("When Kubernetes job with one pod is watched", t, func() {
+		cluster := k8sfake.NewCluster()
+		config := DefaultKubernetesConfig()
+		jobConfig := DefaultKubernetesJobConfig()
+		config.Job = &jobConfig
+		podsAPI := cluster.CoreV1().Pods(v1.NamespaceDefault)
+		handle := newK8sJobTaskHandle("swan-job", "stress-ng", config, cluster.BatchV1().Jobs(v1.NamespaceDefault), podsAPI, newPodLogsFunc(cluster, v1.NamespaceDefault))
+
+		outputDirectory, err := ioutil.TempDir("", "kubernetes-job")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(outputDirectory)
+		handle.stdoutFilePath = filepath.Join(outputDirectory, "stdout")
+		handle.stderrFilePath = filepath.Join(outputDirectory, "stderr")
+		So(ioutil.WriteFile(handle.stdoutFilePath, nil, outputFilePrivileges), ShouldBeNil)
+		So(ioutil.WriteFile(handle.stderrFilePath, nil, outputFilePrivileges), ShouldBeNil)
+
+		podSelector := metav1.ListOptions{LabelSelector: jobNameLabel + "=swan-job"}
+		podWatcher, err := podsAPI.Watch(podSelector)
+		So(err, ShouldBeNil)
+		jobWatcher := watch.NewFake()
+		go handle.watch(jobWatcher, podWatcher, metav1.ListOptions{}, podSelector)
+
+		_, err = podsAPI.Create(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "swan-job-1", Labels: map[string]string{jobNameLabel: "swan-job"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: config.ContainerName}}},
+		})
+		So(err, ShouldBeNil)
+
+		Convey("Job should not be started while its pod is pending", func() {
+			select {
+			case <-handle.started:
+				t.Error("job has been started with pending pod")
+			case <-time.After(200 * time.Millisecond):
+			}
+
+			So(cluster.RunPod("swan-job-1"), ShouldBeNil)
+			select {
+			case <-handle.started:
+			case <-time.After(waitTimeout):
+				t.Error("job has not been started with running pod")
+			}
+
+			Convey("And output of the pod should be streamed while it runs", func() {
+				So(cluster.WriteLog("swan-job-1", config.ContainerName, "progress\n"), ShouldBeNil)
+				So(waitForFileContent(handle.stdoutFilePath, "progress"), ShouldBeTrue)
+				So(handle.Status(), ShouldEqual, RUNNING)
+			})
+
+			Convey("And exit code should be read after pod is deleted when job is stopped", func() {
+				cluster.TerminationExitCode = k8sfake.EvictedExitCode
+				stopped := make(chan error)
+				go func() { stopped <- handle.Stop() }()
+
+				So(cluster.DeletePod("swan-job-1"), ShouldBeNil)
+				jobWatcher.Delete(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "swan-job"}})
+				So(<-stopped, ShouldBeNil)
+
+				exitCode, err := handle.ExitCode()
+				So(err, ShouldBeNil)
+				So(exitCode, ShouldEqual, int(k8sfake.EvictedExitCode))
+				So(handle.StopStage(), ShouldEqual, StoppedForcefully)
+			})
+		})
+
+		Convey("Failure to recreate job watcher should be returned by the handle", func() {
+			cluster.PrependWatchReactor("jobs", func(clienttesting.Action) (bool, watch.Interface, error) {
+				return true, nil, errors.New("connection refused")
+			})
+			jobWatcher.Stop()
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(terminated, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "connection refused")
+			_, err = handle.ExitCode()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// waitForFileContent returns true when file contains text within waitTimeout.
+func waitForFileContent(path, text string) bool {
+	timeout := time.After(waitTimeout)
+	for {
+		content, err := ioutil.ReadFile(path)
+		if err == nil && strings.Contains(string(content), text) {
+			return true
+		}
+		select {
+		case <-timeout:
+			return false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/k8sfake"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKubernetesPodLifecycle(t *testing.T) {
+	Convey("When Kubernetes executor runs a task on the cluster", t, func() {
+		cluster := k8sfake.NewCluster()
+		config := DefaultKubernetesConfig()
+		config.LaunchTimeout = waitTimeout
+		podExecutor := NewKubernetesWithClientset(config, cluster)
+
+		go func() {
+			podName := <-cluster.PodCreated()
+			cluster.WriteLog(podName, config.ContainerName, "output\n")
+			cluster.RunPod(podName)
+		}()
+		handle, err := podExecutor.Execute("test")
+		So(err, ShouldBeNil)
+		defer handle.EraseOutput()
+		defer handle.Stop()
+		podName := handle.(*k8sTaskHandle).podName
+
+		So(handle.Status(), ShouldEqual, RUNNING)
+		_, err = handle.ExitCode()
+		So(err, ShouldNotBeNil)
+
+		Convey("Task should succeed with pod and its output should be copied", func() {
+			So(cluster.SucceedPod(podName), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+			So(handle.(StopStageReporter).StopStage(), ShouldEqual, NotStopped)
+
+			stdout, err := handle.StdoutFile()
+			So(err, ShouldBeNil)
+			defer stdout.Close()
+			output, err := ioutil.ReadAll(stdout)
+			So(err, ShouldBeNil)
+			So(string(output), ShouldEqual, "output\n")
+
+			statuses := handle.(PodStatusReporter).PodStatuses()
+			So(statuses, ShouldHaveLength, 1)
+			So(statuses[0].Name, ShouldEqual, podName)
+			So(statuses[0].Node, ShouldEqual, k8sfake.DefaultNodeName)
+			So(statuses[0].QOSClass, ShouldEqual, "BestEffort")
+			So(statuses[0].CgroupPath, ShouldStartWith, "kubepods/besteffort/pod")
+			So(statuses[0].TerminationReason, ShouldEqual, "Completed")
+			So(CheckEviction(handle), ShouldBeNil)
+
+			Convey("And the pod should be deleted", func() {
+				So(cluster.PodNames(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("Task should fail with exit code of the container", func() {
+			So(cluster.FailPod(podName, 2), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 2)
+		})
+
+		Convey("Task should be terminated when pod is evicted", func() {
+			So(cluster.EvictPod(podName), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, int(k8sfake.EvictedExitCode))
+			So(cluster.PodNames(), ShouldBeEmpty)
+
+			statuses := handle.(PodStatusReporter).PodStatuses()
+			So(statuses, ShouldHaveLength, 1)
+			So(statuses[0].TerminationReason, ShouldEqual, EvictedReason)
+			So(statuses[0].Node, ShouldEqual, k8sfake.DefaultNodeName)
+			err = CheckEviction(handle)
+			So(IsEvicted(err), ShouldBeTrue)
+			So(err.(*EvictedError).Pod, ShouldEqual, podName)
+		})
+
+		Convey("Task should be terminated when its container is OOM-killed", func() {
+			So(cluster.OOMKillContainer(podName, config.ContainerName), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			So(handle.(PodStatusReporter).PodStatuses()[0].TerminationReason, ShouldEqual, OOMKilledReason)
+			So(IsEvicted(CheckEviction(handle)), ShouldBeTrue)
+		})
+
+		Convey("Service should report eviction of its pod as premature termination", func() {
+			service := NewServiceHandle(handle)
+			So(cluster.EvictPod(podName), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			err = service.Stop()
+			So(err, ShouldNotBeNil)
+			So(IsEvicted(err), ShouldBeTrue)
+		})
+
+		Convey("Task should be terminated when pod is deleted by someone else", func() {
+			So(cluster.DeletePod(podName), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, int(k8sfake.TerminatedExitCode))
+			So(handle.(StopStageReporter).StopStage(), ShouldEqual, NotStopped)
+		})
+
+		Convey("Stopped task should be terminated gracefully", func() {
+			So(handle.Stop(), ShouldBeNil)
+
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, int(k8sfake.TerminatedExitCode))
+			So(handle.(StopStageReporter).StopStage(), ShouldEqual, StoppedGracefully)
+			So(cluster.PodNames(), ShouldBeEmpty)
+		})
+
+		Convey("Stopped task ignoring SIGTERM should be killed", func() {
+			cluster.TerminationExitCode = k8sfake.EvictedExitCode
+			So(handle.Stop(), ShouldBeNil)
+
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, int(k8sfake.EvictedExitCode))
+			So(handle.(StopStageReporter).StopStage(), ShouldEqual, StoppedForcefully)
+		})
+
+		Convey("Task should keep running when node is not ready", func() {
+			So(cluster.SetNodeReady(k8sfake.DefaultNodeName, false), ShouldBeNil)
+
+			terminated, err := handle.Wait(100 * time.Millisecond)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeFalse)
+			So(handle.Status(), ShouldEqual, RUNNING)
+
+			Convey("And finish when node gets ready again", func() {
+				So(cluster.SetNodeReady(k8sfake.DefaultNodeName, true), ShouldBeNil)
+				So(cluster.SucceedPod(podName), ShouldBeNil)
+
+				terminated, err := handle.Wait(waitTimeout)
+				So(err, ShouldBeNil)
+				So(terminated, ShouldBeTrue)
+				exitCode, err := handle.ExitCode()
+				So(err, ShouldBeNil)
+				So(exitCode, ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("When pod is not started within launch timeout", t, func() {
+		cluster := k8sfake.NewCluster()
+		config := DefaultKubernetesConfig()
+		config.LaunchTimeout = 100 * time.Millisecond
+
+		handle, err := NewKubernetesWithClientset(config, cluster).Execute("test")
+
+		Convey("Executor should return retryable launch timeout error and delete the pod", func() {
+			So(handle, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+			_, timedOut := errors.Cause(err).(*LaunchTimedOutError)
+			So(timedOut, ShouldBeTrue)
+			So(IsRetryable(err), ShouldBeTrue)
+			So(cluster.PodNames(), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	// logStreamReconnectInterval is a time between attempts to reopen broken log stream.
+	logStreamReconnectInterval = time.Second
+	// logStreamMaxFailures is a number of consecutive failures to open log stream after which streaming is given up.
+	logStreamMaxFailures = 10
+)
+
+// PodLogsStreamer is optionally implemented by Kubernetes clientsets which open pod log streams on their own.
+// Log requests of fake clientsets cannot be streamed, so fake clusters used in tests implement it instead.
+type PodLogsStreamer interface {
+	StreamPodLogs(namespace, podName string, options *v1.PodLogOptions) (io.ReadCloser, error)
+}
+
+// podLogsFunc opens log stream of the pod.
+type podLogsFunc func(podName string, options *v1.PodLogOptions) (io.ReadCloser, error)
+
+// newPodLogsFunc returns function opening log streams of pods in namespace through clientset.
+func newPodLogsFunc(clientset kubernetes.Interface, namespace string) podLogsFunc {
+	if streamer, ok := clientset.(PodLogsStreamer); ok {
+		return func(podName string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+			return streamer.StreamPodLogs(namespace, podName, options)
+		}
+	}
+	podsAPI := clientset.CoreV1().Pods(namespace)
+	return func(podName string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+		return podsAPI.GetLogs(podName, options).Stream()
+	}
+}
+
+// containerLogStreamer follows logs of a container and writes them to output as they appear.
+// Log stream is reopened when it breaks (API server errors, container restarts) and lines already
+// written are skipped using log timestamps, so output contains every line once.
+type containerLogStreamer struct {
+	podsAPI   corev1.PodInterface
+	podLogs   podLogsFunc
+	podName   string
+	container string
+	output    io.Writer
+
+	// lastTimestamp is a timestamp of the last line written and linesAtLastTimestamp is a number of lines written with it.
+	lastTimestamp        time.Time
+	linesAtLastTimestamp int
+	// restartCount is a number of container restarts already handled.
+	restartCount int32
+}
+
+// stream copies container logs until container terminates and all its logs are written.
+func (s *containerLogStreamer) stream() error {
+	failures := 0
+	retry := func(err error) error {
+		failures++
+		log.Warnf("K8s log streamer: log stream of container %q of pod %q broken (%d/%d): %s", s.container, s.podName, failures, logStreamMaxFailures, err.Error())
+		if failures >= logStreamMaxFailures {
+			return errors.Wrapf(err, "cannot stream logs of container %q of pod %q", s.container, s.podName)
+		}
+		time.Sleep(logStreamReconnectInterval)
+		return nil
+	}
+
+	for {
+		status, podExists, err := s.containerStatus()
+		if err != nil {
+			if err := retry(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if !podExists {
+			// Nothing more can be read.
+			return nil
+		}
+
+		if status != nil && status.RestartCount > s.restartCount {
+			// Container restarted; logs of the previous instance might have not been written completely.
+			s.restartCount = status.RestartCount
+			if err := s.copy(true, false); err != nil {
+				log.Warnf("K8s log streamer: cannot get logs of previous instance of container %q of pod %q: %s", s.container, s.podName, err.Error())
+			}
+		}
+
+		if containerTerminated(status) {
+			// Final read makes sure the last lines are written when stream was broken before container terminated.
+			err = s.copy(false, false)
+			if apierrors.IsNotFound(err) {
+				// Pod has been deleted meanwhile.
+				return nil
+			}
+			return err
+		}
+
+		err = s.copy(false, true)
+		if apierrors.IsNotFound(err) {
+			// Pod has been deleted meanwhile; it is checked again above.
+			continue
+		}
+		if err != nil {
+			if err := retry(err); err != nil {
+				return err
+			}
+			continue
+		}
+		failures = 0
+	}
+}
+
+// containerStatus returns current status of the container (nil when it is not known yet).
+func (s *containerLogStreamer) containerStatus() (status *v1.ContainerStatus, podExists bool, err error) {
+	pod, err := s.podsAPI.Get(s.podName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == s.container {
+			return &pod.Status.ContainerStatuses[i], true, nil
+		}
+	}
+	return nil, true, nil
+}
+
+// containerTerminated returns true when container terminated and will not be restarted (executor pods are never restarted).
+func containerTerminated(status *v1.ContainerStatus) bool {
+	return status != nil && status.State.Terminated != nil
+}
+
+// copy opens log stream of the container and writes lines that have not been written yet.
+func (s *containerLogStreamer) copy(previous, follow bool) error {
+	options := &v1.PodLogOptions{
+		Container:  s.container,
+		Follow:     follow,
+		Previous:   previous,
+		Timestamps: true,
+	}
+	if !previous && !s.lastTimestamp.IsZero() {
+		// API accepts time with second precision; lines from that second are filtered in write.
+		sinceTime := metav1.NewTime(s.lastTimestamp.Truncate(time.Second))
+		options.SinceTime = &sinceTime
+	}
+
+	logStream, err := s.podLogs(s.podName, options)
+	if err != nil {
+		return err
+	}
+	defer logStream.Close()
+	return s.write(logStream)
+}
+
+// write copies timestamped log lines to output, skipping lines written before and stripping timestamps.
+func (s *containerLogStreamer) write(logStream io.Reader) error {
+	reader := bufio.NewReader(logStream)
+	skipAtLastTimestamp := s.linesAtLastTimestamp
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			timestamp, text, ok := splitLogTimestamp(line)
+			switch {
+			case !ok:
+				text = line
+			case timestamp.Before(s.lastTimestamp):
+				text = ""
+			case timestamp.Equal(s.lastTimestamp):
+				if skipAtLastTimestamp > 0 {
+					skipAtLastTimestamp--
+					text = ""
+				} else {
+					s.linesAtLastTimestamp++
+				}
+			default:
+				s.lastTimestamp = timestamp
+				s.linesAtLastTimestamp = 1
+				skipAtLastTimestamp = 0
+			}
+
+			if text != "" {
+				if _, writeErr := io.WriteString(s.output, text); writeErr != nil {
+					return errors.Wrapf(writeErr, "cannot write logs of container %q", s.container)
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// splitLogTimestamp splits log line to RFC3339 timestamp added by Kubernetes and the line written by container.
+func splitLogTimestamp(line string) (time.Time, string, bool) {
+	separator := strings.IndexByte(line, ' ')
+	if separator < 0 {
+		return time.Time{}, "", false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, line[:separator])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return timestamp, line[separator+1:], true
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestContainerLogStreamer(t *testing.T) {
+	Convey("When log stream is reopened", t, func() {
+		output := &bytes.Buffer{}
+		streamer := &containerLogStreamer{container: "swan", output: output}
+
+		err := streamer.write(strings.NewReader(
+			"2017-06-01T10:00:00.100000000Z first\n" +
+				"2017-06-01T10:00:00.200000000Z second\n" +
+				"2017-06-01T10:00:00.200000000Z third\n"))
+		So(err, ShouldBeNil)
+
+		// Reopened stream starts at the beginning of the second of the last line.
+		err = streamer.write(strings.NewReader(
+			"2017-06-01T10:00:00.100000000Z first\n" +
+				"2017-06-01T10:00:00.200000000Z second\n" +
+				"2017-06-01T10:00:00.200000000Z third\n" +
+				"2017-06-01T10:00:00.200000000Z fourth\n" +
+				"2017-06-01T10:00:01.000000000Z fifth\n"))
+		So(err, ShouldBeNil)
+
+		Convey("Every line should be written once without timestamp", func() {
+			So(output.String(), ShouldEqual, "first\nsecond\nthird\nfourth\nfifth\n")
+		})
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/swan/pkg/k8sports"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Termination reasons reported by kubelet when it kills the task.
+const (
+	// EvictedReason is a reason of pod failure when kubelet evicted the pod under node resource pressure.
+	EvictedReason = "Evicted"
+	// OOMKilledReason is a reason of container termination when it was killed by kernel OOM killer.
+	OOMKilledReason = "OOMKilled"
+)
+
+// PodStatus describes how Kubernetes ran the pod of a task.
+type PodStatus struct {
+	Name string `json:"name"`
+	Node string `json:"node"`
+	// QOSClass is QoS class pod actually received (Guaranteed, Burstable or BestEffort).
+	QOSClass string `json:"qos_class"`
+	// CgroupPath is a path of pod cgroup (relative to cgroup hierarchy root) assigned by kubelet.
+	CgroupPath string `json:"cgroup_path"`
+	// Restarts is a restart count of the task container.
+	Restarts int32 `json:"restarts"`
+	// TerminationReason is a reason of pod failure or task container termination (e.g. Evicted, OOMKilled, Error).
+	// Empty while task is running.
+	TerminationReason  string `json:"termination_reason,omitempty"`
+	TerminationMessage string `json:"termination_message,omitempty"`
+}
+
+// Evicted returns true when the pod has been evicted by kubelet or the task container has been OOM-killed.
+func (status PodStatus) Evicted() bool {
+	return status.TerminationReason == EvictedReason || status.TerminationReason == OOMKilledReason
+}
+
+// newPodStatus returns status of the pod running task in container with given name.
+func newPodStatus(pod *v1.Pod, containerName, cgroupDriver string) PodStatus {
+	status := PodStatus{
+		Name:       pod.Name,
+		Node:       pod.Spec.NodeName,
+		QOSClass:   k8sports.GetPodQOSClass(pod),
+		CgroupPath: k8sports.PodCgroupPath(pod, cgroupDriver),
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != containerName {
+			continue
+		}
+		status.Restarts = containerStatus.RestartCount
+		if terminated := containerStatus.State.Terminated; terminated != nil {
+			status.TerminationReason = terminated.Reason
+			status.TerminationMessage = terminated.Message
+		}
+	}
+	// Reason of the pod (e.g. eviction) explains termination of its containers.
+	if pod.Status.Phase == v1.PodFailed && pod.Status.Reason != "" {
+		status.TerminationReason = pod.Status.Reason
+		status.TerminationMessage = pod.Status.Message
+	}
+	return status
+}
+
+// PodStatusReporter is optionally implemented by TaskHandles of tasks run in Kubernetes pods.
+type PodStatusReporter interface {
+	// PodStatuses returns the last observed status of every pod of the task.
+	PodStatuses() []PodStatus
+}
+
+// GetPodStatuses returns statuses of pods of the task. Nil is returned when task is not run in pods.
+func GetPodStatuses(handle TaskHandle) []PodStatus {
+	for _, task := range unwrapTask(handle) {
+		if reporter, ok := task.(PodStatusReporter); ok {
+			return reporter.PodStatuses()
+		}
+	}
+	return nil
+}
+
+// EvictedError is returned when task has been terminated, because kubelet evicted its pod or OOM-killed its container.
+type EvictedError struct {
+	Pod     string
+	Node    string
+	Reason  string
+	Message string
+}
+
+// Error implements error interface.
+func (e *EvictedError) Error() string {
+	message := fmt.Sprintf("pod %q on node %q has been terminated by kubelet (%s)", e.Pod, e.Node, e.Reason)
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+// IsEvicted returns true when err has been caused by eviction or OOM-kill of a pod.
+func IsEvicted(err error) bool {
+	_, ok := errors.Cause(err).(*EvictedError)
+	return ok
+}
+
+// CheckEviction returns EvictedError when any pod of the task has been evicted or OOM-killed.
+func CheckEviction(handle TaskHandle) error {
+	for _, status := range GetPodStatuses(handle) {
+		if status.Evicted() {
+			return &EvictedError{
+				Pod:     status.Name,
+				Node:    status.Node,
+				Reason:  status.TerminationReason,
+				Message: status.TerminationMessage,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// podTemplate is a pod manifest read from KubernetesConfig.PodTemplateFile.
+type podTemplate struct {
+	v1.Pod
+	// RuntimeClassName is not a part of vendored Kubernetes API (spec.runtimeClassName), so it is read separately.
+	RuntimeClassName string
+}
+
+// readPodTemplate decodes YAML or JSON pod manifest.
+func readPodTemplate(path string) (*podTemplate, error) {
+	manifest, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read pod template %q", path)
+	}
+
+	template := &podTemplate{}
+	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), len(manifest)).Decode(&template.Pod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decode pod template %q", path)
+	}
+
+	var runtimeClass struct {
+		Spec struct {
+			RuntimeClassName string `json:"runtimeClassName"`
+		} `json:"spec"`
+	}
+	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), len(manifest)).Decode(&runtimeClass)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decode pod template %q", path)
+	}
+	template.RuntimeClassName = runtimeClass.Spec.RuntimeClassName
+
+	if template.Kind != "" && template.Kind != "Pod" {
+		return nil, errors.Errorf("pod template %q describes %s instead of Pod", path, template.Kind)
+	}
+	return template, nil
+}
+
+// mergeResources returns resources of template container with requests and limits from overrides.
+func mergeResources(template, overrides v1.ResourceRequirements) v1.ResourceRequirements {
+	merged := v1.ResourceRequirements{Requests: v1.ResourceList{}, Limits: v1.ResourceList{}}
+	for _, resources := range []v1.ResourceRequirements{template, overrides} {
+		for name, quantity := range resources.Requests {
+			merged.Requests[name] = quantity
+		}
+		for name, quantity := range resources.Limits {
+			merged.Limits[name] = quantity
+		}
+	}
+	return merged
+}
+
+// manifestWithFields serializes Kubernetes object with additional fields that are not a part of vendored
+// Kubernetes API (e.g. spec.runtimeClassName of a pod). Fields are given as dot separated paths.
+func manifestWithFields(object interface{}, apiVersion, kind string, fields map[string]interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot encode %s", kind)
+	}
+
+	var manifest map[string]interface{}
+	err = json.Unmarshal(encoded, &manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decode %s", kind)
+	}
+	manifest["apiVersion"] = apiVersion
+	manifest["kind"] = kind
+
+	for path, value := range fields {
+		keys := strings.Split(path, ".")
+		parent := manifest
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent = child
+		}
+		parent[keys[len(keys)-1]] = value
+	}
+
+	return json.Marshal(manifest)
+}
@@ -15,7 +15,12 @@
 package executor
 
 import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"k8s.io/client-go/pkg/api"
@@ -70,6 +75,183 @@ func TestKubernetes(t *testing.T) {
 
 }
 
+const podTemplate = `
+apiVersion: v1
+kind: Pod
+metadata:
+  labels:
+    team: experiments
+spec:
+  runtimeClassName: kata
+  tolerations:
+  - key: experiment
+    operator: Exists
+    effect: NoSchedule
+  volumes:
+  - name: models
+    hostPath:
+      path: /opt/models
+  containers:
+  - name: ignored
+    image: template-image
+    resources:
+      limits:
+        memory: 1Gi
+    volumeMounts:
+    - name: models
+      mountPath: /models
+`
+
+func TestWrapPodCommand(t *testing.T) {
+	Convey("Command wrapped for pod should be run as a child of the shell", t, func() {
+		Convey("and its output and exit code should be passed through", func() {
+			output, err := exec.Command("sh", "-c", wrapPodCommand("echo 'it works'; exit 5")).Output()
+			So(string(output), ShouldEqual, "it works\n")
+			So(err, ShouldNotBeNil)
+			exitErr, ok := err.(*exec.ExitError)
+			So(ok, ShouldBeTrue)
+			So(exitErr.Sys().(syscall.WaitStatus).ExitStatus(), ShouldEqual, 5)
+		})
+
+		Convey("and SIGTERM should be forwarded to it", func() {
+			command := exec.Command("sh", "-c", wrapPodCommand("sleep 30"))
+			So(command.Start(), ShouldBeNil)
+			time.Sleep(500 * time.Millisecond)
+			So(command.Process.Signal(syscall.SIGTERM), ShouldBeNil)
+
+			err := command.Wait()
+			exitErr, ok := err.(*exec.ExitError)
+			So(ok, ShouldBeTrue)
+			So(exitErr.Sys().(syscall.WaitStatus).ExitStatus(), ShouldEqual, 128+int(syscall.SIGTERM))
+		})
+	})
+}
+
+func TestKubernetesPodSpec(t *testing.T) {
+	Convey("When pod template is provided", t, func() {
+		file, err := ioutil.TempFile("", "pod-template")
+		So(err, ShouldBeNil)
+		defer os.Remove(file.Name())
+		_, err = file.WriteString(podTemplate)
+		So(err, ShouldBeNil)
+		file.Close()
+
+		config := DefaultKubernetesConfig()
+		config.PodTemplateFile = file.Name()
+		config.CPULimit = 2000
+		config.Env = []v1.EnvVar{{Name: "MODEL", Value: "/models/caffe"}}
+		config.ServiceAccountName = "swan"
+		config.Labels = map[string]string{"swan-role": "be", "name": "ignored"}
+		config.ReadinessProbe = &v1.Probe{PeriodSeconds: 1}
+		podExecutor := &k8s{config, nil}
+
+		pod, err := podExecutor.newPod("caffe")
+		So(err, ShouldBeNil)
+
+		Convey("Template should be used as a base of the pod", func() {
+			So(pod.Labels["team"], ShouldEqual, "experiments")
+			So(pod.Labels["name"], ShouldEqual, pod.Name)
+			So(pod.Labels["swan-role"], ShouldEqual, "be")
+			So(pod.Spec.Tolerations, ShouldHaveLength, 1)
+			So(pod.Spec.Volumes, ShouldHaveLength, 1)
+			So(pod.Spec.Containers, ShouldHaveLength, 1)
+			So(pod.Spec.Containers[0].VolumeMounts[0].MountPath, ShouldEqual, "/models")
+		})
+
+		Convey("Configuration should take precedence over the template", func() {
+			container := pod.Spec.Containers[0]
+			So(container.Name, ShouldEqual, config.ContainerName)
+			So(container.Image, ShouldEqual, config.ContainerImage)
+			So(container.Command, ShouldResemble, []string{"sh", "-c", "caffe"})
+			So(container.Env, ShouldResemble, config.Env)
+			So(container.ReadinessProbe, ShouldEqual, config.ReadinessProbe)
+			So(pod.Spec.ServiceAccountName, ShouldEqual, "swan")
+			So(pod.Spec.RestartPolicy, ShouldEqual, v1.RestartPolicyNever)
+
+			cpu := container.Resources.Limits[v1.ResourceCPU]
+			memory := container.Resources.Limits[v1.ResourceMemory]
+			So(cpu.MilliValue(), ShouldEqual, 2000)
+			So(memory.String(), ShouldEqual, "1Gi")
+		})
+
+		Convey("RuntimeClass should be read from the template", func() {
+			runtimeClassName, err := podExecutor.runtimeClassName()
+			So(err, ShouldBeNil)
+			So(runtimeClassName, ShouldEqual, "kata")
+
+			manifest, err := manifestWithFields(pod, "v1", "Pod", map[string]interface{}{"spec.runtimeClassName": runtimeClassName})
+			So(err, ShouldBeNil)
+			So(string(manifest), ShouldContainSubstring, `"runtimeClassName":"kata"`)
+		})
+	})
+}
+
+func TestKubernetesExtendedResources(t *testing.T) {
+	Convey("When hugepages and extended resources are configured", t, func() {
+		config := DefaultKubernetesConfig()
+		config.Hugepages2MiRequest = 512 * 1024 * 1024
+		config.ExtendedResources = map[string]int64{"intel.com/rdt_class_hp": 1}
+		podExecutor := &k8s{config, nil}
+
+		resources := podExecutor.containerResources()
+
+		Convey("They should be requested and limited with the same quantity", func() {
+			for _, list := range []v1.ResourceList{resources.Requests, resources.Limits} {
+				hugepages := list[hugepages2MiResource]
+				rdtClass := list[v1.ResourceName("intel.com/rdt_class_hp")]
+				So(hugepages.String(), ShouldEqual, "512Mi")
+				So(rdtClass.Value(), ShouldEqual, 1)
+			}
+		})
+	})
+
+	Convey("When hugepages request is not a multiple of 2MiB", t, func() {
+		config := DefaultKubernetesConfig()
+		config.Hugepages2MiRequest = 3 * 1024 * 1024
+		podExecutor := &k8s{config, nil}
+
+		Convey("Pod should be rejected", func() {
+			_, err := podExecutor.newPod("memcached")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "not a multiple of 2MiB")
+		})
+	})
+}
+
+func TestKubernetesSidecars(t *testing.T) {
+	Convey("When pod has sidecar containers", t, func() {
+		config := DefaultKubernetesConfig()
+		config.Sidecars = []v1.Container{{Name: "exporter", Image: "exporter"}}
+		podExecutor := &k8s{config, nil}
+
+		pod, err := podExecutor.newPod("memcached")
+		So(err, ShouldBeNil)
+
+		Convey("Task container should be followed by sidecars", func() {
+			So(pod.Spec.Containers, ShouldHaveLength, 2)
+			So(pod.Spec.Containers[0].Name, ShouldEqual, config.ContainerName)
+			So(pod.Spec.Containers[1].Name, ShouldEqual, "exporter")
+		})
+
+		watcher := &k8sWatcher{containerName: config.ContainerName}
+		terminated := func(name string, exitCode int32) v1.ContainerStatus {
+			return v1.ContainerStatus{Name: name, State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: exitCode}}}
+		}
+		running := v1.ContainerStatus{Name: "exporter", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}
+
+		Convey("Task should be finished when its container terminates", func() {
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{terminated(config.ContainerName, 3), running}
+			So(watcher.taskFinished(pod), ShouldBeTrue)
+			So(watcher.exitCode(pod), ShouldEqual, 3)
+		})
+
+		Convey("Failure of sidecar should be reported when task succeeded", func() {
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{terminated(config.ContainerName, 0), terminated("exporter", 2)}
+			So(watcher.exitCode(pod), ShouldEqual, 2)
+		})
+	})
+}
+
 func v1ToAPI(v1Pod *v1.Pod) *api.Pod {
 	apiPod := &api.Pod{}
 	scheme := NewRuntimeScheme()
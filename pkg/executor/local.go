@@ -19,6 +19,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -56,7 +57,7 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 
 	cmd := exec.Command("sh", "-c", l.commandDecorators.Decorate(command))
 
-	// TODO: delete this as we use PID namespace instead
+	// Task is run in its own process group, so Stop can signal all its processes at once.
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	outputDirectory, err := createOutputDirectory(command, "local")
@@ -82,6 +83,7 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 	}
 
 	log.Debug("Local Executor: Started with pid ", cmd.Process.Pid)
+	recordArtifact(recordedArtifact{Kind: ProcessArtifact, Host: localHost, Name: l.commandDecorators.Decorate(command), PID: cmd.Process.Pid})
 
 	// hasProcessExited channel is closed when launched process exits.
 	hasProcessExited := make(chan struct{})
@@ -92,6 +94,7 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 		stdoutFilePath:   stdoutFile.Name(),
 		stderrFilePath:   stderrFile.Name(),
 		hasProcessExited: hasProcessExited,
+		stopPolicy:       DefaultStopPolicy(),
 	}
 
 	// Wait for local task in go routine.
@@ -133,6 +136,7 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 		return nil, err
 	}
 	log.Debugf("Local Executor: pid %d started successfully", cmd.Process.Pid)
+	RegisterTaskHandle(&taskHandle)
 	return &taskHandle, nil
 }
 
@@ -148,6 +152,11 @@ type localTaskHandle struct {
 
 	// Command requested by user. This is how this TaskHandle presents.
 	command string
+
+	stopPolicy StopPolicy
+	mutex      sync.Mutex
+	stopStage  StopStage
+	paused     bool
 }
 
 // isTerminated checks if channel processHasExited is closed. If it is closed, it means
@@ -168,32 +177,96 @@ func (taskHandle *localTaskHandle) getPid() int {
 	return taskHandle.cmdHandler.Process.Pid
 }
 
-// Stop terminates the local task.
+// Stop terminates the local task according to its StopPolicy: the whole process tree is killed with SIGKILL,
+// preceded by SIGTERM and grace period to exit when grace period is set.
+// Children which outlived the task (e.g. daemonized ones) are killed as well.
 func (taskHandle *localTaskHandle) Stop() error {
 	if taskHandle.isTerminated() {
 		return nil
 	}
 
-	// Sending SIGKILL signal to local task.
-	// TODO: Add PID namespace to handle orphan tasks properly.
-	log.Debug("Sending ", syscall.SIGKILL, " to PID ", -taskHandle.getPid())
-	err := syscall.Kill(-taskHandle.getPid(), syscall.SIGKILL)
+	pid := taskHandle.getPid()
+	if taskHandle.isPaused() {
+		// Frozen processes would not handle signals.
+		taskHandle.Resume()
+	}
+
+	tree := processTree(pid)
+	terminate := func() error {
+		log.Debug("Sending ", syscall.SIGTERM, " to PID ", -pid, " and its ", len(tree), " descendants")
+		return signalProcesses(pid, tree, syscall.SIGTERM)
+	}
+	kill := func() error {
+		tree = mergeProcesses(tree, processTree(pid))
+		log.Debug("Sending ", syscall.SIGKILL, " to PID ", -pid, " and its ", len(tree), " descendants")
+		return signalProcesses(pid, tree, syscall.SIGKILL)
+	}
+
+	stage, err := taskHandle.stopPolicy.Stop(taskHandle, terminate, kill)
 	if err != nil {
 		log.Errorf("Local Stop() of command %q has failed: %s", taskHandle.command, err.Error())
 		return errors.Wrapf(err, "Local Stop() of command %q has failed", taskHandle.command)
 	}
 
-	// Checking if kill was successful.
-	isTerminated, _ := taskHandle.Wait(killWaitTimeout)
-	if !isTerminated {
-		log.Errorf("Local Stop() of command %q has failed: timeout", taskHandle.command)
-		return errors.Errorf("Local Stop() of command %q has failed: timeout", taskHandle.command)
+	killed := killLeftovers(tree)
+	if killed > 0 {
+		log.Warnf("Local Stop() of command %q: killed %d leftover child processes", taskHandle.command, killed)
 	}
 
+	taskHandle.mutex.Lock()
+	taskHandle.stopStage = stage
+	taskHandle.mutex.Unlock()
+	log.Debugf("Local Stop() of command %q: terminated by %s", taskHandle.command, stage)
+
 	// No error, task terminated.
 	return nil
 }
 
+// StopStage implements StopStageReporter interface.
+func (taskHandle *localTaskHandle) StopStage() StopStage {
+	taskHandle.mutex.Lock()
+	defer taskHandle.mutex.Unlock()
+	return taskHandle.stopStage
+}
+
+func (taskHandle *localTaskHandle) isPaused() bool {
+	taskHandle.mutex.Lock()
+	defer taskHandle.mutex.Unlock()
+	return taskHandle.paused
+}
+
+func (taskHandle *localTaskHandle) setPaused(paused bool) {
+	taskHandle.mutex.Lock()
+	defer taskHandle.mutex.Unlock()
+	taskHandle.paused = paused
+}
+
+// Pause freezes the local task using freezer cgroup or SIGSTOP.
+func (taskHandle *localTaskHandle) Pause() error {
+	if taskHandle.isTerminated() {
+		return errors.Errorf("Local Pause() of command %q has failed: task is terminated", taskHandle.command)
+	}
+	err := freezeProcess(taskHandle.getPid(), true)
+	if err != nil {
+		return err
+	}
+	taskHandle.setPaused(true)
+	return nil
+}
+
+// Resume thaws the local task.
+func (taskHandle *localTaskHandle) Resume() error {
+	if taskHandle.isTerminated() {
+		return errors.Errorf("Local Resume() of command %q has failed: task is terminated", taskHandle.command)
+	}
+	err := freezeProcess(taskHandle.getPid(), false)
+	if err != nil {
+		return err
+	}
+	taskHandle.setPaused(false)
+	return nil
+}
+
 // Status returns a state of the task.
 func (taskHandle *localTaskHandle) Status() TaskState {
 	if !taskHandle.isTerminated() {
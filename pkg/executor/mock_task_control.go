@@ -22,6 +22,34 @@ func (_m *MockTaskControl) EraseOutput() error {
 	return r0
 }
 
+// Pause provides a mock function with given fields:
+func (_m *MockTaskControl) Pause() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Resume provides a mock function with given fields:
+func (_m *MockTaskControl) Resume() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Stop provides a mock function with given fields:
 func (_m *MockTaskControl) Stop() error {
 	ret := _m.Called()
@@ -58,6 +58,34 @@ func (_m *MockTaskHandle) ExitCode() (int, error) {
 	return r0, r1
 }
 
+// Pause provides a mock function with given fields:
+func (_m *MockTaskHandle) Pause() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Resume provides a mock function with given fields:
+func (_m *MockTaskHandle) Resume() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Status provides a mock function with given fields:
 func (_m *MockTaskHandle) Status() TaskState {
 	ret := _m.Called()
@@ -46,6 +46,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -80,9 +81,11 @@ func DefaultOpenstackConfig(auth gophercloud.AuthOptions) OpenstackConfig {
 			RAM:   flavorRAMFlag.Value(),
 			VCPUs: flavorVCPUsFlag.Value(),
 		},
-		Image:      imageFlag.Value(),
-		User:       userFlag.Value(),
-		SSHKeyPath: sshKeyPathFlag.Value(),
+		Image:           imageFlag.Value(),
+		User:            userFlag.Value(),
+		SSHKeyPath:      sshKeyPathFlag.Value(),
+		HostAggregateID: hostAggregateIDFlag.Value(),
+		BootUpTimeout:   bootUpTimeOut.Value(),
 	}
 }
 
@@ -148,17 +151,23 @@ type OpenstackConfig struct {
 	ID            string
 	Hypervisor    Hypervisor
 	HostAggregate HostAggregate
+	// HostAggregateID is ID of host aggregate instances are placed in.
+	HostAggregateID int
+	// BootUpTimeout is time given to instance to boot up after it becomes active.
+	BootUpTimeout time.Duration
 }
 
 // Openstack defines OpenStack server configuration and client.
 type Openstack struct {
 	config *OpenstackConfig
 	client *gophercloud.ServiceClient
+	// newRemote creates executor running commands on booted instance (NewRemote by default).
+	newRemote func(address string, config RemoteConfig) (Executor, error)
 }
 
 // NewOpenstack creates OpenStack executor.
 func NewOpenstack(config *OpenstackConfig) Executor {
-	return Openstack{config: config}
+	return Openstack{config: config, newRemote: NewRemote}
 }
 
 // String returns user-friendly name of executor.
@@ -166,135 +175,55 @@ func (stack Openstack) String() string {
 	return fmt.Sprintf("%s at %s", executorName, stack.config.Auth.IdentityEndpoint)
 }
 
-// Execute runs provided command on OpenStack cluster.
-func (stack Openstack) Execute(command string) (TaskHandle, error) {
-	provider, err := openstack.AuthenticatedClient(stack.config.Auth)
-	if err != nil {
-		err = errors.Wrapf(err, "%s Couldn't get provider", executorLogPrefix)
-		log.Error(err.Error())
-		return nil, err
-	}
-
-	stack.client, err = openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: "RegionOne"})
-	if err != nil {
-		err = errors.Wrapf(err, "%s Couldn't get compute client", executorLogPrefix)
-		log.Error(err.Error())
-		return nil, err
-	}
+// openstackInstance describes instance booted by the executor and reachable through floating IP.
+type openstackInstance struct {
+	id            string
+	name          string
+	floatingIP    string
+	hypervisor    Hypervisor
+	hostAggregate HostAggregate
+}
 
-	flavorID, err := stack.ensureFlavor()
-	if err != nil {
-		log.Error(err.Error())
-		return nil, err
-	}
+// describe fills config with details of the instance, so that they can be used e.g. as experiment tags.
+func (instance *openstackInstance) describe(config *OpenstackConfig) {
+	config.Name = instance.name
+	config.ID = instance.id
+	config.Hypervisor = instance.hypervisor
+	config.HostAggregate = instance.hostAggregate
+}
 
-	if err = stack.ensureKeypair(); err != nil {
-		log.Error(err.Error())
-		return nil, err
-	}
+// bootSpec holds resources shared by all the instances booted by the executor.
+type bootSpec struct {
+	flavorID      string
+	imageID       string
+	hostAggregate HostAggregate
+}
 
-	floatingIP, err := stack.findFloatingIP()
+// Execute runs provided command on OpenStack cluster.
+func (stack Openstack) Execute(command string) (TaskHandle, error) {
+	var err error
+	stack.client, err = stack.connect()
 	if err != nil {
 		log.Error(err.Error())
 		return nil, err
 	}
 
-	image, err := images.IDFromName(stack.client, stack.config.Image)
+	spec, err := stack.prepareBoot()
 	if err != nil {
-		err = errors.Wrapf(err, "%s Couldn't get image id from name: %s !", executorLogPrefix, image)
 		log.Error(err.Error())
 		return nil, err
 	}
 
-	instanceName := fmt.Sprintf("krico.%s", uuid.New())
-
-	stack.config.Name = instanceName
-
-	aggregate, err := aggregates.Get(stack.client, hostAggregateIDFlag.Value()).Extract()
+	instance, err := stack.bootInstance(spec)
 	if err != nil {
-		err = errors.Wrapf(err, "%s Couldn't get host aggregate info", executorLogPrefix)
 		log.Error(err.Error())
 		return nil, err
 	}
+	instance.describe(stack.config)
 
-	stack.config.HostAggregate.Name = aggregate.Name
-	stack.config.HostAggregate.AvailabilityZone = aggregate.AvailabilityZone
-	stack.config.HostAggregate.ConfigurationID = aggregate.Metadata["configuration_id"]
-	stack.config.HostAggregate.Disk.Iops = aggregate.Metadata["disk_iops"]
-	stack.config.HostAggregate.Disk.Size = aggregate.Metadata["disk_size"]
-	stack.config.HostAggregate.CPU.Performance = aggregate.Metadata["cpu_performance"]
-	stack.config.HostAggregate.CPU.Threads = aggregate.Metadata["cpu_threads"]
-	stack.config.HostAggregate.RAM.Size = aggregate.Metadata["ram_size"]
-	stack.config.HostAggregate.RAM.Bandwidth = aggregate.Metadata["ram_bandwidth"]
-
-	serverOpts := servers.CreateOpts{
-		Name:             instanceName,
-		FlavorRef:        flavorID,
-		ImageRef:         image,
-		AvailabilityZone: stack.config.HostAggregate.AvailabilityZone,
-	}
-
-	serverOptsExt := keypairs.CreateOptsExt{
-		CreateOptsBuilder: serverOpts,
-		KeyName:           keypairName.Value(),
-	}
-
-	instance, err := servers.Create(stack.client, serverOptsExt).Extract()
+	remote, err := stack.newRemote(instance.floatingIP, stack.remoteConfig())
 	if err != nil {
-		err = errors.Wrapf(err, "%s Unable to create instance", executorLogPrefix)
-		log.Error(err.Error())
-		return nil, err
-	}
-
-	log.Infof("%s Scheduled instance %s creation", executorLogPrefix, instance.ID)
-
-	stack.config.ID = instance.ID
-
-	if err = servers.WaitForStatus(stack.client, instance.ID, "ACTIVE", 60); err != nil {
-		err = errors.Wrapf(err, "%s Couldn't launch instance", executorLogPrefix)
-		log.Error(err.Error())
-		return nil, err
-	}
-
-	log.Infof("%s Launched instance %s", executorLogPrefix, instance.ID)
-
-	associateOpts := floatingips.AssociateOpts{
-		FloatingIP: floatingIP,
-	}
-
-	if err = floatingips.AssociateInstance(stack.client, instance.ID, associateOpts).ExtractErr(); err != nil {
-		err = errors.Wrapf(err, "%s Couldn't associate %q floating ip to instance %s", executorLogPrefix, floatingIP, instanceName)
-		log.Error(err.Error())
-		return nil, err
-	}
-
-	log.Infof("%s Associated %q floating ip", executorLogPrefix, floatingIP)
-
-	remoteConfig := RemoteConfig{
-		User:    stack.config.User,
-		KeyPath: stack.config.SSHKeyPath,
-		Port:    22,
-	}
-
-	// Wait while to ensure that everything booted up
-	log.Infof("%s Waiting for %s instance to boot up", executorLogPrefix, instanceName)
-	time.Sleep(bootUpTimeOut.Value())
-
-	stack.config.Hypervisor.InstanceName, err = stack.obtainHypervisorInstanceName(instance.ID)
-	if err != nil {
-		log.Errorf("%s Couldn't obtain hypervisor instance name for %s instance!", executorLogPrefix, instanceName)
-		return nil, err
-	}
-
-	stack.config.Hypervisor.Address, err = stack.obtainHypervisorAddress(instance.ID)
-	if err != nil {
-		log.Errorf("%s Couldn't obtain hypervisor address for %s instance!", executorLogPrefix, instanceName)
-		return nil, err
-	}
-
-	remote, err := NewRemote(floatingIP, remoteConfig)
-	if err != nil {
-		log.Errorf("%s Couldn't create remote executor for %s instance with %s ip", executorLogPrefix, instanceName, floatingIP)
+		log.Errorf("%s Couldn't create remote executor for %s instance with %s ip", executorLogPrefix, instance.name, instance.floatingIP)
 		return nil, err
 	}
 
@@ -302,13 +231,13 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 
 	remoteHandler, err := remote.Execute(command)
 	if err != nil {
-		log.Errorf("%s Couldn't execute %q on %s (%s)", executorLogPrefix, command, instanceName, floatingIP)
+		log.Errorf("%s Couldn't execute %q on %s (%s)", executorLogPrefix, command, instance.name, instance.floatingIP)
 		return nil, err
 	}
 
 	exitCode, _ := remoteHandler.ExitCode()
 
-	log.Infof("%s Executed %q on %s (%s) with exit code: %d", executorLogPrefix, command, instanceName, floatingIP, exitCode)
+	log.Infof("%s Executed %q on %s (%s) with exit code: %d", executorLogPrefix, command, instance.name, instance.floatingIP, exitCode)
 
 	outputDirectory, err := createOutputDirectory(command, directoryPrefix)
 	if err != nil {
@@ -330,10 +259,10 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 
 	taskHandle := &OpenstackTaskHandle{
 		command:        command,
-		hostIP:         floatingIP,
+		hostIP:         instance.floatingIP,
 		stdoutFilePath: stdoutFileName,
 		stderrFilePath: stderrFileName,
-		instance:       instance.ID,
+		instance:       instance.id,
 		running:        true,
 		exitCode:       exitCode,
 		requestStop:    make(chan struct{}),
@@ -343,7 +272,7 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 	}
 
 	taskWatcher := &openstackWatcher{
-		instance:      instance.ID,
+		instance:      instance.id,
 		client:        stack.client,
 		running:       &taskHandle.running,
 		requestStop:   taskHandle.requestStop,
@@ -358,9 +287,150 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 
 	err = taskWatcher.watch()
 
+	RegisterTaskHandle(taskHandle)
 	return taskHandle, nil
 }
 
+// connect authenticates in Keystone and returns Nova client.
+func (stack Openstack) connect() (*gophercloud.ServiceClient, error) {
+	provider, err := openstack.AuthenticatedClient(stack.config.Auth)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s Couldn't get provider", executorLogPrefix)
+	}
+
+	client, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: "RegionOne"})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s Couldn't get compute client", executorLogPrefix)
+	}
+	return client, nil
+}
+
+// prepareBoot makes sure that flavor and keypair exist and finds image and host aggregate of instances.
+func (stack Openstack) prepareBoot() (spec bootSpec, err error) {
+	spec.flavorID, err = stack.ensureFlavor()
+	if err != nil {
+		return spec, err
+	}
+
+	if err = stack.ensureKeypair(); err != nil {
+		return spec, err
+	}
+
+	spec.imageID, err = images.IDFromName(stack.client, stack.config.Image)
+	if err != nil {
+		return spec, errors.Wrapf(err, "%s Couldn't get image id from name: %s !", executorLogPrefix, stack.config.Image)
+	}
+
+	aggregate, err := aggregates.Get(stack.client, stack.config.HostAggregateID).Extract()
+	if err != nil {
+		return spec, errors.Wrapf(err, "%s Couldn't get host aggregate info", executorLogPrefix)
+	}
+
+	spec.hostAggregate.Name = aggregate.Name
+	spec.hostAggregate.AvailabilityZone = aggregate.AvailabilityZone
+	spec.hostAggregate.ConfigurationID = aggregate.Metadata["configuration_id"]
+	spec.hostAggregate.Disk.Iops = aggregate.Metadata["disk_iops"]
+	spec.hostAggregate.Disk.Size = aggregate.Metadata["disk_size"]
+	spec.hostAggregate.CPU.Performance = aggregate.Metadata["cpu_performance"]
+	spec.hostAggregate.CPU.Threads = aggregate.Metadata["cpu_threads"]
+	spec.hostAggregate.RAM.Size = aggregate.Metadata["ram_size"]
+	spec.hostAggregate.RAM.Bandwidth = aggregate.Metadata["ram_bandwidth"]
+	return spec, nil
+}
+
+// bootInstance creates instance in host aggregate, associates free floating IP with it and waits for it to boot up.
+// Instance is deleted when it cannot be made reachable.
+func (stack Openstack) bootInstance(spec bootSpec) (*openstackInstance, error) {
+	floatingIP, err := stack.findFloatingIP()
+	if err != nil {
+		return nil, err
+	}
+
+	instanceName := fmt.Sprintf("krico.%s", uuid.New())
+
+	serverOpts := servers.CreateOpts{
+		Name:             instanceName,
+		FlavorRef:        spec.flavorID,
+		ImageRef:         spec.imageID,
+		AvailabilityZone: spec.hostAggregate.AvailabilityZone,
+	}
+
+	serverOptsExt := keypairs.CreateOptsExt{
+		CreateOptsBuilder: serverOpts,
+		KeyName:           keypairName.Value(),
+	}
+
+	server, err := servers.Create(stack.client, serverOptsExt).Extract()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s Unable to create instance", executorLogPrefix)
+	}
+
+	log.Infof("%s Scheduled instance %s creation", executorLogPrefix, server.ID)
+
+	instance := &openstackInstance{
+		id:            server.ID,
+		name:          instanceName,
+		floatingIP:    floatingIP,
+		hostAggregate: spec.hostAggregate,
+	}
+	if err = stack.launchInstance(instance); err != nil {
+		if deleteErr := stack.deleteInstance(instance.id); deleteErr != nil {
+			log.Errorf("%s Couldn't delete instance %s which failed to launch: %s", executorLogPrefix, instance.id, deleteErr.Error())
+		}
+		return nil, err
+	}
+	return instance, nil
+}
+
+func (stack Openstack) launchInstance(instance *openstackInstance) (err error) {
+	if err = servers.WaitForStatus(stack.client, instance.id, "ACTIVE", 60); err != nil {
+		return errors.Wrapf(err, "%s Couldn't launch instance", executorLogPrefix)
+	}
+
+	log.Infof("%s Launched instance %s", executorLogPrefix, instance.id)
+
+	associateOpts := floatingips.AssociateOpts{
+		FloatingIP: instance.floatingIP,
+	}
+
+	if err = floatingips.AssociateInstance(stack.client, instance.id, associateOpts).ExtractErr(); err != nil {
+		return errors.Wrapf(err, "%s Couldn't associate %q floating ip to instance %s", executorLogPrefix, instance.floatingIP, instance.name)
+	}
+
+	log.Infof("%s Associated %q floating ip", executorLogPrefix, instance.floatingIP)
+
+	// Wait while to ensure that everything booted up
+	log.Infof("%s Waiting for %s instance to boot up", executorLogPrefix, instance.name)
+	time.Sleep(stack.config.BootUpTimeout)
+
+	instance.hypervisor.InstanceName, err = stack.obtainHypervisorInstanceName(instance.id)
+	if err != nil {
+		return errors.Wrapf(err, "%s Couldn't obtain hypervisor instance name for %s instance!", executorLogPrefix, instance.name)
+	}
+
+	instance.hypervisor.Address, err = stack.obtainHypervisorAddress(instance.id)
+	if err != nil {
+		return errors.Wrapf(err, "%s Couldn't obtain hypervisor address for %s instance!", executorLogPrefix, instance.name)
+	}
+	return nil
+}
+
+func (stack Openstack) deleteInstance(instanceID string) error {
+	if err := servers.Delete(stack.client, instanceID).ExtractErr(); err != nil {
+		return errors.Wrapf(err, "%s Couldn't delete instance %s", executorLogPrefix, instanceID)
+	}
+	log.Infof("%s Deleted instance %s", executorLogPrefix, instanceID)
+	return nil
+}
+
+func (stack Openstack) remoteConfig() RemoteConfig {
+	return RemoteConfig{
+		User:    stack.config.User,
+		KeyPath: stack.config.SSHKeyPath,
+		Port:    22,
+	}
+}
+
 func (stack Openstack) ensureFlavor() (string, error) {
 	stack.config.Flavor.Name = fmt.Sprintf("krico.cpu-%d.ram-%d.disk-%d", stack.config.Flavor.VCPUs, stack.config.Flavor.RAM, stack.config.Flavor.Disk)
 
@@ -603,21 +673,20 @@ func (th *OpenstackTaskHandle) StdoutFile() (*os.File, error) {
 	return openFile(th.stdoutFilePath)
 }
 
-// Stop stops task.
+// Stop stops task, shuts off the instance and deletes it.
+// Instance which is already shut off or in error state is only deleted.
 func (th *OpenstackTaskHandle) Stop() error {
-	if !th.isRunning() {
-		return nil
-	}
-
-	log.Debugf("%s delete instance %q", taskHandleLogPrefix, th.instance)
+	if th.isRunning() {
+		log.Debugf("%s stop instance %q", taskHandleLogPrefix, th.instance)
 
-	th.requestStop <- struct{}{}
+		th.requestStop <- struct{}{}
 
-	log.Debugf("%s waiting for instance %q to stop", taskHandleLogPrefix, th.instance)
+		log.Debugf("%s waiting for instance %q to stop", taskHandleLogPrefix, th.instance)
 
-	<-th.stopped
+		<-th.stopped
 
-	log.Debugf("%s instance %q stop", taskHandleLogPrefix, th.instance)
+		log.Debugf("%s instance %q stop", taskHandleLogPrefix, th.instance)
+	}
 
 	th.requestDelete <- struct{}{}
 
@@ -628,6 +697,16 @@ func (th *OpenstackTaskHandle) Stop() error {
 	return nil
 }
 
+// Pause is not supported for OpenStack instances.
+func (th *OpenstackTaskHandle) Pause() error {
+	return errors.Errorf("Openstack instance %q cannot be paused", th.instance)
+}
+
+// Resume is not supported for OpenStack instances.
+func (th *OpenstackTaskHandle) Resume() error {
+	return errors.Errorf("Openstack instance %q cannot be resumed", th.instance)
+}
+
 // Wait blocks and waits for task to terminate.
 // For '0' it'll wait until task termination.
 func (th *OpenstackTaskHandle) Wait(timeout time.Duration) (bool, error) {
@@ -663,13 +742,28 @@ type openstackWatcher struct {
 	requestDelete chan struct{}
 	stopped       chan struct{}
 	deleted       chan struct{}
+
+	stopOnce, deleteOnce sync.Once
 }
 
+// watch polls status of the instance until it is deleted and serves stop and delete requests of task handle.
+// Channels stopped and deleted are closed when instance is shut off and deleted respectively.
 func (watcher *openstackWatcher) watch() error {
 
 	go func() {
 		for {
-			server, _ := servers.Get(watcher.client, watcher.instance).Extract()
+			server, err := servers.Get(watcher.client, watcher.instance).Extract()
+			if err != nil {
+				if _, notFound := err.(gophercloud.ErrDefault404); notFound {
+					log.Debugf("%s instance %q does not exist anymore", taskHandleLogPrefix, watcher.instance)
+					*watcher.running = false
+					watcher.markStopped()
+					return
+				}
+				log.Warnf("%s couldn't get status of instance %q: %s", taskHandleLogPrefix, watcher.instance, err.Error())
+				time.Sleep(watcherIntervalFlag.Value())
+				continue
+			}
 			switch server.Status {
 			case "ACTIVE":
 				*watcher.running = true
@@ -682,10 +776,9 @@ func (watcher *openstackWatcher) watch() error {
 				watcher.requestDelete <- struct{}{}
 			case "SHUTOFF":
 				*watcher.running = false
-				watcher.stopped <- struct{}{}
+				watcher.markStopped()
 			case "PAUSED":
 				*watcher.running = false
-				*watcher.running = false
 			case "RESCUED":
 				*watcher.running = true
 			case "STOPPED":
@@ -708,18 +801,30 @@ func (watcher *openstackWatcher) watch() error {
 				if watcher.cmdHandler != nil {
 					err := watcher.cmdHandler.Stop()
 					if err != nil {
-						print(err.Error())
-						return
+						log.Errorf("%s couldn't stop task on instance %q: %s", taskHandleLogPrefix, watcher.instance, err.Error())
+						watcher.markStopped()
+						continue
 					}
 				}
 
-				startstop.Stop(watcher.client, watcher.instance).ExtractErr()
+				if err := startstop.Stop(watcher.client, watcher.instance).ExtractErr(); err != nil {
+					log.Errorf("%s couldn't stop instance %q: %s", taskHandleLogPrefix, watcher.instance, err.Error())
+					watcher.markStopped()
+				}
 			case <-watcher.requestDelete:
-				servers.Delete(watcher.client, watcher.instance)
-				watcher.deleted <- struct{}{}
+				if err := servers.Delete(watcher.client, watcher.instance).ExtractErr(); err != nil {
+					if _, notFound := err.(gophercloud.ErrDefault404); !notFound {
+						log.Errorf("%s couldn't delete instance %q: %s", taskHandleLogPrefix, watcher.instance, err.Error())
+					}
+				}
+				watcher.deleteOnce.Do(func() { close(watcher.deleted) })
 			}
 		}
 	}()
 
 	return nil
 }
+
+func (watcher *openstackWatcher) markStopped() {
+	watcher.stopOnce.Do(func() { close(watcher.stopped) })
+}
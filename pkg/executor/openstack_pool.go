@@ -0,0 +1,338 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// OpenstackPoolSizeFlag is a number of instances booted up front and reused across tasks.
+	OpenstackPoolSizeFlag    = conf.NewIntFlag("os_vm_pool_size", "Number of Openstack instances booted up front and reused across tasks. Instance is booted for every task when 0.", 0)
+	vmPoolResetCommandFlag   = conf.NewStringFlag("os_vm_pool_reset_command", "Command run on pooled Openstack instance after task terminates or is stopped, before instance is reused. Instance is deleted when it fails.", "sync && sudo sh -c 'echo 3 > /proc/sys/vm/drop_caches'")
+	vmPoolAcquireTimeoutFlag = conf.NewDurationFlag("os_vm_pool_acquire_timeout", "Maximum time to wait for free instance of Openstack VM pool. Wait forever when 0.", 10*time.Minute)
+)
+
+// OpenstackPoolConfig defines size of the pool and how its instances are reused.
+type OpenstackPoolConfig struct {
+	Size int
+	// ResetCommand is run on the instance (over SSH) when task terminates or is stopped. Instance is not reset when empty.
+	ResetCommand string
+	// AcquireTimeout limits time Execute waits for free instance. It waits forever when 0.
+	AcquireTimeout time.Duration
+}
+
+// DefaultOpenstackPoolConfig creates default OpenStack pool config.
+func DefaultOpenstackPoolConfig() OpenstackPoolConfig {
+	return OpenstackPoolConfig{
+		Size:           OpenstackPoolSizeFlag.Value(),
+		ResetCommand:   vmPoolResetCommandFlag.Value(),
+		AcquireTimeout: vmPoolAcquireTimeoutFlag.Value(),
+	}
+}
+
+// OpenstackPool boots instances of the same flavor and image up front and hands them out to Execute.
+// Commands are run on the instances over SSH. Instance is reset and returned to the pool when the task terminates
+// or is stopped, so booting (flavor, floating IP, boot up timeout) is paid once per instance instead of once per task.
+// Instances are deleted by Delete, which is also registered as a cleanup of the experiment.
+type OpenstackPool struct {
+	stack  Openstack
+	config OpenstackPoolConfig
+
+	mutex     sync.Mutex
+	instances []*openstackInstance
+	free      chan *openstackInstance
+	deleted   bool
+}
+
+// NewOpenstackPool boots instances described by config. Already booted instances are deleted when any of them fails to boot.
+func NewOpenstackPool(config *OpenstackConfig, poolConfig OpenstackPoolConfig) (*OpenstackPool, error) {
+	return newOpenstackPool(Openstack{config: config, newRemote: NewRemote}, poolConfig)
+}
+
+func newOpenstackPool(stack Openstack, poolConfig OpenstackPoolConfig) (*OpenstackPool, error) {
+	if poolConfig.Size < 1 {
+		return nil, errors.Errorf("%s pool size must be positive, got %d", executorLogPrefix, poolConfig.Size)
+	}
+
+	var err error
+	stack.client, err = stack.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := stack.prepareBoot()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &OpenstackPool{
+		stack:  stack,
+		config: poolConfig,
+		free:   make(chan *openstackInstance, poolConfig.Size),
+	}
+	for i := 0; i < poolConfig.Size; i++ {
+		instance, err := stack.bootInstance(spec)
+		if err != nil {
+			if deleteErr := pool.Delete(); deleteErr != nil {
+				log.Errorf("%s Couldn't delete instances of pool which failed to boot: %s", executorLogPrefix, deleteErr.Error())
+			}
+			return nil, errors.Wrapf(err, "%s Couldn't boot instance %d of %d", executorLogPrefix, i+1, poolConfig.Size)
+		}
+		pool.instances = append(pool.instances, instance)
+		pool.free <- instance
+	}
+	log.Infof("%s Booted pool of %d instances of %q image", executorLogPrefix, poolConfig.Size, stack.config.Image)
+
+	RegisterCleanup(pool.String(), pool.Delete)
+	return pool, nil
+}
+
+// String returns user-friendly name of the pool.
+func (pool *OpenstackPool) String() string {
+	return fmt.Sprintf("%s pool of %d %q instances at %s", executorName, pool.config.Size, pool.stack.config.Image, pool.stack.config.Auth.IdentityEndpoint)
+}
+
+// Execute runs command on free instance of the pool. See Executor.
+func (pool *OpenstackPool) Execute(command string) (TaskHandle, error) {
+	return pool.Executor(pool.stack.config).Execute(command)
+}
+
+// Executor returns executor running commands on instances of the pool.
+// Details of instance running the task (name, ID, hypervisor, host aggregate) are written to config,
+// as Openstack executor does.
+func (pool *OpenstackPool) Executor(config *OpenstackConfig) Executor {
+	return openstackPoolExecutor{pool: pool, config: config}
+}
+
+// Size returns number of instances in the pool (instances failing to reset are removed).
+func (pool *OpenstackPool) Size() int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return len(pool.instances)
+}
+
+// Delete deletes all the instances of the pool. Tasks still running on them are terminated.
+func (pool *OpenstackPool) Delete() error {
+	pool.mutex.Lock()
+	if pool.deleted {
+		pool.mutex.Unlock()
+		return nil
+	}
+	pool.deleted = true
+	close(pool.free)
+	instances := pool.instances
+	pool.instances = nil
+	pool.mutex.Unlock()
+
+	var errCollection errcollection.ErrorCollection
+	for _, instance := range instances {
+		errCollection.Add(pool.stack.deleteInstance(instance.id))
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// acquire waits for free instance.
+func (pool *OpenstackPool) acquire() (*openstackInstance, error) {
+	select {
+	case instance, ok := <-pool.free:
+		if !ok {
+			return nil, errors.Errorf("%s pool has been deleted", executorLogPrefix)
+		}
+		return instance, nil
+	case <-getTimeoutChan(pool.config.AcquireTimeout):
+		return nil, errors.Errorf("%s no free instance in pool within %s", executorLogPrefix, pool.config.AcquireTimeout)
+	}
+}
+
+// release resets the instance and returns it to the pool. Instance which cannot be reset is deleted.
+func (pool *OpenstackPool) release(instance *openstackInstance, reset bool) error {
+	if pool.isDeleted() {
+		return nil
+	}
+	if reset && pool.config.ResetCommand != "" {
+		if err := pool.reset(instance); err != nil {
+			pool.remove(instance)
+			if deleteErr := pool.stack.deleteInstance(instance.id); deleteErr != nil {
+				log.Errorf("%s Couldn't delete instance %s which failed to reset: %s", executorLogPrefix, instance.id, deleteErr.Error())
+			}
+			return err
+		}
+	}
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if !pool.deleted {
+		pool.free <- instance
+	}
+	return nil
+}
+
+// reset runs reset command on the instance and waits for it to finish.
+func (pool *OpenstackPool) reset(instance *openstackInstance) error {
+	remote, err := pool.stack.newRemote(instance.floatingIP, pool.stack.remoteConfig())
+	if err != nil {
+		return errors.Wrapf(err, "%s Couldn't create remote executor for %s instance", executorLogPrefix, instance.name)
+	}
+	handle, err := remote.Execute(pool.config.ResetCommand)
+	if err != nil {
+		return errors.Wrapf(err, "%s Couldn't reset %s instance", executorLogPrefix, instance.name)
+	}
+	defer handle.EraseOutput()
+
+	if _, err = handle.Wait(0); err != nil {
+		return errors.Wrapf(err, "%s Couldn't reset %s instance", executorLogPrefix, instance.name)
+	}
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return errors.Wrapf(err, "%s Couldn't reset %s instance", executorLogPrefix, instance.name)
+	}
+	if exitCode != 0 {
+		return errors.Errorf("%s reset of %s instance with %q failed with exit code %d", executorLogPrefix, instance.name, pool.config.ResetCommand, exitCode)
+	}
+	return nil
+}
+
+func (pool *OpenstackPool) isDeleted() bool {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.deleted
+}
+
+func (pool *OpenstackPool) remove(instance *openstackInstance) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	for i := range pool.instances {
+		if pool.instances[i] == instance {
+			pool.instances = append(pool.instances[:i], pool.instances[i+1:]...)
+			return
+		}
+	}
+}
+
+// openstackPoolExecutor runs commands on instances of the pool.
+type openstackPoolExecutor struct {
+	pool   *OpenstackPool
+	config *OpenstackConfig
+}
+
+// String returns user-friendly name of executor.
+func (e openstackPoolExecutor) String() string {
+	return e.pool.String()
+}
+
+// Execute runs command on free instance of the pool. Instance is returned to the pool when the task terminates or is stopped.
+func (e openstackPoolExecutor) Execute(command string) (TaskHandle, error) {
+	instance, err := e.pool.acquire()
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	remote, err := e.pool.stack.newRemote(instance.floatingIP, e.pool.stack.remoteConfig())
+	if err != nil {
+		e.pool.release(instance, false)
+		log.Errorf("%s Couldn't create remote executor for %s instance with %s ip", executorLogPrefix, instance.name, instance.floatingIP)
+		return nil, err
+	}
+
+	handle, err := remote.Execute(command)
+	if err != nil {
+		e.pool.release(instance, false)
+		log.Errorf("%s Couldn't execute %q on %s (%s)", executorLogPrefix, command, instance.name, instance.floatingIP)
+		return nil, err
+	}
+	instance.describe(e.config)
+
+	log.Infof("%s Executed %q on pooled instance %s (%s)", executorLogPrefix, command, instance.name, instance.floatingIP)
+	taskHandle := &openstackPoolTaskHandle{TaskHandle: handle, pool: e.pool, instance: instance}
+	go taskHandle.releaseWhenTerminated()
+	return taskHandle, nil
+}
+
+// openstackPoolTaskHandle is a handle of remote task run on pooled instance.
+// Instance is released back to the pool when the task terminates or is stopped.
+type openstackPoolTaskHandle struct {
+	TaskHandle
+	pool        *OpenstackPool
+	instance    *openstackInstance
+	releaseOnce sync.Once
+	releaseErr  error
+}
+
+// releaseWhenTerminated releases the instance as soon as the task terminates, so tasks which are not
+// stopped (e.g. finished jobs) do not hold the instance until experiment ends.
+func (th *openstackPoolTaskHandle) releaseWhenTerminated() {
+	if _, err := th.TaskHandle.Wait(0); err != nil {
+		log.Errorf("%s Couldn't wait for task on %s instance: %s", executorLogPrefix, th.instance.name, err.Error())
+		return
+	}
+	th.release()
+}
+
+// release resets the instance and returns it to the pool. It is done once, error is returned by every call.
+func (th *openstackPoolTaskHandle) release() error {
+	th.releaseOnce.Do(func() {
+		th.releaseErr = th.pool.release(th.instance, true)
+		if th.releaseErr != nil {
+			log.Errorf("%s Couldn't release %s instance: %s", executorLogPrefix, th.instance.name, th.releaseErr.Error())
+		}
+	})
+	return th.releaseErr
+}
+
+// Stop stops the task, resets the instance and returns it to the pool.
+func (th *openstackPoolTaskHandle) Stop() error {
+	var errCollection errcollection.ErrorCollection
+	errCollection.Add(th.TaskHandle.Stop())
+	errCollection.Add(th.release())
+	return errCollection.GetErrIfAny()
+}
+
+// Wait waits for the task. Instance is returned to the pool before Wait returns when the task has terminated.
+func (th *openstackPoolTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	terminated, err := th.TaskHandle.Wait(timeout)
+	if err == nil && terminated {
+		th.release()
+	}
+	return terminated, err
+}
+
+// Unwrap implements WrappedTask interface.
+func (th *openstackPoolTaskHandle) Unwrap() TaskHandle {
+	return th.TaskHandle
+}
+
+// Address returns floating IP of the instance.
+func (th *openstackPoolTaskHandle) Address() string {
+	return th.instance.floatingIP
+}
+
+// Instance returns OpenStack instance ID.
+func (th *openstackPoolTaskHandle) Instance() string {
+	return th.instance.id
+}
+
+// String returns user-friendly name of task handle.
+func (th *openstackPoolTaskHandle) String() string {
+	return fmt.Sprintf("Openstack pooled instance %q running with command %s", th.instance.id, th.TaskHandle)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/openstackfake"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOpenstackPool(t *testing.T) {
+	Convey("When pool of Openstack instances is booted", t, func() {
+		cloud := openstackfake.NewCloud()
+		defer cloud.Close()
+		cloud.AddFloatingIP("172.24.4.1")
+		cloud.AddFloatingIP("172.24.4.2")
+		cloud.AddFloatingIP("172.24.4.3")
+
+		keyDirectory, err := ioutil.TempDir("", "openstack-pool")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(keyDirectory)
+		stack := newFakeOpenstack(cloud, keyDirectory)
+
+		poolConfig := OpenstackPoolConfig{Size: 2, ResetCommand: "true", AcquireTimeout: 100 * time.Millisecond}
+		pool, err := newOpenstackPool(stack, poolConfig)
+		So(err, ShouldBeNil)
+		defer pool.Delete()
+
+		Convey("Instances should be booted up front with floating IPs", func() {
+			servers := cloud.Servers()
+			So(servers, ShouldHaveLength, 2)
+			So(servers[0].Status, ShouldEqual, "ACTIVE")
+			So(servers[0].AvailabilityZone, ShouldEqual, openstackfake.DefaultAvailabilityZone)
+			So(servers[0].FloatingIP, ShouldNotEqual, servers[1].FloatingIP)
+			So(cloud.Flavors(), ShouldHaveLength, 1)
+			So(cloud.Keypairs(), ShouldHaveLength, 1)
+		})
+
+		Convey("Task should run on pooled instance", func() {
+			handle, err := pool.Execute("echo pooled")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+			So(handle.Address(), ShouldStartWith, "172.24.4.")
+			So(stack.config.ID, ShouldEqual, handle.(*openstackPoolTaskHandle).Instance())
+			So(stack.config.Hypervisor.Address, ShouldEqual, openstackfake.DefaultHypervisorAddress)
+
+			Convey("And instance should be released when the task terminates", func() {
+				second, err := pool.Execute("sleep 10")
+				So(err, ShouldBeNil)
+				defer second.EraseOutput()
+				third, err := pool.Execute("sleep 10")
+				So(err, ShouldBeNil)
+				defer third.EraseOutput()
+				defer third.Stop()
+				So([]string{second.Address(), third.Address()}, ShouldContain, handle.Address())
+
+				_, err = pool.Execute("true")
+				So(err, ShouldNotBeNil)
+
+				Convey("And instance should be reused after task is stopped", func() {
+					So(second.Stop(), ShouldBeNil)
+					fourth, err := pool.Execute("true")
+					So(err, ShouldBeNil)
+					defer fourth.EraseOutput()
+					So(fourth.Address(), ShouldEqual, second.Address())
+					So(cloud.Servers(), ShouldHaveLength, 2)
+				})
+			})
+		})
+
+		Convey("Instance should be released when the task terminates without being waited for", func() {
+			pool.config.AcquireTimeout = waitTimeout
+			for i := 0; i < 3; i++ {
+				handle, err := pool.Execute("true")
+				So(err, ShouldBeNil)
+				defer handle.EraseOutput()
+			}
+			So(cloud.Servers(), ShouldHaveLength, 2)
+		})
+
+		Convey("Instance failing to reset should be deleted", func() {
+			pool.config.ResetCommand = "false"
+			handle, err := pool.Execute("true")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			So(handle.Stop(), ShouldNotBeNil)
+			So(pool.Size(), ShouldEqual, 1)
+			So(cloud.DeletedServers(), ShouldResemble, []string{handle.(*openstackPoolTaskHandle).Instance()})
+		})
+
+		Convey("All the instances should be deleted with the pool", func() {
+			So(pool.Delete(), ShouldBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+			So(pool.Delete(), ShouldBeNil)
+
+			_, err := pool.Execute("true")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When pool cannot be fully booted", t, func() {
+		cloud := openstackfake.NewCloud()
+		defer cloud.Close()
+		cloud.AddFloatingIP("172.24.4.1")
+
+		keyDirectory, err := ioutil.TempDir("", "openstack-pool")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(keyDirectory)
+
+		_, err = newOpenstackPool(newFakeOpenstack(cloud, keyDirectory), OpenstackPoolConfig{Size: 2})
+
+		Convey("Error should be returned and booted instances should be deleted", func() {
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+			So(cloud.DeletedServers(), ShouldHaveLength, 1)
+		})
+	})
+}
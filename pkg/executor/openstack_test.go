@@ -1,9 +1,15 @@
 package executor
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
 	"github.com/gophercloud/gophercloud"
+	"github.com/intelsdi-x/swan/pkg/openstackfake"
 	. "github.com/smartystreets/goconvey/convey"
-	"testing"
 )
 
 func TestDefaultOpenstackConfig(t *testing.T) {
@@ -20,5 +26,238 @@ func TestDefaultOpenstackConfig(t *testing.T) {
 		So(config.Image, ShouldEqual, "cirros")
 		So(config.User, ShouldEqual, "cirros")
 		So(config.SSHKeyPath, ShouldEqual, "~/.ssh/id_rsa")
+		So(config.HostAggregateID, ShouldEqual, -1)
+		So(config.BootUpTimeout, ShouldEqual, 30*time.Second)
+	})
+}
+
+// newFakeOpenstack returns executor booting instances in fake cloud and running commands locally instead of over SSH.
+func newFakeOpenstack(cloud *openstackfake.Cloud, keyDirectory string) Openstack {
+	config := DefaultOpenstackConfig(cloud.AuthOptions())
+	config.Image = openstackfake.DefaultImage
+	config.HostAggregateID = openstackfake.DefaultHostAggregateID
+	config.BootUpTimeout = 0
+	config.SSHKeyPath = filepath.Join(keyDirectory, "id_rsa")
+	ioutil.WriteFile(config.SSHKeyPath+".pub", []byte("ssh-rsa fake"), 0600)
+
+	return Openstack{
+		config: &config,
+		newRemote: func(address string, config RemoteConfig) (Executor, error) {
+			return NewLocal(), nil
+		},
+	}
+}
+
+func TestOpenstackExecutor(t *testing.T) {
+	Convey("When Openstack executor runs a task in the cloud", t, func() {
+		cloud := openstackfake.NewCloud()
+		defer cloud.Close()
+		cloud.AddFloatingIP("172.24.4.1")
+		cloud.AddFloatingIP("172.24.4.2")
+
+		keyDirectory, err := ioutil.TempDir("", "openstack")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(keyDirectory)
+		stack := newFakeOpenstack(cloud, keyDirectory)
+
+		handle, err := stack.Execute("sleep 10")
+		So(err, ShouldBeNil)
+		defer handle.EraseOutput()
+		defer handle.Stop()
+
+		servers := cloud.Servers()
+		So(servers, ShouldHaveLength, 1)
+		server := servers[0]
+
+		Convey("Instance should be booted with flavor, keypair and floating IP", func() {
+			So(handle.Status(), ShouldEqual, RUNNING)
+			So(handle.Address(), ShouldEqual, "172.24.4.1")
+			So(handle.(*OpenstackTaskHandle).Instance(), ShouldEqual, server.ID)
+			So(server.Status, ShouldEqual, "ACTIVE")
+			So(server.FloatingIP, ShouldEqual, "172.24.4.1")
+			So(server.KeyName, ShouldEqual, keypairName.Value())
+
+			flavors := cloud.Flavors()
+			So(flavors, ShouldHaveLength, 1)
+			So(flavors[0].Name, ShouldEqual, "krico.cpu-1.ram-1024.disk-10")
+			So(server.FlavorID, ShouldEqual, flavors[0].ID)
+			So(cloud.Keypairs(), ShouldResemble, []string{keypairName.Value()})
+		})
+
+		Convey("Details of the instance should be written to config", func() {
+			So(stack.config.ID, ShouldEqual, server.ID)
+			So(stack.config.Name, ShouldEqual, server.Name)
+			So(stack.config.Hypervisor.InstanceName, ShouldEqual, server.InstanceName)
+			So(stack.config.Hypervisor.Address, ShouldEqual, openstackfake.DefaultHypervisorAddress)
+		})
+
+		Convey("Wait should time out while instance is running", func() {
+			terminated, err := handle.Wait(100 * time.Millisecond)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeFalse)
+			_, err = handle.ExitCode()
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Stopped task should shut off and delete the instance", func() {
+			So(handle.Stop(), ShouldBeNil)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			So(cloud.Servers(), ShouldBeEmpty)
+			So(cloud.DeletedServers(), ShouldResemble, []string{server.ID})
+
+			terminated, err := handle.Wait(100 * time.Millisecond)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+		})
+
+		Convey("Task should be terminated when instance is shut off", func() {
+			So(cloud.SetServerStatus(server.ID, "SHUTOFF"), ShouldBeNil)
+
+			terminated, err := handle.Wait(waitTimeout)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+
+			Convey("And the instance should be deleted when task is stopped", func() {
+				So(handle.Stop(), ShouldBeNil)
+				So(cloud.Servers(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("Instance in error state should be deleted", func() {
+			So(cloud.SetServerStatus(server.ID, "ERROR"), ShouldBeNil)
+
+			select {
+			case <-handle.(*OpenstackTaskHandle).deleted:
+			case <-time.After(waitTimeout):
+			}
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			So(cloud.DeletedServers(), ShouldResemble, []string{server.ID})
+		})
+
+		Convey("Existing flavor and keypair should be reused by next task", func() {
+			second, err := stack.Execute("true")
+			So(err, ShouldBeNil)
+			defer second.EraseOutput()
+			defer second.Stop()
+
+			So(second.Address(), ShouldEqual, "172.24.4.2")
+			So(cloud.Flavors(), ShouldHaveLength, 1)
+			So(cloud.Keypairs(), ShouldHaveLength, 1)
+			So(cloud.Servers(), ShouldHaveLength, 2)
+		})
+	})
+}
+
+func TestOpenstackExecutorPlacement(t *testing.T) {
+	Convey("When Openstack executor runs a task in host aggregate", t, func() {
+		cloud := openstackfake.NewCloud()
+		defer cloud.Close()
+		cloud.AddFloatingIP("172.24.4.1")
+		cloud.AddHostAggregate(7, "krico-fast", "fast-zone", map[string]string{
+			"configuration_id": "fast-1",
+			"cpu_threads":      "16",
+			"ram_size":         "64",
+			"disk_iops":        "10000",
+		})
+
+		keyDirectory, err := ioutil.TempDir("", "openstack")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(keyDirectory)
+		stack := newFakeOpenstack(cloud, keyDirectory)
+		stack.config.HostAggregateID = 7
+
+		handle, err := stack.Execute("true")
+		So(err, ShouldBeNil)
+		defer handle.EraseOutput()
+		defer handle.Stop()
+
+		Convey("Instance should be booted in availability zone of the aggregate", func() {
+			servers := cloud.Servers()
+			So(servers, ShouldHaveLength, 1)
+			So(servers[0].AvailabilityZone, ShouldEqual, "fast-zone")
+		})
+
+		Convey("Host aggregate metadata should be written to config", func() {
+			So(stack.config.HostAggregate.Name, ShouldEqual, "krico-fast")
+			So(stack.config.HostAggregate.AvailabilityZone, ShouldEqual, "fast-zone")
+			So(stack.config.HostAggregate.ConfigurationID, ShouldEqual, "fast-1")
+			So(stack.config.HostAggregate.CPU.Threads, ShouldEqual, "16")
+			So(stack.config.HostAggregate.RAM.Size, ShouldEqual, "64")
+			So(stack.config.HostAggregate.Disk.Iops, ShouldEqual, "10000")
+		})
+	})
+}
+
+func TestOpenstackExecutorErrors(t *testing.T) {
+	Convey("When Openstack executor cannot boot an instance", t, func() {
+		cloud := openstackfake.NewCloud()
+		defer cloud.Close()
+		cloud.AddFloatingIP("172.24.4.1")
+
+		keyDirectory, err := ioutil.TempDir("", "openstack")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(keyDirectory)
+		stack := newFakeOpenstack(cloud, keyDirectory)
+
+		Convey("Error should be returned when credentials are invalid", func() {
+			stack.config.Auth.Password = "invalid"
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Error should be returned when public key cannot be read", func() {
+			stack.config.SSHKeyPath = filepath.Join(keyDirectory, "missing")
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Keypairs(), ShouldBeEmpty)
+		})
+
+		Convey("Error should be returned when image does not exist", func() {
+			stack.config.Image = "missing"
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+		})
+
+		Convey("Error should be returned when host aggregate does not exist", func() {
+			stack.config.HostAggregateID = 42
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+		})
+
+		Convey("Error should be returned when there is no free floating IP", func() {
+			first, err := stack.Execute("true")
+			So(err, ShouldBeNil)
+			defer first.EraseOutput()
+			defer first.Stop()
+
+			_, err = stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldHaveLength, 1)
+		})
+
+		Convey("Error should be returned when server cannot be created", func() {
+			cloud.FailRequests("POST", "servers", 500)
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+		})
+
+		Convey("Instance which failed to boot should be deleted", func() {
+			cloud.BootStatus = "ERROR"
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+			So(cloud.DeletedServers(), ShouldHaveLength, 1)
+		})
+
+		Convey("Instance should be deleted when hypervisor cannot be found", func() {
+			cloud.FailRequests("GET", "os-hypervisors", 503)
+			_, err := stack.Execute("true")
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+		})
 	})
 }
@@ -16,10 +16,12 @@ package executor
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
@@ -66,6 +68,7 @@ func createOutputDirectory(command string, prefix string) (createdDirectoryPath
 		os.RemoveAll(createdDirectoryPath)
 		return "", errors.Wrapf(err, "failed to set privileges for dir %q", createdDirectoryPath)
 	}
+	recordArtifact(recordedArtifact{Kind: OutputDirectoryArtifact, Host: localHost, Name: createdDirectoryPath})
 
 	return createdDirectoryPath, nil
 }
@@ -185,3 +188,56 @@ func tailFile(filePath string, lineCount int) (tail string, err error) {
 
 	return "\n" + string(output), nil
 }
+
+// ArchiveOutput copies all files from task output directory (stdout, stderr and followed remote files)
+// into subdirectory of archiveDirectory named after the output directory.
+func ArchiveOutput(handle TaskHandle, archiveDirectory string) error {
+	stdout, err := handle.StdoutFile()
+	if err != nil {
+		return err
+	}
+	outputDirectory := filepath.Dir(stdout.Name())
+	stdout.Close()
+
+	destination := filepath.Join(archiveDirectory, filepath.Base(outputDirectory))
+	err = os.MkdirAll(destination, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create archive directory %q", destination)
+	}
+
+	files, err := ioutil.ReadDir(outputDirectory)
+	if err != nil {
+		return errors.Wrapf(err, "cannot list output directory %q", outputDirectory)
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		err = copyFile(filepath.Join(outputDirectory, file.Name()), filepath.Join(destination, file.Name()))
+		if err != nil {
+			return errors.Wrapf(err, "cannot archive output file %q", file.Name())
+		}
+	}
+	return nil
+}
+
+// copyFile streams content of source file into destination file.
+func copyFile(sourcePath, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open %q", sourcePath)
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(destinationPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, outputFilePrivileges)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create %q", destinationPath)
+	}
+	defer destination.Close()
+
+	_, err = io.Copy(destination, source)
+	if err != nil {
+		return errors.Wrapf(err, "cannot copy %q to %q", sourcePath, destinationPath)
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package executor
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"testing"
@@ -86,3 +87,33 @@ func TestCreateExecutorOutputFiles(t *testing.T) {
 		})
 	})
 }
+
+func TestArchiveOutput(t *testing.T) {
+	Convey("When task output directory contains files", t, func() {
+		outputDir, err := createOutputDirectory("command", "test")
+		So(err, ShouldBeNil)
+		stdout, stderr, err := createExecutorOutputFiles(outputDir)
+		So(err, ShouldBeNil)
+		defer filesCleanup(stdout, stderr)
+		_, err = stdout.WriteString("output")
+		So(err, ShouldBeNil)
+
+		archiveDir, err := ioutil.TempDir("", "archive")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(archiveDir)
+
+		handle := new(MockTaskHandle)
+		stdoutForHandle, err := os.Open(stdout.Name())
+		So(err, ShouldBeNil)
+		handle.On("StdoutFile").Return(stdoutForHandle, nil)
+
+		Convey("They should be copied to archive subdirectory named after output directory", func() {
+			So(ArchiveOutput(handle, archiveDir), ShouldBeNil)
+			content, err := ioutil.ReadFile(path.Join(archiveDir, path.Base(outputDir), "stdout"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "output")
+			_, err = os.Stat(path.Join(archiveDir, path.Base(outputDir), "stderr"))
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -16,37 +16,240 @@ package executor
 
 import (
 	"fmt"
-	"syscall"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/intelsdi-x/swan/pkg/isolation"
-	"github.com/sirupsen/logrus"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
-// Parallel allows to run same command using same executor multiple times.
-// Using Parallel decorator will mix output from all the commands executed.
-// Parallel is run in new PID namespace (using isolation.Namespace) as children might not be killed correctly otherwise.
+// Parallel is an Executor that runs the same command multiple times using underlying executor.
+// Every clone is a separate task with its own output files and exit code; they are controlled
+// together by ParallelTaskHandle.
 type Parallel struct {
-	numberOfClones int
+	executor Executor
+	// cloneDecorators are applied to the command of respective clone (e.g. to pin it to its own CPU).
+	cloneDecorators []isolation.Decorator
 }
 
-// NewParallel prepares instance of Decorator that allows to ran tasks in parallel.
-func NewParallel(numberOfClones int) Parallel {
-	return Parallel{numberOfClones: numberOfClones}
+// NewParallel returns Parallel executor running numberOfClones clones of every command.
+func NewParallel(executor Executor, numberOfClones int) Parallel {
+	cloneDecorators := make([]isolation.Decorator, numberOfClones)
+	for i := range cloneDecorators {
+		cloneDecorators[i] = isolation.Decorators{}
+	}
+	return Parallel{executor: executor, cloneDecorators: cloneDecorators}
+}
+
+// NewParallelPinned returns Parallel executor running one clone of every command on every CPU from cpus.
+func NewParallelPinned(executor Executor, cpus isolation.IntSet) Parallel {
+	var cloneDecorators []isolation.Decorator
+	for _, cpu := range cpus.AsSlice() {
+		cloneDecorators = append(cloneDecorators, isolation.Taskset{CPUList: isolation.NewIntSet(cpu)})
+	}
+	return Parallel{executor: executor, cloneDecorators: cloneDecorators}
+}
+
+// NewParallelWithDecorators returns Parallel executor running one clone of every command per decorator.
+func NewParallelWithDecorators(executor Executor, cloneDecorators ...isolation.Decorator) Parallel {
+	return Parallel{executor: executor, cloneDecorators: cloneDecorators}
+}
+
+// String returns user-friendly name of executor.
+func (p Parallel) String() string {
+	return fmt.Sprintf("%d clones on %s", len(p.cloneDecorators), p.executor)
 }
 
-// Decorate implements isolation.Decorator interface by adding invocation of parallel to a command.
-func (p Parallel) Decorate(command string) string {
-	logrus.Debugf("Attempting to run command %q %d times", command, p.numberOfClones)
-	decorated := fmt.Sprintf("seq %d | xargs -P%d -l -i sh -c %q", p.numberOfClones, p.numberOfClones, command)
-	// You need to run parallel in new PID namespace to make sure that all the children are killed.
-	unshare, err := isolation.NewNamespace(syscall.CLONE_NEWPID)
+// Execute starts all the clones. When any clone fails to start, already started ones are stopped.
+func (p Parallel) Execute(command string) (TaskHandle, error) {
+	if len(p.cloneDecorators) == 0 {
+		return nil, errors.Errorf("cannot run %q in parallel: no clones requested", command)
+	}
+
+	log.Debugf("Running %d clones of %q on %s", len(p.cloneDecorators), command, p.executor)
+	clones := make([]TaskHandle, 0, len(p.cloneDecorators))
+	for i, decorator := range p.cloneDecorators {
+		clone, err := p.executor.Execute(decorator.Decorate(command))
+		if err != nil {
+			var errCollection errcollection.ErrorCollection
+			errCollection.Add(errors.Wrapf(err, "cannot start clone %d of %q", i, command))
+			for _, started := range clones {
+				errCollection.Add(started.Stop())
+				errCollection.Add(started.EraseOutput())
+			}
+			return nil, errCollection.GetErrIfAny()
+		}
+		clones = append(clones, clone)
+	}
+
+	return &ParallelTaskHandle{command: command, clones: clones}, nil
+}
+
+// ParallelTaskHandle controls all clones started by Parallel executor.
+// Output files and exit codes of individual clones are available through Clones().
+type ParallelTaskHandle struct {
+	command string
+	clones  []TaskHandle
+}
+
+// Clones returns handles of individual clones.
+func (p *ParallelTaskHandle) Clones() []TaskHandle {
+	return p.clones
+}
+
+// String returns user-friendly name of the task.
+func (p *ParallelTaskHandle) String() string {
+	return fmt.Sprintf("%d clones of %q", len(p.clones), p.command)
+}
+
+// Address returns address of the first clone.
+func (p *ParallelTaskHandle) Address() string {
+	return p.clones[0].Address()
+}
+
+// ExitCode returns exit code of the first clone that failed or 0 when all succeeded.
+// Returns error if any clone is not terminated.
+func (p *ParallelTaskHandle) ExitCode() (int, error) {
+	exitCodes, err := p.ExitCodes()
 	if err != nil {
-		logrus.Errorf("Impossible to create namespace decorator: %q", err)
-		return command
+		return 0, err
 	}
-	decorated = unshare.Decorate(decorated)
-	logrus.Debugf("Parallelized command prepared: %q", decorated)
-	logrus.Debug("Be aware that using Parallel decorator will mix output from all the commands executed")
+	for _, exitCode := range exitCodes {
+		if exitCode != 0 {
+			return exitCode, nil
+		}
+	}
+	return 0, nil
+}
 
-	return decorated
+// ExitCodes returns exit codes of all the clones.
+// Returns error if any clone is not terminated.
+func (p *ParallelTaskHandle) ExitCodes() ([]int, error) {
+	exitCodes := make([]int, len(p.clones))
+	for i, clone := range p.clones {
+		exitCode, err := clone.ExitCode()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get exit code of clone %d", i)
+		}
+		exitCodes[i] = exitCode
+	}
+	return exitCodes, nil
+}
+
+// Status returns RUNNING when any clone is running.
+func (p *ParallelTaskHandle) Status() TaskState {
+	for _, clone := range p.clones {
+		if clone.Status() == RUNNING {
+			return RUNNING
+		}
+	}
+	return TERMINATED
+}
+
+// StdoutFile returns stdout file of the first clone.
+func (p *ParallelTaskHandle) StdoutFile() (*os.File, error) {
+	return p.clones[0].StdoutFile()
+}
+
+// StderrFile returns stderr file of the first clone.
+func (p *ParallelTaskHandle) StderrFile() (*os.File, error) {
+	return p.clones[0].StderrFile()
+}
+
+// Stop stops all the clones concurrently.
+func (p *ParallelTaskHandle) Stop() error {
+	return p.forEachConcurrently(func(clone TaskHandle) error { return clone.Stop() })
+}
+
+// Pause suspends all the clones.
+func (p *ParallelTaskHandle) Pause() error {
+	return p.forEach(func(clone TaskHandle) error { return clone.Pause() })
+}
+
+// Resume continues all the clones.
+func (p *ParallelTaskHandle) Resume() error {
+	return p.forEach(func(clone TaskHandle) error { return clone.Resume() })
+}
+
+// EraseOutput removes output files of all the clones.
+func (p *ParallelTaskHandle) EraseOutput() error {
+	return p.forEach(func(clone TaskHandle) error { return clone.EraseOutput() })
+}
+
+// Wait waits for all the clones to terminate. For `0` it will wait until all of them terminate.
+func (p *ParallelTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	var errCollection errcollection.ErrorCollection
+	deadline := time.Now().Add(timeout)
+	for _, clone := range p.clones {
+		remaining := time.Duration(0)
+		if timeout != 0 {
+			remaining = deadline.Sub(time.Now())
+			if remaining <= 0 {
+				// Do not block: check if clone terminated already.
+				if clone.Status() == RUNNING {
+					return false, errCollection.GetErrIfAny()
+				}
+				continue
+			}
+		}
+		terminated, err := clone.Wait(remaining)
+		errCollection.Add(err)
+		if !terminated {
+			return false, errCollection.GetErrIfAny()
+		}
+	}
+	return true, errCollection.GetErrIfAny()
+}
+
+// StopStage implements StopStageReporter interface. The most severe stage of all the clones is returned.
+func (p *ParallelTaskHandle) StopStage() StopStage {
+	stage := NotStopped
+	for _, clone := range p.clones {
+		if cloneStage := GetStopStage(clone); cloneStage > stage {
+			stage = cloneStage
+		}
+	}
+	return stage
+}
+
+// PodStatuses implements PodStatusReporter interface. Statuses of pods of all the clones are returned.
+func (p *ParallelTaskHandle) PodStatuses() (statuses []PodStatus) {
+	for _, clone := range p.clones {
+		statuses = append(statuses, GetPodStatuses(clone)...)
+	}
+	return statuses
+}
+
+func (p *ParallelTaskHandle) forEach(f func(TaskHandle) error) error {
+	var errCollection errcollection.ErrorCollection
+	for i, clone := range p.clones {
+		if err := f(clone); err != nil {
+			errCollection.Add(errors.Wrapf(err, "clone %d", i))
+		}
+	}
+	return errCollection.GetErrIfAny()
+}
+
+func (p *ParallelTaskHandle) forEachConcurrently(f func(TaskHandle) error) error {
+	errs := make([]error, len(p.clones))
+	var wg sync.WaitGroup
+	for i, clone := range p.clones {
+		wg.Add(1)
+		go func(i int, clone TaskHandle) {
+			defer wg.Done()
+			errs[i] = f(clone)
+		}(i, clone)
+	}
+	wg.Wait()
+
+	var errCollection errcollection.ErrorCollection
+	for i, err := range errs {
+		if err != nil {
+			errCollection.Add(errors.Wrapf(err, "clone %d", i))
+		}
+	}
+	return errCollection.GetErrIfAny()
 }
@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParallel(t *testing.T) {
+	Convey("When Parallel executor pins clones to CPUs", t, func() {
+		first, second := new(MockTaskHandle), new(MockTaskHandle)
+		exec := new(MockExecutor)
+		exec.On("String").Return("Mock Executor")
+		exec.On("Execute", "taskset -c 1 stress").Return(first, nil)
+		exec.On("Execute", "taskset -c 3 stress").Return(second, nil)
+
+		handle, err := NewParallelPinned(exec, isolation.NewIntSet(3, 1)).Execute("stress")
+
+		Convey("Every clone should be run on its own CPU", func() {
+			So(err, ShouldBeNil)
+			So(handle.(*ParallelTaskHandle).Clones(), ShouldResemble, []TaskHandle{first, second})
+		})
+
+		Convey("Exit code of the first failed clone should be returned", func() {
+			first.On("ExitCode").Return(0, nil)
+			second.On("ExitCode").Return(2, nil)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 2)
+		})
+
+		Convey("Task should be running until all clones terminate", func() {
+			first.On("Status").Return(TERMINATED)
+			second.On("Status").Return(RUNNING)
+			So(handle.Status(), ShouldEqual, RUNNING)
+		})
+	})
+
+	Convey("When one of clones fails to start", t, func() {
+		started := new(MockTaskHandle)
+		started.On("Stop").Return(nil)
+		started.On("EraseOutput").Return(nil)
+		exec := new(MockExecutor)
+		exec.On("String").Return("Mock Executor")
+		exec.On("Execute", "stress").Return(started, nil).Once()
+		exec.On("Execute", "stress").Return(nil, errors.New("no such file")).Once()
+
+		_, err := NewParallel(exec, 2).Execute("stress")
+
+		Convey("Already started clones should be stopped", func() {
+			So(err, ShouldNotBeNil)
+			So(started.AssertCalled(t, "Stop"), ShouldBeTrue)
+		})
+	})
+}
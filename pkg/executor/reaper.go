@@ -0,0 +1,381 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Kinds of artifacts found by Reaper.
+const (
+	ProcessArtifact         = "process"
+	ContainerArtifact       = "container"
+	UnitArtifact            = "systemd unit"
+	PodArtifact             = "pod"
+	CgroupArtifact          = "cgroup"
+	ResctrlArtifact         = "resctrl group"
+	OutputDirectoryArtifact = "output directory"
+)
+
+// Artifact is a resource left behind by an experiment that was not shut down cleanly.
+type Artifact struct {
+	Kind string
+	Host string
+	Name string
+
+	remove func() error
+}
+
+// String returns user-friendly description of the artifact.
+func (a Artifact) String() string {
+	return fmt.Sprintf("%s %q on %s", a.Kind, a.Name, a.Host)
+}
+
+// Remove deletes the artifact.
+func (a Artifact) Remove() error {
+	return a.remove()
+}
+
+// ReaperConfig describes where Reaper looks for artifacts.
+type ReaperConfig struct {
+	// Hosts are addresses of machines where cgroups and resctrl groups are cleaned. Local machine is accessed without SSH.
+	Hosts []string
+	// ManifestFile is a path to artifact manifest written by executors (see ArtifactManifestFlag).
+	// Only processes, containers, systemd units and output directories recorded there are removed.
+	ManifestFile string
+	// Prefix of cgroups, resctrl groups and pods created by experiments.
+	Prefix string
+	// CgroupRoot is a mount point of cgroup controllers.
+	CgroupRoot string
+	// ResctrlRoot is a mount point of resctrl filesystem.
+	ResctrlRoot string
+	// KubernetesAddress is an address of Kubernetes API server. Pods are not looked for when empty.
+	KubernetesAddress   string
+	KubernetesNamespace string
+	// CommandTimeout limits time of a single discovery or removal command.
+	CommandTimeout time.Duration
+}
+
+// DefaultReaperConfig returns ReaperConfig that cleans local machine.
+func DefaultReaperConfig() ReaperConfig {
+	return ReaperConfig{
+		Hosts:               []string{localHost},
+		ManifestFile:        ArtifactManifestFlag.Value(),
+		Prefix:              DefaultKubernetesConfig().PodNamePrefix,
+		CgroupRoot:          "/sys/fs/cgroup",
+		ResctrlRoot:         "/sys/fs/resctrl",
+		KubernetesAddress:   "",
+		KubernetesNamespace: v1.NamespaceDefault,
+		CommandTimeout:      10 * time.Second,
+	}
+}
+
+// Reaper discovers and removes artifacts left behind by experiments after dirty shutdown.
+type Reaper struct {
+	config ReaperConfig
+}
+
+// NewReaper returns Reaper using given configuration.
+func NewReaper(config ReaperConfig) *Reaper {
+	return &Reaper{config: config}
+}
+
+// Discover returns all the artifacts found in order they should be removed.
+// Manifest entries of artifacts that do not exist anymore are removed from the manifest.
+// Errors from unreachable hosts or cluster are returned along with artifacts found elsewhere.
+func (r *Reaper) Discover() ([]Artifact, error) {
+	var artifacts []Artifact
+	var errCollection errcollection.ErrorCollection
+
+	var recorded []recordedArtifact
+	manifestSize := 0
+	if r.config.ManifestFile != "" {
+		var err error
+		recorded, manifestSize, err = readArtifactManifest(r.config.ManifestFile)
+		errCollection.Add(err)
+	}
+
+	executors := map[string]Executor{}
+	hosts := append([]string{}, r.config.Hosts...)
+	for _, artifact := range recorded {
+		hosts = append(hosts, artifact.Host)
+	}
+	for _, host := range hosts {
+		if _, ok := executors[host]; ok {
+			continue
+		}
+		exec, err := r.executor(host)
+		if err != nil {
+			errCollection.Add(err)
+			continue
+		}
+		executors[host] = exec
+	}
+
+	// Recorded processes, containers and units are stopped before anything else is removed.
+	var existing []recordedArtifact
+	var directories []Artifact
+	for _, entry := range recorded {
+		exec, ok := executors[entry.Host]
+		if !ok {
+			// Host is not reachable now, so entry is kept for later.
+			existing = append(existing, entry)
+			continue
+		}
+		found, err := r.discoverRecorded(entry, exec)
+		if err != nil {
+			errCollection.Add(err)
+			existing = append(existing, entry)
+			continue
+		}
+		if len(found) == 0 {
+			continue
+		}
+		existing = append(existing, entry)
+		if entry.Kind == OutputDirectoryArtifact {
+			directories = append(directories, found...)
+		} else {
+			artifacts = append(artifacts, found...)
+		}
+	}
+	if r.config.ManifestFile != "" && len(existing) != len(recorded) {
+		errCollection.Add(pruneArtifactManifest(r.config.ManifestFile, existing, manifestSize))
+	}
+
+	if r.config.KubernetesAddress != "" {
+		found, err := r.discoverPods()
+		errCollection.Add(err)
+		artifacts = append(artifacts, found...)
+	}
+
+	for _, host := range r.config.Hosts {
+		exec, ok := executors[host]
+		if !ok {
+			continue
+		}
+		for _, discover := range []func(string, Executor) ([]Artifact, error){r.discoverCgroups, r.discoverResctrlGroups} {
+			found, err := discover(host, exec)
+			errCollection.Add(err)
+			artifacts = append(artifacts, found...)
+		}
+	}
+
+	return append(artifacts, directories...), errCollection.GetErrIfAny()
+}
+
+// Reap removes all the artifacts. Removal continues when single artifact cannot be removed.
+func Reap(artifacts []Artifact) error {
+	var errCollection errcollection.ErrorCollection
+	for _, artifact := range artifacts {
+		log.Infof("Reaper: removing %s", artifact)
+		err := artifact.Remove()
+		if err != nil {
+			errCollection.Add(errors.Wrapf(err, "cannot remove %s", artifact))
+		}
+	}
+	return errCollection.GetErrIfAny()
+}
+
+func (r *Reaper) executor(host string) (Executor, error) {
+	if host == localHost || host == "localhost" {
+		return NewLocal(), nil
+	}
+	exec, err := NewRemoteFromIP(host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot connect to %q", host)
+	}
+	return exec, nil
+}
+
+// discoverRecorded returns artifacts for manifest entry that still exists on its host.
+func (r *Reaper) discoverRecorded(entry recordedArtifact, exec Executor) ([]Artifact, error) {
+	quotedName := shellQuote(entry.Name)
+	switch entry.Kind {
+	case ProcessArtifact:
+		if entry.PID > 0 {
+			// Local tasks are process group leaders running "sh -c <command>"; PID might have been reused since.
+			lines, err := r.run(exec, fmt.Sprintf("ps -o pgid=,args= -p %d || true", entry.PID))
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot check process %d on %q", entry.PID, entry.Host)
+			}
+			if len(lines) == 0 || !strings.HasPrefix(lines[0], fmt.Sprintf("%d ", entry.PID)) || !strings.Contains(lines[0], entry.Name) {
+				return nil, nil
+			}
+			return []Artifact{r.artifact(ProcessArtifact, entry.Host, fmt.Sprintf("%s (pid %d)", entry.Name, entry.PID), exec, fmt.Sprintf("kill -9 -- -%d", entry.PID))}, nil
+		}
+		// Only processes with exactly the recorded command line are matched.
+		pids, err := r.run(exec, fmt.Sprintf("pgrep -x -f %s || true", shellQuote(regexp.QuoteMeta(entry.Name))))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list %q processes on %q", entry.Name, entry.Host)
+		}
+		var artifacts []Artifact
+		for _, pid := range pids {
+			artifacts = append(artifacts, r.artifact(ProcessArtifact, entry.Host, fmt.Sprintf("%s (pid %s)", entry.Name, pid), exec, fmt.Sprintf("kill -9 %s", pid)))
+		}
+		return artifacts, nil
+	case ContainerArtifact:
+		lines, err := r.run(exec, fmt.Sprintf("docker ps --all --quiet --filter name=%s", shellQuote("^/"+entry.Name+"$")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot check container %q on %q", entry.Name, entry.Host)
+		}
+		if len(lines) == 0 {
+			return nil, nil
+		}
+		return []Artifact{r.artifact(ContainerArtifact, entry.Host, entry.Name, exec, fmt.Sprintf("docker rm --force %s", quotedName))}, nil
+	case UnitArtifact:
+		lines, err := r.run(exec, fmt.Sprintf("systemctl list-units --all --plain --no-legend %s", quotedName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot check unit %q on %q", entry.Name, entry.Host)
+		}
+		if len(lines) == 0 {
+			return nil, nil
+		}
+		// Failed transient units stay loaded until they are reset.
+		return []Artifact{r.artifact(UnitArtifact, entry.Host, entry.Name, exec, fmt.Sprintf("systemctl stop %s; systemctl reset-failed %s 2>/dev/null || true", quotedName, quotedName))}, nil
+	case OutputDirectoryArtifact:
+		lines, err := r.run(exec, fmt.Sprintf("test -d %s && echo exists || true", quotedName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot check output directory %q on %q", entry.Name, entry.Host)
+		}
+		if len(lines) == 0 {
+			return nil, nil
+		}
+		return []Artifact{r.artifact(OutputDirectoryArtifact, entry.Host, entry.Name, exec, fmt.Sprintf("rm -rf %s", quotedName))}, nil
+	default:
+		return nil, errors.Errorf("unknown kind %q of recorded artifact %q", entry.Kind, entry.Name)
+	}
+}
+
+func (r *Reaper) discoverPods() ([]Artifact, error) {
+	clientset, err := NewKubernetesClientset(r.config.KubernetesAddress)
+	if err != nil {
+		return nil, err
+	}
+	podsAPI := clientset.Pods(r.config.KubernetesNamespace)
+	pods, err := podsAPI.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list pods in namespace %q", r.config.KubernetesNamespace)
+	}
+
+	var artifacts []Artifact
+	for _, pod := range pods.Items {
+		if !strings.HasPrefix(pod.Name, r.config.Prefix+"-") {
+			continue
+		}
+		name := pod.Name
+		artifacts = append(artifacts, Artifact{
+			Kind: PodArtifact,
+			Host: r.config.KubernetesAddress,
+			Name: name,
+			remove: func() error {
+				var gracePeriod int64
+				return podsAPI.Delete(name, &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+			},
+		})
+	}
+	return artifacts, nil
+}
+
+func (r *Reaper) discoverCgroups(host string, exec Executor) ([]Artifact, error) {
+	paths, err := r.run(exec, fmt.Sprintf("find '%s' -mindepth 2 -maxdepth 2 -type d -name '%s*' 2>/dev/null || true", r.config.CgroupRoot, r.config.Prefix))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list cgroups on %q", host)
+	}
+	var artifacts []Artifact
+	for _, path := range paths {
+		// Cgroups can only be removed with rmdir, starting from the deepest ones.
+		artifacts = append(artifacts, r.artifact(CgroupArtifact, host, path, exec, fmt.Sprintf("find '%s' -depth -type d -exec rmdir {} +", path)))
+	}
+	return artifacts, nil
+}
+
+func (r *Reaper) discoverResctrlGroups(host string, exec Executor) ([]Artifact, error) {
+	paths, err := r.run(exec, fmt.Sprintf("find '%s' -mindepth 1 -maxdepth 1 -type d -name '%s*' 2>/dev/null || true", r.config.ResctrlRoot, r.config.Prefix))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list resctrl groups on %q", host)
+	}
+	var artifacts []Artifact
+	for _, path := range paths {
+		artifacts = append(artifacts, r.artifact(ResctrlArtifact, host, path, exec, fmt.Sprintf("rmdir '%s'", path)))
+	}
+	return artifacts, nil
+}
+
+func (r *Reaper) artifact(kind, host, name string, exec Executor, removeCommand string) Artifact {
+	return Artifact{
+		Kind: kind,
+		Host: host,
+		Name: name,
+		remove: func() error {
+			_, err := r.run(exec, removeCommand)
+			return err
+		},
+	}
+}
+
+// run executes command and returns non-empty lines of its output.
+func (r *Reaper) run(exec Executor, command string) ([]string, error) {
+	handle, err := exec.Execute(command)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.EraseOutput()
+
+	terminated, err := handle.Wait(r.config.CommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !terminated {
+		handle.Stop()
+		return nil, errors.Errorf("timeout while waiting for %q", command)
+	}
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, errors.Errorf("%q exited with code %d", command, exitCode)
+	}
+
+	stdout, err := handle.StdoutFile()
+	if err != nil {
+		return nil, err
+	}
+	defer stdout.Close()
+	output, err := ioutil.ReadAll(stdout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read output of %q", command)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
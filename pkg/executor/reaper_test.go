@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReaperRecordedArtifacts(t *testing.T) {
+	Convey("When artifact manifest records output directories", t, func() {
+		workDir, err := ioutil.TempDir("", "reaper")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(workDir)
+
+		existing := path.Join(workDir, "local_memcached_1")
+		So(os.Mkdir(existing, 0755), ShouldBeNil)
+		notRecorded := path.Join(workDir, "local_mutilate_2")
+		So(os.Mkdir(notRecorded, 0755), ShouldBeNil)
+
+		manifest := path.Join(workDir, "manifest.json")
+		content := `{"kind":"output directory","host":"127.0.0.1","name":"` + existing + `"}` + "\n" +
+			`{"kind":"output directory","host":"127.0.0.1","name":"` + path.Join(workDir, "removed") + `"}` + "\n"
+		So(ioutil.WriteFile(manifest, []byte(content), 0644), ShouldBeNil)
+
+		config := DefaultReaperConfig()
+		config.Hosts = nil
+		config.ManifestFile = manifest
+		reaper := NewReaper(config)
+
+		artifacts, err := reaper.Discover()
+		So(err, ShouldBeNil)
+
+		Convey("Only existing recorded directory should be found", func() {
+			So(artifacts, ShouldHaveLength, 1)
+			So(artifacts[0].Kind, ShouldEqual, OutputDirectoryArtifact)
+			So(artifacts[0].Name, ShouldEqual, existing)
+
+			recorded, _, err := readArtifactManifest(manifest)
+			So(err, ShouldBeNil)
+			So(recorded, ShouldHaveLength, 1)
+			So(recorded[0].Name, ShouldEqual, existing)
+		})
+
+		Convey("Reaping should not touch directories which were not recorded", func() {
+			So(Reap(artifacts), ShouldBeNil)
+			_, err := os.Stat(existing)
+			So(os.IsNotExist(err), ShouldBeTrue)
+			_, err = os.Stat(notRecorded)
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestArtifactManifest(t *testing.T) {
+	Convey("When artifact manifest is enabled in experiment directory", t, func() {
+		workDir, err := ioutil.TempDir("", "manifest")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(workDir)
+
+		manifest := EnableArtifactManifest(workDir)
+		defer func() { manifestPath = "" }()
+		So(manifest, ShouldEqual, path.Join(workDir, artifactManifestName))
+
+		recordArtifact(recordedArtifact{Kind: UnitArtifact, Host: localHost, Name: "swan-test.service"})
+		recorded, _, err := readArtifactManifest(manifest)
+		So(err, ShouldBeNil)
+		So(recorded, ShouldHaveLength, 1)
+
+		Convey("It should be empty after truncation", func() {
+			So(TruncateArtifactManifest(), ShouldBeNil)
+			recorded, _, err := readArtifactManifest(manifest)
+			So(err, ShouldBeNil)
+			So(recorded, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Artifacts should not be recorded until manifest is enabled", t, func() {
+		So(manifestPath, ShouldBeEmpty)
+		So(TruncateArtifactManifest(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CleanupTimeoutFlag limits time spent on reclaiming a single task or isolation during shutdown.
+var CleanupTimeoutFlag = conf.NewDurationFlag("cleanup_timeout", "Maximum time spent on stopping a single task or cleaning a single isolation when experiment is interrupted.", 10*time.Second)
+
+// registryEntry is a resource reclaimed by Registry.
+// Exactly one of the handle, isolation or cleanup is set.
+type registryEntry struct {
+	handle    TaskHandle
+	isolation isolation.Isolation
+	name      string
+	cleanup   func() error
+}
+
+func (entry registryEntry) String() string {
+	switch {
+	case entry.handle != nil:
+		return entry.handle.String()
+	case entry.isolation != nil:
+		return fmt.Sprintf("isolation %q", entry.isolation.Decorate("<command>"))
+	default:
+		return entry.name
+	}
+}
+
+// reclaim stops task, cleans isolation or runs cleanup function.
+func (entry registryEntry) reclaim() error {
+	switch {
+	case entry.handle != nil:
+		if entry.handle.Status() == TERMINATED {
+			return nil
+		}
+		return entry.handle.Stop()
+	case entry.isolation != nil:
+		return entry.isolation.Clean()
+	default:
+		return entry.cleanup()
+	}
+}
+
+// Registry keeps track of all tasks, isolations and cleanup functions that need to be reclaimed
+// when experiment is terminated before deferred cleanup is run (e.g. by a signal).
+type Registry struct {
+	mutex   sync.Mutex
+	entries []registryEntry
+}
+
+// NewRegistry returns empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry is a process-wide registry used by all the executors.
+var DefaultRegistry = NewRegistry()
+
+// RegisterTaskHandle adds task to the registry. Already terminated tasks are dropped from the registry.
+func (r *Registry) RegisterTaskHandle(handle TaskHandle) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := r.entries[:0]
+	for _, entry := range r.entries {
+		if entry.handle != nil && entry.handle.Status() == TERMINATED {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	r.entries = append(entries, registryEntry{handle: handle})
+}
+
+// RegisterIsolation adds created isolation to the registry.
+func (r *Registry) RegisterIsolation(isolation isolation.Isolation) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, registryEntry{isolation: isolation})
+}
+
+// UnregisterIsolation removes isolation from the registry after it was cleaned.
+func (r *Registry) UnregisterIsolation(isolation isolation.Isolation) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].isolation == isolation {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// RegisterCleanup adds named cleanup function (e.g. restoring host configuration) to the registry.
+func (r *Registry) RegisterCleanup(name string, cleanup func() error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, registryEntry{name: name, cleanup: cleanup})
+}
+
+// Len returns number of registered entries.
+func (r *Registry) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.entries)
+}
+
+// Cleanup reclaims all registered entries in reverse order of registration.
+// Every entry is given at most timeout to be reclaimed.
+// Returned error lists all the entries that could not be reclaimed.
+func (r *Registry) Cleanup(timeout time.Duration) error {
+	r.mutex.Lock()
+	entries := r.entries
+	r.entries = nil
+	r.mutex.Unlock()
+
+	var errCollection errcollection.ErrorCollection
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		log.Debugf("Cleanup: reclaiming %s", entry)
+
+		result := make(chan error, 1)
+		go func() {
+			result <- entry.reclaim()
+		}()
+
+		select {
+		case err := <-result:
+			if err != nil {
+				errCollection.Add(errors.Wrapf(err, "cannot reclaim %s", entry))
+			}
+		case <-time.After(timeout):
+			errCollection.Add(errors.Errorf("cannot reclaim %s: timeout after %s", entry, timeout))
+		}
+	}
+
+	return errCollection.GetErrIfAny()
+}
+
+// RegisterTaskHandle adds task to the DefaultRegistry.
+func RegisterTaskHandle(handle TaskHandle) {
+	DefaultRegistry.RegisterTaskHandle(handle)
+}
+
+// RegisterIsolation adds created isolation to the DefaultRegistry.
+func RegisterIsolation(isolation isolation.Isolation) {
+	DefaultRegistry.RegisterIsolation(isolation)
+}
+
+// UnregisterIsolation removes cleaned isolation from the DefaultRegistry.
+func UnregisterIsolation(isolation isolation.Isolation) {
+	DefaultRegistry.UnregisterIsolation(isolation)
+}
+
+// RegisterCleanup adds named cleanup function to the DefaultRegistry.
+func RegisterCleanup(name string, cleanup func() error) {
+	DefaultRegistry.RegisterCleanup(name, cleanup)
+}
+
+func cleanupDefaultRegistry() {
+	err := DefaultRegistry.Cleanup(CleanupTimeoutFlag.Value())
+	if err != nil {
+		log.Errorf("Cleanup: following resources were not reclaimed and need to be removed manually:\n%s", err.Error())
+		return
+	}
+	// Everything recorded was reclaimed, so nothing is left for swan-reap.
+	err = TruncateArtifactManifest()
+	if err != nil {
+		log.Warnf("Cleanup: %s", err.Error())
+	}
+}
+
+// Exit reclaims everything from DefaultRegistry and terminates the experiment with given exit code.
+// It should be used instead of os.Exit, which does not run deferred cleanup.
+func Exit(code int) {
+	cleanupDefaultRegistry()
+	os.Exit(code)
+}
+
+// CleanupOnExit makes sure that everything from DefaultRegistry is reclaimed when experiment
+// receives SIGINT or SIGTERM or terminates with logrus.Fatal (e.g. errutil.Check).
+// Resources that could not be reclaimed are reported, so they can be removed manually.
+func CleanupOnExit() {
+	log.RegisterExitHandler(cleanupDefaultRegistry)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		received := <-signals
+		log.Warnf("Received %s, stopping all tasks", received)
+		Exit(128 + int(received.(syscall.Signal)))
+	}()
+}
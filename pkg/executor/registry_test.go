@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("When tasks and cleanup functions are registered", t, func() {
+		registry := NewRegistry()
+		order := []string{}
+
+		terminated := new(MockTaskHandle)
+		terminated.On("Status").Return(TERMINATED)
+
+		running := new(MockTaskHandle)
+		running.On("Status").Return(RUNNING)
+		running.On("Stop").Return(nil).Run(func(_ mock.Arguments) { order = append(order, "task") })
+
+		registry.RegisterCleanup("first", func() error {
+			order = append(order, "first")
+			return nil
+		})
+		registry.RegisterTaskHandle(terminated)
+		registry.RegisterTaskHandle(running)
+
+		Convey("Terminated tasks should be dropped", func() {
+			So(registry.Len(), ShouldEqual, 2)
+		})
+
+		Convey("Entries should be reclaimed in reverse order", func() {
+			So(registry.Cleanup(time.Second), ShouldBeNil)
+			So(order, ShouldResemble, []string{"task", "first"})
+			So(registry.Len(), ShouldEqual, 0)
+			So(running.AssertExpectations(t), ShouldBeTrue)
+		})
+
+		Convey("Entries which could not be reclaimed should be reported", func() {
+			registry.RegisterCleanup("failing", func() error { return errors.New("busy") })
+			registry.RegisterCleanup("hanging", func() error {
+				time.Sleep(time.Second)
+				return nil
+			})
+
+			err := registry.Cleanup(10 * time.Millisecond)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "cannot reclaim failing: busy")
+			So(err.Error(), ShouldContainSubstring, "cannot reclaim hanging: timeout")
+			So(order, ShouldResemble, []string{"task", "first"})
+		})
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
@@ -40,6 +41,9 @@ var (
 		"Default value is '$HOME/.ssh/id_rsa'", sshUserFlag.Name), path.Join(currentUser.HomeDir, ".ssh/id_rsa"))
 
 	sshPortFlag = conf.NewIntFlag("remote_ssh_port", "Port used for SSH connection to remote nodes. ", 22)
+
+	remoteFollowFilesFlag = conf.NewStringSliceFlag("remote_follow_files", "Comma separated remote files (e.g. logs written by remote workloads) streamed into "+
+		"output directory of every remote task while it is running.", []string{})
 )
 
 // RemoteConfig is configuration for Remote Executor.
@@ -48,14 +52,19 @@ type RemoteConfig struct {
 	KeyPath string
 
 	Port int
+
+	// FollowFiles are remote files written by executed commands (e.g. logs) that are streamed
+	// into local output directory while the task is running.
+	FollowFiles []string
 }
 
 // DefaultRemoteConfig returns default Remote Executor configuration from flags.
 func DefaultRemoteConfig() RemoteConfig {
 	return RemoteConfig{
-		User:    sshUserFlag.Value(),
-		KeyPath: sshUserKeyPathFlag.Value(),
-		Port:    sshPortFlag.Value(),
+		User:        sshUserFlag.Value(),
+		KeyPath:     sshUserKeyPathFlag.Value(),
+		Port:        sshPortFlag.Value(),
+		FollowFiles: remoteFollowFilesFlag.Value(),
 	}
 }
 
@@ -123,30 +132,45 @@ func (remote Remote) String() string {
 	return fmt.Sprintf("Remote executor pointing at %s@%s", remote.config.User, remote.targetHost)
 }
 
-// Execute runs the command given as input.
-// Returned Task Handle is able to stop & monitor the provisioned process.
-func (remote Remote) Execute(command string) (TaskHandle, error) {
-	connectionCommand := fmt.Sprintf("%s:%d", remote.targetHost, remote.config.Port)
-	connection, err := ssh.Dial(
-		"tcp",
-		connectionCommand,
-		remote.clientConfig)
+func (remote Remote) address() string {
+	return fmt.Sprintf("%s:%d", remote.targetHost, remote.config.Port)
+}
+
+// HealthCheck verifies that SSH connection to remote host can be established and responds to keep-alive requests.
+func (remote Remote) HealthCheck() error {
+	connection, err := defaultSSHPool.acquire(remote.address(), remote.clientConfig)
 	if err != nil {
-		return nil, errors.Wrapf(err, "ssh.Dial to '%s@%s' for command %q failed",
-			remote.clientConfig.User, connectionCommand, command)
+		return err
 	}
+	defer defaultSSHPool.release(connection)
 
-	session, err := newSessionWithPty(connection)
+	err = checkConnection(connection.client, sshKeepAliveTimeoutFlag.Value())
 	if err != nil {
-		return nil, errors.Wrapf(err, "connection.sewSessionWithPty for command %q failed with error %v", command, err)
+		defaultSSHPool.markBroken(connection)
+		return errors.Wrapf(err, "ssh connection to %s@%s is not healthy", remote.config.User, remote.targetHost)
+	}
+	return nil
+}
+
+// Execute runs the command given as input.
+// Returned Task Handle is able to stop & monitor the provisioned process.
+// SSH connections are shared by all the tasks executed on the same host (see remote_ssh_connection_pool flag).
+func (remote Remote) Execute(command string) (TaskHandle, error) {
+	session, connection, err := defaultSSHPool.newSession(remote.address(), remote.clientConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open ssh session for command %q", command)
 	}
 
 	output, err := createOutputDirectory(command, "remote")
 	if err != nil {
+		session.Close()
+		defaultSSHPool.release(connection)
 		return nil, errors.Wrapf(err, "createOutputDirectory for command %q failed", command)
 	}
 	stdoutFile, stderrFile, err := createExecutorOutputFiles(output)
 	if err != nil {
+		session.Close()
+		defaultSSHPool.release(connection)
 		removeDirectory(output)
 		return nil, errors.Wrapf(err, "createExecutorOutputFiles for command %q failed", command)
 	}
@@ -167,14 +191,24 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 	log.Debug("Starting '", stringForSh, "' remotely on '", remote.targetHost, "'")
 	err = session.Start(stringForSh)
 	if err != nil {
+		session.Close()
+		defaultSSHPool.release(connection)
 		return nil, errors.Wrapf(err, "session.Start for command %q failed", command)
 	}
 
 	log.Debug("Started remote command")
+	recordArtifact(recordedArtifact{Kind: ProcessArtifact, Host: remote.targetHost, Name: command})
 
 	// hasProcessExited channel is closed when launched process exits.
 	hasProcessExited := make(chan struct{})
 
+	for _, remotePath := range remote.config.FollowFiles {
+		err = remote.followRemoteFile(remotePath, output, hasProcessExited)
+		if err != nil {
+			log.Warnf("Cannot follow %q for command %q: %s", remotePath, command, err.Error())
+		}
+	}
+
 	// TODO(bplotka): Move exit code constants to global executor scope.
 	const successExitCode int = 0
 	const errorExitCode int = -1
@@ -194,17 +228,18 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 	go func() {
 		defer func() {
 			session.Close()
-			connection.Close()
+			defaultSSHPool.release(connection)
 		}()
 		taskHandle.exitCode = successExitCode
 		// Wait for task completion.
 		err := session.Wait()
 		if err != nil {
 			if exitError, ok := err.(*ssh.ExitError); !ok {
-				// In case of NON Exit Errors we are not sure if task does
-				// terminate so panic.
-				err = errors.Wrap(err, "wait returned with NON exit error")
-				log.Panicf("Waiting for remote task failed %+v", err)
+				// Connection was dropped, so exit status of the task is unknown. Error is reported by the handle
+				// and connection is not used for new tasks.
+				taskHandle.waitErr = errors.Wrapf(err, "connection to %s was lost while waiting for %q", remote.targetHost, command)
+				log.Errorf("Remote Executor: %s", taskHandle.waitErr.Error())
+				defaultSSHPool.markBroken(connection)
 			} else {
 				taskHandle.exitCode = exitError.Waitmsg.ExitStatus()
 			}
@@ -229,17 +264,20 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 	if err != nil {
 		return nil, err
 	}
+	RegisterTaskHandle(&taskHandle)
 	return &taskHandle, nil
 }
 
 // remoteTaskHandle implements TaskHandle interface.
 type remoteTaskHandle struct {
 	session        *ssh.Session
-	connection     *ssh.Client
+	connection     *pooledConnection
 	stdoutFilePath string
 	stderrFilePath string
 	host           string
 	exitCode       int
+	// waitErr is set when exit status could not be received (e.g. connection was dropped).
+	waitErr error
 
 	// Command requested by User. This is how this TaskHandle presents.
 	command string
@@ -247,6 +285,9 @@ type remoteTaskHandle struct {
 	// This channel is closed immediately when process exits.
 	// It is used to signal task termination.
 	hasProcessExited chan struct{}
+
+	mutex     sync.Mutex
+	stopStage StopStage
 }
 
 // isTerminated checks if channel processHasExited is closed. If it is closed, it means
@@ -267,18 +308,57 @@ func (taskHandle *remoteTaskHandle) Stop() error {
 		return nil
 	}
 
-	err := taskHandle.session.Close()
+	// Remote command is signalled through SSH; closing the session hangs up whole process group on remote PTY.
+	stage, err := DefaultStopPolicy().Stop(taskHandle,
+		func() error { return taskHandle.session.Signal(ssh.SIGTERM) },
+		func() error {
+			taskHandle.session.Signal(ssh.SIGKILL)
+			err := taskHandle.session.Close()
+			if err != nil {
+				return errors.Wrapf(err, "could not close ssh session")
+			}
+			return nil
+		})
 	if err != nil {
-		return errors.Wrapf(err, "could not close ssh session")
-	}
-	isTerminated, _ := taskHandle.Wait(killWaitTimeout)
-	if !isTerminated {
-		return errors.New("cannot stop ssh session")
+		return errors.Wrapf(err, "cannot stop ssh session")
 	}
+
+	taskHandle.mutex.Lock()
+	taskHandle.stopStage = stage
+	taskHandle.mutex.Unlock()
+
 	// No error, task terminated.
 	return nil
 }
 
+// StopStage implements StopStageReporter interface.
+func (taskHandle *remoteTaskHandle) StopStage() StopStage {
+	taskHandle.mutex.Lock()
+	defer taskHandle.mutex.Unlock()
+	return taskHandle.stopStage
+}
+
+// Pause sends SIGSTOP to the remote command. Remote sshd needs to support signals (OpenSSH 7.9 or newer).
+func (taskHandle *remoteTaskHandle) Pause() error {
+	return taskHandle.signal(ssh.SIGSTOP)
+}
+
+// Resume sends SIGCONT to the remote command.
+func (taskHandle *remoteTaskHandle) Resume() error {
+	return taskHandle.signal(ssh.SIGCONT)
+}
+
+func (taskHandle *remoteTaskHandle) signal(signal ssh.Signal) error {
+	if taskHandle.isTerminated() {
+		return errors.Errorf("cannot send %s to %q: task is terminated", signal, taskHandle.command)
+	}
+	err := taskHandle.session.Signal(signal)
+	if err != nil {
+		return errors.Wrapf(err, "cannot send %s to %q on %s", signal, taskHandle.command, taskHandle.host)
+	}
+	return nil
+}
+
 // Status returns a state of the task.
 func (taskHandle *remoteTaskHandle) Status() TaskState {
 	if !taskHandle.isTerminated() {
@@ -288,11 +368,14 @@ func (taskHandle *remoteTaskHandle) Status() TaskState {
 	return TERMINATED
 }
 
-// ExitCode returns a exitCode. If task is not terminated it returns error.
+// ExitCode returns a exitCode. If task is not terminated or its exit status was lost it returns error.
 func (taskHandle *remoteTaskHandle) ExitCode() (int, error) {
 	if !taskHandle.isTerminated() {
 		return -1, errors.New("task is not terminated")
 	}
+	if taskHandle.waitErr != nil {
+		return -1, taskHandle.waitErr
+	}
 
 	return taskHandle.exitCode, nil
 }
@@ -314,10 +397,10 @@ func (taskHandle *remoteTaskHandle) EraseOutput() error {
 }
 
 // Wait waits for the command to finish with the given timeout time.
-// It returns true if task is terminated.
+// It returns true if task is terminated and error when connection was lost while waiting for it.
 func (taskHandle *remoteTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	if taskHandle.isTerminated() {
-		return true, nil
+		return true, taskHandle.waitErr
 	}
 
 	timeoutChannel := getTimeoutChan(timeout)
@@ -325,7 +408,7 @@ func (taskHandle *remoteTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	select {
 	case <-taskHandle.hasProcessExited:
 		// If waitEndChannel is closed then task is terminated.
-		return true, nil
+		return true, taskHandle.waitErr
 	case <-timeoutChannel:
 		// If timeout time exceeded return then task did not terminate yet.
 		return false, nil
@@ -333,7 +416,7 @@ func (taskHandle *remoteTaskHandle) Wait(timeout time.Duration) (bool, error) {
 }
 
 func (taskHandle *remoteTaskHandle) String() string {
-	return fmt.Sprintf("Remote command %q running on %s@%s", taskHandle.command, taskHandle.connection.User(), taskHandle.Address())
+	return fmt.Sprintf("Remote command %q running on %s@%s", taskHandle.command, taskHandle.connection.client.User(), taskHandle.Address())
 }
 
 func (taskHandle *remoteTaskHandle) Address() string {
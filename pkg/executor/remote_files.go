@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// followFlushTimeout is a time given to tail to catch up with followed file after the task terminates.
+const followFlushTimeout = time.Second
+
+// withSFTP runs f with SFTP client working over pooled connection to the remote host.
+func (remote Remote) withSFTP(f func(client *sftp.Client) error) error {
+	connection, err := defaultSSHPool.acquire(remote.address(), remote.clientConfig)
+	if err != nil {
+		return err
+	}
+	defer defaultSSHPool.release(connection)
+
+	client, err := sftp.NewClient(connection.client)
+	if err != nil {
+		return errors.Wrapf(err, "cannot start sftp session on %s", remote.targetHost)
+	}
+	defer client.Close()
+
+	return f(client)
+}
+
+// Upload copies local file to remote host. Missing remote directories are created and file mode is preserved.
+func (remote Remote) Upload(localPath, remotePath string) error {
+	source, err := os.Open(localPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open %q", localPath)
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "cannot stat %q", localPath)
+	}
+
+	return remote.withSFTP(func(client *sftp.Client) error {
+		err := client.MkdirAll(path.Dir(remotePath))
+		if err != nil {
+			return errors.Wrapf(err, "cannot create directory %q on %s", path.Dir(remotePath), remote.targetHost)
+		}
+
+		destination, err := client.Create(remotePath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot create %q on %s", remotePath, remote.targetHost)
+		}
+		defer destination.Close()
+
+		_, err = io.Copy(destination, source)
+		if err != nil {
+			return errors.Wrapf(err, "cannot upload %q to %s:%s", localPath, remote.targetHost, remotePath)
+		}
+
+		err = client.Chmod(remotePath, info.Mode())
+		if err != nil {
+			return errors.Wrapf(err, "cannot change mode of %q on %s", remotePath, remote.targetHost)
+		}
+
+		log.Debugf("Uploaded %q to %s:%s", localPath, remote.targetHost, remotePath)
+		return nil
+	})
+}
+
+// Download copies file from remote host to local path. Missing local directories are created.
+func (remote Remote) Download(remotePath, localPath string) error {
+	return remote.withSFTP(func(client *sftp.Client) error {
+		source, err := client.Open(remotePath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open %q on %s", remotePath, remote.targetHost)
+		}
+		defer source.Close()
+
+		err = os.MkdirAll(filepath.Dir(localPath), 0755)
+		if err != nil {
+			return errors.Wrapf(err, "cannot create directory %q", filepath.Dir(localPath))
+		}
+
+		destination, err := os.Create(localPath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot create %q", localPath)
+		}
+		defer destination.Close()
+
+		_, err = io.Copy(destination, source)
+		if err != nil {
+			return errors.Wrapf(err, "cannot download %s:%s to %q", remote.targetHost, remotePath, localPath)
+		}
+
+		log.Debugf("Downloaded %s:%s to %q", remote.targetHost, remotePath, localPath)
+		return nil
+	})
+}
+
+// followRemoteFile streams remote file into local output directory as it grows until the task terminates.
+// It is used for outputs that remote command writes to files instead of stdout (see RemoteConfig.FollowFiles).
+func (remote Remote) followRemoteFile(remotePath, outputDirectory string, taskExited <-chan struct{}) error {
+	session, connection, err := defaultSSHPool.newSession(remote.address(), remote.clientConfig)
+	if err != nil {
+		return err
+	}
+
+	localPath := filepath.Join(outputDirectory, path.Base(remotePath))
+	output, err := os.Create(localPath)
+	if err != nil {
+		session.Close()
+		defaultSSHPool.release(connection)
+		return errors.Wrapf(err, "cannot create %q", localPath)
+	}
+	session.Stdout = output
+
+	err = session.Start(fmt.Sprintf("tail -n +1 -F %s", shellQuote(remotePath)))
+	if err != nil {
+		output.Close()
+		session.Close()
+		defaultSSHPool.release(connection)
+		return errors.Wrapf(err, "cannot follow %q on %s", remotePath, remote.targetHost)
+	}
+
+	go func() {
+		defer defaultSSHPool.release(connection)
+		defer syncAndClose(output)
+
+		sessionEnded := make(chan struct{})
+		go func() {
+			session.Wait()
+			close(sessionEnded)
+		}()
+
+		select {
+		case <-taskExited:
+			// Give tail a chance to copy last lines written by the task.
+			time.Sleep(followFlushTimeout)
+			// Closing the session hangs up tail.
+			session.Signal(ssh.SIGTERM)
+			session.Close()
+			<-sessionEnded
+		case <-sessionEnded:
+		}
+		log.Debugf("Stopped following %s:%s", remote.targetHost, remotePath)
+	}()
+
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// procRoot and cgroupRoot are mount points of procfs and cgroup controllers (variables for testing).
+var (
+	procRoot   = "/proc"
+	cgroupRoot = "/sys/fs/cgroup"
+)
+
+// Resources describes resource limits of a running task. Zero values are left unchanged.
+type Resources struct {
+	// CPUSet is a set of CPUs task is allowed to run on.
+	CPUSet isolation.IntSet
+	// CPUQuota is a number of CPUs worth of time task can use (e.g. 1.5).
+	CPUQuota float64
+	// MemoryLimit in bytes.
+	MemoryLimit int64
+}
+
+// String returns user-friendly description of resources.
+func (r Resources) String() string {
+	var parts []string
+	if !r.CPUSet.Empty() {
+		parts = append(parts, "cpuset="+r.CPUSet.AsRangeString())
+	}
+	if r.CPUQuota > 0 {
+		parts = append(parts, fmt.Sprintf("cpus=%g", r.CPUQuota))
+	}
+	if r.MemoryLimit > 0 {
+		parts = append(parts, fmt.Sprintf("memory=%d", r.MemoryLimit))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Resizable is an optional interface of TaskHandle which allows changing resource limits
+// of running task without restarting it.
+type Resizable interface {
+	// Resize applies new resource limits to the task.
+	Resize(resources Resources) error
+}
+
+// Resize changes resource limits of running task.
+// Returns error when handle does not implement Resizable or task has already terminated.
+func Resize(handle TaskHandle, resources Resources) error {
+	var resizable Resizable
+	for _, task := range unwrapTask(handle) {
+		if resizable, _ = task.(Resizable); resizable != nil {
+			break
+		}
+	}
+	if resizable == nil {
+		return errors.Errorf("task %s cannot be resized", handle)
+	}
+	if handle.Status() == TERMINATED {
+		return errors.Errorf("task %s has already terminated", handle)
+	}
+	log.Debugf("Resizing task %s: %s", handle, resources)
+	return resizable.Resize(resources)
+}
+
+// Resize implements Resizable interface by writing limits to cgroups of the task process.
+// Task needs to be run in dedicated cgroups (e.g. with cgroup.CPUSet decorator); root cgroups and cgroups
+// shared with processes of other tasks are never modified.
+func (taskHandle *localTaskHandle) Resize(resources Resources) error {
+	return resizeCgroups(taskHandle.getPid(), resources)
+}
+
+// resizeCgroups writes resource limits to cgroup v1 controllers of process pid.
+// Cgroup is modified only when it was created by Swan or contains only the process tree of pid.
+func resizeCgroups(pid int, resources Resources) error {
+	cgroups, err := processCgroups(pid)
+	if err != nil {
+		return err
+	}
+
+	var controllers []string
+	if !resources.CPUSet.Empty() {
+		controllers = append(controllers, "cpuset")
+	}
+	if resources.CPUQuota > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if resources.MemoryLimit > 0 {
+		controllers = append(controllers, "memory")
+	}
+	for _, controller := range controllers {
+		ownership, err := taskCgroupOwnership(cgroups, controller, pid)
+		if err != nil {
+			return err
+		}
+		if !ownership.createdBySwan && !ownership.exclusive {
+			return errors.Errorf("%s cgroup %q of process %d was not created by Swan and is shared with other processes", controller, ownership.directory, pid)
+		}
+	}
+
+	if !resources.CPUSet.Empty() {
+		err := writeCgroupFile(cgroups, "cpuset", "cpuset.cpus", resources.CPUSet.AsRangeString())
+		if err != nil {
+			return err
+		}
+	}
+
+	if resources.CPUQuota > 0 {
+		period, err := readCgroupFile(cgroups, "cpu", "cpu.cfs_period_us")
+		if err != nil {
+			return err
+		}
+		periodUs, err := strconv.Atoi(period)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse cpu.cfs_period_us %q", period)
+		}
+		quotaUs := int(resources.CPUQuota * float64(periodUs))
+		err = writeCgroupFile(cgroups, "cpu", "cpu.cfs_quota_us", strconv.Itoa(quotaUs))
+		if err != nil {
+			return err
+		}
+	}
+
+	if resources.MemoryLimit > 0 {
+		err := writeCgroupFile(cgroups, "memory", "memory.limit_in_bytes", strconv.FormatInt(resources.MemoryLimit, 10))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processCgroups returns map of controller to cgroup directory of process pid (read from /proc/<pid>/cgroup).
+func processCgroups(pid int) (map[string]string, error) {
+	path := filepath.Join(procRoot, strconv.Itoa(pid), "cgroup")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read cgroups of process %d", pid)
+	}
+	defer file.Close()
+
+	cgroups := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Line format is "hierarchy-ID:controller-list:cgroup-path", e.g. "4:cpu,cpuacct:/swan".
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) == 3 && fields[0] == "0" && fields[1] == "" {
+			// Unified hierarchy has a single "0::cgroup-path" entry without v1 controllers.
+			return nil, errors.Errorf("process %d is run in cgroup v2 unified hierarchy which is not supported (use systemd executor to resize tasks)", pid)
+		}
+		if len(fields) != 3 || fields[1] == "" {
+			continue
+		}
+		directory := filepath.Join(cgroupRoot, fields[1], fields[2])
+		if fields[2] == "/" {
+			// Root cgroup is shared by the whole system, so it is never modified.
+			directory = ""
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			cgroups[controller] = directory
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "cannot read %q", path)
+	}
+	return cgroups, nil
+}
+
+// swanCgroupPrefix is a prefix of top level cgroups created by Swan isolation decorators (see also Reaper).
+const swanCgroupPrefix = "swan"
+
+// cgroupOwnership describes who uses cgroup of the task.
+type cgroupOwnership struct {
+	directory string
+	// createdBySwan is true when cgroup is nested in top level cgroup with Swan prefix.
+	createdBySwan bool
+	// exclusive is true when cgroup contains only processes from the task process tree.
+	exclusive bool
+}
+
+// taskCgroupOwnership checks cgroup of controller which process pid belongs to.
+func taskCgroupOwnership(cgroups map[string]string, controller string, pid int) (cgroupOwnership, error) {
+	directory, err := cgroupFilePath(cgroups, controller, "")
+	if err != nil {
+		return cgroupOwnership{}, err
+	}
+	ownership := cgroupOwnership{directory: directory}
+
+	// Directory is "<cgroupRoot>/<controllers>/<top level cgroup>/...".
+	relative, err := filepath.Rel(cgroupRoot, directory)
+	if err == nil {
+		parts := strings.Split(relative, string(filepath.Separator))
+		ownership.createdBySwan = len(parts) > 1 && strings.HasPrefix(parts[1], swanCgroupPrefix)
+	}
+
+	procs, err := ioutil.ReadFile(filepath.Join(directory, "cgroup.procs"))
+	if err != nil {
+		return cgroupOwnership{}, errors.Wrapf(err, "cannot list processes of %s cgroup %q", controller, directory)
+	}
+	task := map[int]bool{pid: true}
+	for _, process := range processTree(pid) {
+		task[process.pid] = true
+	}
+	ownership.exclusive = true
+	for _, field := range strings.Fields(string(procs)) {
+		member, err := strconv.Atoi(field)
+		if err != nil {
+			return cgroupOwnership{}, errors.Wrapf(err, "cannot parse processes of %s cgroup %q", controller, directory)
+		}
+		if !task[member] {
+			ownership.exclusive = false
+			break
+		}
+	}
+	return ownership, nil
+}
+
+func cgroupFilePath(cgroups map[string]string, controller, name string) (string, error) {
+	directory, ok := cgroups[controller]
+	if !ok {
+		return "", errors.Errorf("%s controller is not available", controller)
+	}
+	if directory == "" {
+		return "", errors.Errorf("task is not run in dedicated %s cgroup", controller)
+	}
+	return filepath.Join(directory, name), nil
+}
+
+func readCgroupFile(cgroups map[string]string, controller, name string) (string, error) {
+	path, err := cgroupFilePath(cgroups, controller, name)
+	if err != nil {
+		return "", err
+	}
+	value, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %q", path)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+func writeCgroupFile(cgroups map[string]string, controller, name, value string) error {
+	path, err := cgroupFilePath(cgroups, controller, name)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, []byte(value), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot write %q to %q", value, path)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestResizeCgroups(t *testing.T) {
+	Convey("When process is run in dedicated cgroups", t, func() {
+		root, err := ioutil.TempDir("", "resize")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		oldProcRoot, oldCgroupRoot := procRoot, cgroupRoot
+		procRoot, cgroupRoot = filepath.Join(root, "proc"), filepath.Join(root, "cgroup")
+		defer func() { procRoot, cgroupRoot = oldProcRoot, oldCgroupRoot }()
+
+		writeFile := func(path, content string) {
+			So(os.MkdirAll(filepath.Dir(path), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+		}
+		readFile := func(path string) string {
+			content, err := ioutil.ReadFile(path)
+			So(err, ShouldBeNil)
+			return string(content)
+		}
+
+		writeFile(filepath.Join(procRoot, "42", "cgroup"), "4:memory:/\n3:cpu,cpuacct:/swan/be\n2:cpuset:/swan/be\n1:name=systemd:/user.slice\n")
+		writeFile(filepath.Join(cgroupRoot, "cpu,cpuacct", "swan", "be", "cpu.cfs_period_us"), "100000\n")
+		writeFile(filepath.Join(cgroupRoot, "cpu,cpuacct", "swan", "be", "cgroup.procs"), "42\n43\n")
+		writeFile(filepath.Join(cgroupRoot, "cpuset", "swan", "be", "cgroup.procs"), "42\n43\n")
+
+		Convey("CPU set and quota should be written to its cgroups", func() {
+			err := resizeCgroups(42, Resources{CPUSet: isolation.NewIntSet(2, 3), CPUQuota: 1.5})
+			So(err, ShouldBeNil)
+			So(readFile(filepath.Join(cgroupRoot, "cpuset", "swan", "be", "cpuset.cpus")), ShouldEqual, "2,3")
+			So(readFile(filepath.Join(cgroupRoot, "cpu,cpuacct", "swan", "be", "cpu.cfs_quota_us")), ShouldEqual, "150000")
+		})
+
+		Convey("Root cgroup should not be modified", func() {
+			err := resizeCgroups(42, Resources{MemoryLimit: 1024})
+			So(err, ShouldNotBeNil)
+			_, err = os.Stat(filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+
+	Convey("When process is run in cgroups which were not created by Swan", t, func() {
+		root, err := ioutil.TempDir("", "resize")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		oldProcRoot, oldCgroupRoot := procRoot, cgroupRoot
+		procRoot, cgroupRoot = filepath.Join(root, "proc"), filepath.Join(root, "cgroup")
+		defer func() { procRoot, cgroupRoot = oldProcRoot, oldCgroupRoot }()
+
+		writeFile := func(path, content string) {
+			So(os.MkdirAll(filepath.Dir(path), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+		}
+
+		writeFile(filepath.Join(procRoot, "42", "cgroup"), "3:memory:/user.slice/memcached\n2:cpuset:/user.slice\n")
+		writeFile(filepath.Join(procRoot, "43", "stat"), "43 (memcached) S 42 42 42 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 100 0 0\n")
+		writeFile(filepath.Join(cgroupRoot, "memory", "user.slice", "memcached", "cgroup.procs"), "42\n43\n")
+		writeFile(filepath.Join(cgroupRoot, "cpuset", "user.slice", "cgroup.procs"), "1\n42\n43\n")
+
+		Convey("Cgroup used only by the task process tree should be resized", func() {
+			So(resizeCgroups(42, Resources{MemoryLimit: 1024}), ShouldBeNil)
+			limit, err := ioutil.ReadFile(filepath.Join(cgroupRoot, "memory", "user.slice", "memcached", "memory.limit_in_bytes"))
+			So(err, ShouldBeNil)
+			So(string(limit), ShouldEqual, "1024")
+		})
+
+		Convey("Cgroup shared with other processes should not be modified", func() {
+			err := resizeCgroups(42, Resources{CPUSet: isolation.NewIntSet(2)})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "shared")
+			_, err = os.Stat(filepath.Join(cgroupRoot, "cpuset", "user.slice", "cpuset.cpus"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+
+	Convey("When process is run in cgroup v2 unified hierarchy", t, func() {
+		root, err := ioutil.TempDir("", "resize")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		oldProcRoot, oldCgroupRoot := procRoot, cgroupRoot
+		procRoot, cgroupRoot = filepath.Join(root, "proc"), filepath.Join(root, "cgroup")
+		defer func() { procRoot, cgroupRoot = oldProcRoot, oldCgroupRoot }()
+
+		So(os.MkdirAll(filepath.Join(procRoot, "42"), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(procRoot, "42", "cgroup"), []byte("0::/swan/be\n"), 0644), ShouldBeNil)
+
+		Convey("Resize should fail instead of being ignored", func() {
+			err := resizeCgroups(42, Resources{CPUQuota: 1})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "cgroup v2")
+		})
+	})
+}
+
+func TestSetResource(t *testing.T) {
+	Convey("When pod has Guaranteed QoS class", t, func() {
+		requirements := v1.ResourceRequirements{
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		}
+
+		Convey("Request should follow the limit", func() {
+			setResource(&requirements, v1.ResourceCPU, resource.MustParse("2"))
+			limit, request := requirements.Limits[v1.ResourceCPU], requirements.Requests[v1.ResourceCPU]
+			So(limit.String(), ShouldEqual, "2")
+			So(request.String(), ShouldEqual, "2")
+		})
+	})
+
+	Convey("When pod has Burstable QoS class", t, func() {
+		requirements := v1.ResourceRequirements{
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		}
+
+		Convey("Request should be kept when it does not exceed new limit", func() {
+			setResource(&requirements, v1.ResourceCPU, resource.MustParse("3"))
+			limit, request := requirements.Limits[v1.ResourceCPU], requirements.Requests[v1.ResourceCPU]
+			So(limit.String(), ShouldEqual, "3")
+			So(request.String(), ShouldEqual, "1")
+		})
+	})
+}
+
+type fakeServerVersion struct {
+	info version.Info
+}
+
+func (f fakeServerVersion) ServerVersion() (*version.Info, error) {
+	return &f.info, nil
+}
+
+func TestCheckPodResizeSupported(t *testing.T) {
+	Convey("In-place pod resize should be rejected by Kubernetes older than 1.33", t, func() {
+		So(checkPodResizeSupported(fakeServerVersion{version.Info{Major: "1", Minor: "7", GitVersion: "v1.7.0"}}), ShouldNotBeNil)
+		So(checkPodResizeSupported(fakeServerVersion{version.Info{GitVersion: "v0.0.0-master"}}), ShouldNotBeNil)
+		So(checkPodResizeSupported(nil), ShouldNotBeNil)
+	})
+
+	Convey("In-place pod resize should be allowed by Kubernetes 1.33 and newer", t, func() {
+		So(checkPodResizeSupported(fakeServerVersion{version.Info{Major: "1", Minor: "33", GitVersion: "v1.33.0"}}), ShouldBeNil)
+		So(checkPodResizeSupported(fakeServerVersion{version.Info{Major: "1", Minor: "34+", GitVersion: "v1.34.1-gke.1"}}), ShouldBeNil)
+	})
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	retryAttemptsFlag   = conf.NewIntFlag("retry_attempts", "Maximum number of attempts to start a task when it fails because of transient infrastructure error (e.g. port not yet free, SSH connection failure).", 3)
+	retryBackoffFlag    = conf.NewDurationFlag("retry_backoff", "Time to wait before second attempt to start a task. It is doubled after every failed attempt.", time.Second)
+	retryMaxBackoffFlag = conf.NewDurationFlag("retry_max_backoff", "Maximum time to wait between attempts to start a task.", 30*time.Second)
+	taskDeadlineFlag    = conf.NewDurationFlag("task_deadline", "Maximum lifetime of a task started with retry policy. Task is stopped and reported as timed out when it is exceeded. Disabled when 0.", 0)
+)
+
+// TimeoutError is returned when task has been stopped because it exceeded its deadline.
+type TimeoutError struct {
+	Task     string
+	Deadline time.Duration
+}
+
+// Error implements error interface.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("task %s has been stopped after exceeding deadline of %s", e.Task, e.Deadline)
+}
+
+// IsTimeout returns true when err has been caused by task exceeding its deadline.
+func IsTimeout(err error) bool {
+	_, ok := errors.Cause(err).(*TimeoutError)
+	return ok
+}
+
+// retryableError marks errors caused by transient infrastructure failures.
+type retryableError struct {
+	error
+}
+
+// Cause implements causer interface from github.com/pkg/errors.
+func (e retryableError) Cause() error {
+	return e.error
+}
+
+// Retryable marks err as transient, so starting the task might succeed when attempted again.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retryableError{err}
+}
+
+// IsRetryable returns true when err (or any error it wraps) has been marked with Retryable or is a temporary network error.
+func IsRetryable(err error) bool {
+	for err != nil {
+		switch e := err.(type) {
+		case retryableError:
+			return true
+		case net.Error:
+			if e.Temporary() || e.Timeout() {
+				return true
+			}
+		}
+		causer, ok := err.(interface {
+			Cause() error
+		})
+		if !ok {
+			return false
+		}
+		err = causer.Cause()
+	}
+	return false
+}
+
+// RetryConfig describes how tasks are retried and how long they are allowed to run.
+type RetryConfig struct {
+	// Attempts is a maximum number of attempts to start a task (1 disables retries).
+	Attempts int
+	// Backoff is a time to wait before second attempt. It is doubled after every failed attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// IsRetryable decides if failed attempt should be repeated.
+	IsRetryable func(error) bool
+	// Deadline is a maximum lifetime of started task. Task is stopped when it is exceeded and its
+	// Wait, Stop and ExitCode return TimeoutError. Disabled when 0.
+	Deadline time.Duration
+}
+
+// DefaultRetryConfig returns RetryConfig based on flags.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Attempts:    retryAttemptsFlag.Value(),
+		Backoff:     retryBackoffFlag.Value(),
+		MaxBackoff:  retryMaxBackoffFlag.Value(),
+		IsRetryable: IsRetryable,
+		Deadline:    taskDeadlineFlag.Value(),
+	}
+}
+
+// RetryExecutor is a decorator of Executor that repeats failed Execute calls according to RetryConfig
+// and enforces deadline on the tasks it starts.
+type RetryExecutor struct {
+	Executor
+	config RetryConfig
+}
+
+// NewRetryExecutor is a constructor for RetryExecutor.
+func NewRetryExecutor(executor Executor, config RetryConfig) RetryExecutor {
+	return RetryExecutor{Executor: executor, config: config}
+}
+
+// Execute implements Executor interface.
+func (re RetryExecutor) Execute(command string) (TaskHandle, error) {
+	return retry(re.config, fmt.Sprintf("executing %q on %s", command, re.Executor), func() (TaskHandle, error) {
+		return re.Executor.Execute(command)
+	})
+}
+
+// RetryLauncher is a decorator of Launcher that repeats failed Launch calls according to RetryConfig
+// and enforces deadline on the tasks it starts.
+type RetryLauncher struct {
+	Launcher
+	config RetryConfig
+}
+
+// NewRetryLauncher is a constructor for RetryLauncher.
+func NewRetryLauncher(launcher Launcher, config RetryConfig) RetryLauncher {
+	return RetryLauncher{Launcher: launcher, config: config}
+}
+
+// Launch implements Launcher interface.
+func (rl RetryLauncher) Launch() (TaskHandle, error) {
+	return retry(rl.config, fmt.Sprintf("launching %s", rl.Launcher), rl.Launcher.Launch)
+}
+
+// retry calls start until it succeeds, returns error that is not retryable or attempts are exhausted.
+func retry(config RetryConfig, description string, start func() (TaskHandle, error)) (TaskHandle, error) {
+	backoff := config.Backoff
+	for attempt := 1; ; attempt++ {
+		handle, err := start()
+		if err == nil {
+			return newDeadlineTaskHandle(handle, config.Deadline), nil
+		}
+
+		if config.IsRetryable == nil || !config.IsRetryable(err) {
+			return nil, err
+		}
+		if attempt >= config.Attempts {
+			if attempt == 1 {
+				return nil, err
+			}
+			return nil, errors.Wrapf(err, "%s failed %d times", description, attempt)
+		}
+
+		log.Warnf("Attempt %d of %d at %s failed (%s), retrying in %s", attempt, config.Attempts, description, err.Error(), backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if config.MaxBackoff > 0 && backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// deadlineTaskHandle stops the task when it exceeds deadline and reports TimeoutError afterwards.
+type deadlineTaskHandle struct {
+	TaskHandle
+	deadline time.Duration
+	mutex    sync.Mutex
+	timedOut bool
+}
+
+// newDeadlineTaskHandle returns handle unchanged when deadline is disabled.
+func newDeadlineTaskHandle(handle TaskHandle, deadline time.Duration) TaskHandle {
+	if deadline <= 0 {
+		return handle
+	}
+	deadlineHandle := &deadlineTaskHandle{TaskHandle: handle, deadline: deadline}
+	go deadlineHandle.enforce()
+	return deadlineHandle
+}
+
+func (handle *deadlineTaskHandle) enforce() {
+	terminated, err := handle.TaskHandle.Wait(handle.deadline)
+	if err != nil || terminated {
+		return
+	}
+
+	handle.mutex.Lock()
+	handle.timedOut = true
+	handle.mutex.Unlock()
+
+	log.Errorf("Task %s exceeded deadline of %s, stopping it", handle.TaskHandle, handle.deadline)
+	err = handle.TaskHandle.Stop()
+	if err != nil {
+		log.Errorf("Cannot stop task %s after deadline: %s", handle.TaskHandle, err.Error())
+	}
+}
+
+func (handle *deadlineTaskHandle) timeoutError() error {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+	if !handle.timedOut {
+		return nil
+	}
+	return &TimeoutError{Task: handle.TaskHandle.String(), Deadline: handle.deadline}
+}
+
+// Stop implements TaskHandle interface.
+func (handle *deadlineTaskHandle) Stop() error {
+	err := handle.TaskHandle.Stop()
+	if err != nil {
+		return err
+	}
+	return handle.timeoutError()
+}
+
+// Wait implements TaskHandle interface.
+func (handle *deadlineTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	terminated, err := handle.TaskHandle.Wait(timeout)
+	if err != nil || !terminated {
+		return terminated, err
+	}
+	return true, handle.timeoutError()
+}
+
+// Unwrap implements WrappedTask interface.
+func (handle *deadlineTaskHandle) Unwrap() TaskHandle {
+	return handle.TaskHandle
+}
+
+// ExitCode implements TaskHandle interface.
+func (handle *deadlineTaskHandle) ExitCode() (int, error) {
+	exitCode, err := handle.TaskHandle.ExitCode()
+	if err != nil {
+		return exitCode, err
+	}
+	return exitCode, handle.timeoutError()
+}
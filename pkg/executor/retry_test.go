@@ -0,0 +1,146 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetryLauncher(t *testing.T) {
+	config := RetryConfig{
+		Attempts:    3,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		IsRetryable: IsRetryable,
+	}
+
+	Convey("When launcher fails with transient error", t, func() {
+		handle := new(MockTaskHandle)
+		launcher := new(MockLauncher)
+		launcher.On("String").Return("memcached")
+		launcher.On("Launch").Return(nil, Retryable(errors.New("port is busy"))).Twice()
+		launcher.On("Launch").Return(handle, nil).Once()
+
+		Convey("Launch should be retried until it succeeds", func() {
+			launched, err := NewRetryLauncher(launcher, config).Launch()
+			So(err, ShouldBeNil)
+			So(launched, ShouldEqual, handle)
+			So(launcher.AssertNumberOfCalls(t, "Launch", 3), ShouldBeTrue)
+		})
+	})
+
+	Convey("When launcher keeps failing with transient error", t, func() {
+		launcher := new(MockLauncher)
+		launcher.On("String").Return("memcached")
+		launcher.On("Launch").Return(nil, Retryable(errors.New("port is busy")))
+
+		Convey("Error should be returned after all attempts and still be retryable", func() {
+			_, err := NewRetryLauncher(launcher, config).Launch()
+			So(err, ShouldNotBeNil)
+			So(IsRetryable(err), ShouldBeTrue)
+			So(launcher.AssertNumberOfCalls(t, "Launch", 3), ShouldBeTrue)
+		})
+	})
+
+	Convey("When launcher fails with permanent error", t, func() {
+		launcher := new(MockLauncher)
+		launcher.On("String").Return("memcached")
+		launcher.On("Launch").Return(nil, errors.New("binary not found"))
+
+		Convey("Launch should not be retried", func() {
+			_, err := NewRetryLauncher(launcher, config).Launch()
+			So(err, ShouldNotBeNil)
+			So(IsRetryable(err), ShouldBeFalse)
+			So(launcher.AssertNumberOfCalls(t, "Launch", 1), ShouldBeTrue)
+		})
+	})
+}
+
+func TestDeadlineTaskHandle(t *testing.T) {
+	Convey("When task exceeds its deadline", t, func() {
+		stopped := make(chan struct{})
+		handle := new(MockTaskHandle)
+		handle.On("String").Return("memcached")
+		handle.On("Wait", time.Second).Return(false, nil).Once()
+		handle.On("Stop").Return(nil).Run(func(_ mock.Arguments) { close(stopped) }).Once()
+		handle.On("Wait", time.Duration(0)).Return(true, nil)
+
+		deadlineHandle := newDeadlineTaskHandle(handle, time.Second)
+		<-stopped
+
+		Convey("It should be stopped and Wait should return timeout error", func() {
+			terminated, err := deadlineHandle.Wait(0)
+			So(terminated, ShouldBeTrue)
+			So(IsTimeout(err), ShouldBeTrue)
+			So(IsTimeout(errors.Wrap(err, "repetition failed")), ShouldBeTrue)
+		})
+	})
+
+	Convey("When deadline is disabled", t, func() {
+		handle := new(MockTaskHandle)
+
+		Convey("Handle should not be wrapped", func() {
+			So(newDeadlineTaskHandle(handle, 0), ShouldEqual, handle)
+		})
+	})
+}
+
+// probedJobTaskHandle mimics handle of Kubernetes job with readiness probe.
+type probedJobTaskHandle struct {
+	*MockTaskHandle
+}
+
+func (probedJobTaskHandle) PodAddresses() ([]string, error) {
+	return []string{"10.0.0.1", "10.0.0.2"}, nil
+}
+
+func (probedJobTaskHandle) ReadinessProbed() bool {
+	return true
+}
+
+func TestWrappedTaskCapabilities(t *testing.T) {
+	Convey("When task run in many probed pods is wrapped with deadline and service handles", t, func() {
+		handle := new(MockTaskHandle)
+		handle.On("Wait", time.Hour).Return(true, nil)
+		wrapped := NewServiceHandle(newDeadlineTaskHandle(probedJobTaskHandle{handle}, time.Hour))
+
+		Convey("Pod addresses of the task should be available", func() {
+			multiPodTask, ok := GetMultiPodTask(wrapped)
+			So(ok, ShouldBeTrue)
+			addresses, err := multiPodTask.PodAddresses()
+			So(err, ShouldBeNil)
+			So(addresses, ShouldResemble, []string{"10.0.0.1", "10.0.0.2"})
+		})
+
+		Convey("Task should be reported as listening", func() {
+			notReachable := func(string, time.Duration) bool { return false }
+			So(IsListening(wrapped, "10.0.0.1:11211", time.Second, notReachable), ShouldBeTrue)
+		})
+	})
+
+	Convey("When plain task is wrapped", t, func() {
+		wrapped := NewServiceHandle(new(MockTaskHandle))
+
+		Convey("It should not be reported as run in many pods", func() {
+			_, ok := GetMultiPodTask(wrapped)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -85,6 +85,11 @@ func (s *serviceHandle) Wait(duration time.Duration) (bool, error) {
 	return s.TaskHandle.Wait(duration)
 }
 
+// Unwrap implements WrappedTask interface.
+func (s *serviceHandle) Unwrap() TaskHandle {
+	return s.TaskHandle
+}
+
 func (s *serviceHandle) checkErrorCondition() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -99,6 +104,9 @@ func (s *serviceHandle) checkErrorCondition() error {
 		s.taskHasBeenTerminatedByUser = true
 		if s.TaskHandle.Status() == TERMINATED {
 			s.err = errors.Errorf("ServiceHandle with command %q has terminated prematurely", s.TaskHandle)
+			if evictionErr := CheckEviction(s.TaskHandle); evictionErr != nil {
+				s.err = errors.Wrapf(evictionErr, "ServiceHandle with command %q has terminated prematurely", s.TaskHandle)
+			}
 			logrus.Errorf(s.err.Error())
 			logOutput(s.TaskHandle)
 			return s.err
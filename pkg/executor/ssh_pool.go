@@ -0,0 +1,257 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	sshConnectionPoolFlag = conf.NewBoolFlag("remote_ssh_connection_pool", "Share SSH connections between remote tasks executed on the same host. "+
+		"When disabled, new SSH connection is established for every task.", true)
+	sshMaxSessionsFlag = conf.NewIntFlag("remote_ssh_max_sessions", "Maximum number of sessions multiplexed over single pooled SSH connection. "+
+		"Should not exceed MaxSessions setting of remote sshd (10 by default).", 10)
+	sshKeepAliveIntervalFlag = conf.NewDurationFlag("remote_ssh_keepalive_interval", "Interval of keep-alive requests sent over pooled SSH connections. "+
+		"Connections that do not respond are not used for new tasks.", 15*time.Second)
+	sshKeepAliveTimeoutFlag = conf.NewDurationFlag("remote_ssh_keepalive_timeout", "Maximum time to wait for reply to keep-alive request before SSH connection is considered broken.", 10*time.Second)
+)
+
+// pooledConnection is a SSH connection shared by multiple sessions.
+type pooledConnection struct {
+	client   *ssh.Client
+	key      string
+	sessions int
+	// broken connections are not used for new sessions and are closed when last session ends.
+	broken bool
+	closed chan struct{}
+}
+
+// pendingDial is a connection being established. Its session slots are handed out before dial finishes,
+// so concurrent acquires do not dial more connections than needed.
+type pendingDial struct {
+	sessions int
+	done     chan struct{}
+	// connection and err are set before done is closed.
+	connection *pooledConnection
+	err        error
+}
+
+// sshPool keeps SSH connections to remote hosts. Connections are shared by all Remote executors
+// with the same user, host and port.
+type sshPool struct {
+	mutex       sync.Mutex
+	connections map[string][]*pooledConnection
+	pending     map[string][]*pendingDial
+}
+
+func newSSHPool() *sshPool {
+	return &sshPool{connections: map[string][]*pooledConnection{}, pending: map[string][]*pendingDial{}}
+}
+
+// defaultSSHPool is used by all Remote executors.
+var defaultSSHPool = newSSHPool()
+
+// newSession returns session with PTY on a pooled connection to address.
+// When pooled connection turns out to be broken, new connection is established.
+func (pool *sshPool) newSession(address string, clientConfig *ssh.ClientConfig) (*ssh.Session, *pooledConnection, error) {
+	connection, err := pool.acquire(address, clientConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := newSessionWithPty(connection.client)
+	if err == nil {
+		return session, connection, nil
+	}
+
+	// Connection might have been dropped since last keep-alive; reconnect once.
+	log.Debugf("SSH pool: connection to %s is broken (%s), reconnecting", connection.key, err.Error())
+	pool.markBroken(connection)
+	pool.release(connection)
+
+	connection, err = pool.acquire(address, clientConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err = newSessionWithPty(connection.client)
+	if err != nil {
+		pool.markBroken(connection)
+		pool.release(connection)
+		return nil, nil, err
+	}
+	return session, connection, nil
+}
+
+// acquire returns healthy connection with free session slot or establishes new one.
+// Dialing is done without holding the pool lock, so slow or unreachable host does not block other hosts.
+func (pool *sshPool) acquire(address string, clientConfig *ssh.ClientConfig) (*pooledConnection, error) {
+	key := fmt.Sprintf("%s@%s", clientConfig.User, address)
+	pooled := sshConnectionPoolFlag.Value()
+
+	pool.mutex.Lock()
+	if pooled {
+		for _, connection := range pool.connections[key] {
+			if !connection.broken && connection.sessions < sshMaxSessionsFlag.Value() {
+				connection.sessions++
+				pool.mutex.Unlock()
+				return connection, nil
+			}
+		}
+		for _, dial := range pool.pending[key] {
+			if dial.sessions < sshMaxSessionsFlag.Value() {
+				dial.sessions++
+				pool.mutex.Unlock()
+				<-dial.done
+				return dial.connection, dial.err
+			}
+		}
+	}
+	dial := &pendingDial{sessions: 1, done: make(chan struct{})}
+	if pooled {
+		pool.pending[key] = append(pool.pending[key], dial)
+	}
+	pool.mutex.Unlock()
+
+	client, err := ssh.Dial("tcp", address, clientConfig)
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	defer close(dial.done)
+	pool.removePending(key, dial)
+
+	if err != nil {
+		dial.err = Retryable(errors.Wrapf(err, "ssh.Dial to '%s' failed", key))
+		return nil, dial.err
+	}
+	dial.connection = &pooledConnection{client: client, key: key, sessions: dial.sessions, closed: make(chan struct{})}
+
+	if pooled {
+		pool.connections[key] = append(pool.connections[key], dial.connection)
+		go pool.keepAlive(dial.connection, sshKeepAliveIntervalFlag.Value(), sshKeepAliveTimeoutFlag.Value())
+		log.Debugf("SSH pool: new connection to %s (%d connections)", key, len(pool.connections[key]))
+	} else {
+		// Connection is not shared, so it is closed as soon as session ends.
+		dial.connection.broken = true
+	}
+
+	return dial.connection, nil
+}
+
+// removePending must be called with mutex held.
+func (pool *sshPool) removePending(key string, dial *pendingDial) {
+	dials := pool.pending[key]
+	for i, pending := range dials {
+		if pending == dial {
+			pool.pending[key] = append(dials[:i], dials[i+1:]...)
+			break
+		}
+	}
+	if len(pool.pending[key]) == 0 {
+		delete(pool.pending, key)
+	}
+}
+
+// release frees session slot. Broken connections are closed when no session uses them.
+func (pool *sshPool) release(connection *pooledConnection) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	connection.sessions--
+	if connection.broken && connection.sessions == 0 {
+		pool.close(connection)
+	}
+}
+
+// markBroken removes connection from the pool, so it is not used for new sessions.
+func (pool *sshPool) markBroken(connection *pooledConnection) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	connection.broken = true
+	connections := pool.connections[connection.key]
+	for i, pooled := range connections {
+		if pooled == connection {
+			pool.connections[connection.key] = append(connections[:i], connections[i+1:]...)
+			break
+		}
+	}
+}
+
+// close must be called with mutex held.
+func (pool *sshPool) close(connection *pooledConnection) {
+	select {
+	case <-connection.closed:
+		return
+	default:
+		close(connection.closed)
+		connection.client.Close()
+	}
+}
+
+// keepAlive periodically checks connection health. Unhealthy connection is removed from the pool.
+func (pool *sshPool) keepAlive(connection *pooledConnection, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-connection.closed:
+			return
+		case <-ticker.C:
+			err := checkConnection(connection.client, timeout)
+			if err != nil {
+				log.Warnf("SSH pool: connection to %s is not responding: %s", connection.key, err.Error())
+				pool.markBroken(connection)
+				pool.mutex.Lock()
+				if connection.sessions == 0 {
+					pool.close(connection)
+				}
+				pool.mutex.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// requestSender sends global requests over SSH connection (implemented by ssh.Client).
+type requestSender interface {
+	SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error)
+}
+
+// checkConnection sends keep-alive request and waits for reply at most timeout.
+// Half-open connection never replies, so the request is abandoned when timeout passes.
+func checkConnection(client requestSender, timeout time.Duration) error {
+	reply := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		reply <- err
+	}()
+
+	select {
+	case err := <-reply:
+		if err != nil {
+			return errors.Wrap(err, "keep-alive request failed")
+		}
+		return nil
+	case <-time.After(timeout):
+		return errors.Errorf("no reply to keep-alive request within %s", timeout)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+)
+
+// blockingSender never replies to requests, like half-open connection.
+type blockingSender struct{}
+
+func (blockingSender) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	select {}
+}
+
+// startSSHServer starts SSH server accepting any client. Keep-alive requests are answered only when respond is true.
+func startSSHServer(respond bool) (address string, stop func(), err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go func() {
+					for request := range requests {
+						if respond && request.WantReply {
+							request.Reply(true, nil)
+						}
+					}
+				}()
+				for channel := range channels {
+					channel.Reject(ssh.Prohibited, "sessions are not supported")
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() { listener.Close() }, nil
+}
+
+func isClosed(channel chan struct{}) bool {
+	select {
+	case <-channel:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeAll closes all connections of the pool, so their keep-alive goroutines end.
+func closeAll(pool *sshPool) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	for _, connections := range pool.connections {
+		for _, connection := range connections {
+			pool.close(connection)
+		}
+	}
+}
+
+func TestSSHPool(t *testing.T) {
+	clientConfig := &ssh.ClientConfig{User: "swan", HostKeyCallback: ssh.InsecureIgnoreHostKey()}
+
+	Convey("When SSH server responds to keep-alive requests", t, func() {
+		address, stop, err := startSSHServer(true)
+		So(err, ShouldBeNil)
+		defer stop()
+		pool := newSSHPool()
+		defer closeAll(pool)
+
+		Convey("Connection should be shared until all session slots are used", func() {
+			var connections []*pooledConnection
+			for i := 0; i < sshMaxSessionsFlag.Value(); i++ {
+				connection, err := pool.acquire(address, clientConfig)
+				So(err, ShouldBeNil)
+				connections = append(connections, connection)
+				So(connection, ShouldEqual, connections[0])
+			}
+			So(connections[0].sessions, ShouldEqual, sshMaxSessionsFlag.Value())
+
+			another, err := pool.acquire(address, clientConfig)
+			So(err, ShouldBeNil)
+			So(another, ShouldNotEqual, connections[0])
+			So(pool.connections["swan@"+address], ShouldHaveLength, 2)
+
+			Convey("Released slot should be reused", func() {
+				pool.release(connections[0])
+				connection, err := pool.acquire(address, clientConfig)
+				So(err, ShouldBeNil)
+				So(connection, ShouldEqual, connections[0])
+			})
+		})
+
+		Convey("Concurrent acquires should share connection being established", func() {
+			connections := make(chan *pooledConnection, sshMaxSessionsFlag.Value())
+			for i := 0; i < sshMaxSessionsFlag.Value(); i++ {
+				go func() {
+					connection, err := pool.acquire(address, clientConfig)
+					if err != nil {
+						connection = nil
+					}
+					connections <- connection
+				}()
+			}
+			for i := 0; i < sshMaxSessionsFlag.Value(); i++ {
+				So(<-connections, ShouldNotBeNil)
+			}
+			So(pool.connections["swan@"+address], ShouldHaveLength, 1)
+			So(pool.connections["swan@"+address][0].sessions, ShouldEqual, sshMaxSessionsFlag.Value())
+			So(pool.pending, ShouldBeEmpty)
+		})
+
+		Convey("Host that does not complete handshake should not block acquires to other hosts", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			accepted := make(chan net.Conn, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err == nil {
+					accepted <- conn
+				}
+			}()
+			hanging := make(chan error, 1)
+			go func() {
+				_, err := pool.acquire(listener.Addr().String(), clientConfig)
+				hanging <- err
+			}()
+			conn := <-accepted
+
+			connection, err := pool.acquire(address, clientConfig)
+			So(err, ShouldBeNil)
+			defer pool.release(connection)
+
+			// Dial fails once the connection is dropped.
+			conn.Close()
+			listener.Close()
+			So(<-hanging, ShouldNotBeNil)
+			So(pool.pending, ShouldBeEmpty)
+		})
+
+		Convey("Healthy connection should pass keep-alive check", func() {
+			connection, err := pool.acquire(address, clientConfig)
+			So(err, ShouldBeNil)
+			defer pool.release(connection)
+			So(checkConnection(connection.client, time.Second), ShouldBeNil)
+		})
+
+		Convey("Broken connection should not be used for new sessions and closed when released", func() {
+			connection, err := pool.acquire(address, clientConfig)
+			So(err, ShouldBeNil)
+			pool.markBroken(connection)
+
+			another, err := pool.acquire(address, clientConfig)
+			So(err, ShouldBeNil)
+			So(another, ShouldNotEqual, connection)
+			defer pool.release(another)
+
+			pool.release(connection)
+			So(isClosed(connection.closed), ShouldBeTrue)
+		})
+	})
+
+	Convey("When SSH server does not reply to keep-alive requests", t, func() {
+		address, stop, err := startSSHServer(false)
+		So(err, ShouldBeNil)
+		defer stop()
+		pool := newSSHPool()
+
+		connection, err := pool.acquire(address, clientConfig)
+		So(err, ShouldBeNil)
+		defer connection.client.Close()
+
+		Convey("Keep-alive check should time out", func() {
+			So(checkConnection(connection.client, 100*time.Millisecond), ShouldNotBeNil)
+			So(checkConnection(blockingSender{}, 100*time.Millisecond), ShouldNotBeNil)
+		})
+
+		Convey("Keep-alive should remove connection from the pool and close it when it is not used", func() {
+			pool.release(connection)
+			done := make(chan struct{})
+			go func() {
+				pool.keepAlive(connection, 10*time.Millisecond, 100*time.Millisecond)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("keep-alive did not detect broken connection")
+			}
+			So(pool.connections["swan@"+address], ShouldBeEmpty)
+			So(isClosed(connection.closed), ShouldBeTrue)
+		})
+	})
+}
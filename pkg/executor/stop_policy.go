@@ -0,0 +1,221 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var stopGracePeriodFlag = conf.NewDurationFlag("stop_grace_period", "Time given to a task to exit after SIGTERM before it is killed with SIGKILL together with all its child processes. "+
+	"When 0, tasks are killed immediately.", 0)
+
+// StopStage tells how the task was terminated by Stop.
+type StopStage int
+
+const (
+	// NotStopped means that task has not been stopped (it is running or it terminated on its own).
+	NotStopped StopStage = iota
+	// StoppedGracefully means that task exited within grace period after SIGTERM.
+	StoppedGracefully
+	// StoppedForcefully means that task had to be killed with SIGKILL.
+	StoppedForcefully
+)
+
+// String returns user-friendly name of the stage.
+func (stage StopStage) String() string {
+	switch stage {
+	case StoppedGracefully:
+		return "SIGTERM"
+	case StoppedForcefully:
+		return "SIGKILL"
+	default:
+		return "not stopped"
+	}
+}
+
+// StopStageReporter is implemented by TaskHandles which record the stage that terminated the task.
+type StopStageReporter interface {
+	StopStage() StopStage
+}
+
+// GetStopStage returns the stage that terminated the task or NotStopped when handle does not record it.
+func GetStopStage(handle TaskHandle) StopStage {
+	for _, task := range unwrapTask(handle) {
+		if reporter, ok := task.(StopStageReporter); ok {
+			return reporter.StopStage()
+		}
+	}
+	return NotStopped
+}
+
+// StopPolicy describes how tasks are stopped: task is asked to terminate (SIGTERM) and given grace period to exit,
+// then it is killed (SIGKILL) together with all its child processes.
+type StopPolicy struct {
+	GracePeriod time.Duration
+	// KillTimeout is a time to wait for the task to exit after it has been killed.
+	KillTimeout time.Duration
+}
+
+// DefaultStopPolicy returns StopPolicy based on flags.
+func DefaultStopPolicy() StopPolicy {
+	return StopPolicy{
+		GracePeriod: stopGracePeriodFlag.Value(),
+		KillTimeout: killWaitTimeout,
+	}
+}
+
+// Stop escalates from terminate to kill. Task is given GracePeriod to exit after terminate is called
+// (terminate is skipped when GracePeriod is 0). Returns the stage that terminated the task.
+func (policy StopPolicy) Stop(task TaskControl, terminate, kill func() error) (StopStage, error) {
+	if policy.GracePeriod > 0 {
+		err := terminate()
+		if err != nil {
+			log.Debugf("Cannot terminate task gracefully, killing it: %s", err.Error())
+		} else {
+			terminated, _ := task.Wait(policy.GracePeriod)
+			if terminated {
+				return StoppedGracefully, nil
+			}
+		}
+	}
+
+	err := kill()
+	if err != nil {
+		return NotStopped, err
+	}
+	terminated, _ := task.Wait(policy.KillTimeout)
+	if !terminated {
+		return NotStopped, errors.Errorf("task has not exited %s after it was killed", policy.KillTimeout)
+	}
+	return StoppedForcefully, nil
+}
+
+// process identifies process by pid and start time, so process which reused pid of exited one is not mistaken for it.
+type process struct {
+	pid       int
+	startTime string
+}
+
+// processStat is a subset of /proc/<pid>/stat fields.
+type processStat struct {
+	state     string
+	parent    int
+	startTime string
+}
+
+func readProcessStat(pid int) (processStat, error) {
+	stat, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return processStat{}, err
+	}
+	// Format is "pid (comm) state ppid ... starttime ..."; comm can contain spaces and parentheses.
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	if len(fields) < 20 {
+		return processStat{}, errors.Errorf("malformed stat of process %d", pid)
+	}
+	parent, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return processStat{}, errors.Wrapf(err, "malformed stat of process %d", pid)
+	}
+	return processStat{state: fields[0], parent: parent, startTime: fields[19]}, nil
+}
+
+// processTree returns all descendants of the process pid.
+func processTree(pid int) []process {
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		log.Warnf("Cannot list processes: %s", err.Error())
+		return nil
+	}
+
+	children := map[int][]process{}
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := readProcessStat(child)
+		if err != nil {
+			// Process has already exited.
+			continue
+		}
+		children[stat.parent] = append(children[stat.parent], process{pid: child, startTime: stat.startTime})
+	}
+
+	var tree []process
+	queue := children[pid]
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		tree = append(tree, next)
+		queue = append(queue, children[next.pid]...)
+	}
+	return tree
+}
+
+// signalProcesses sends signal to process group pgid and to every process from the tree.
+// Processes which have already exited are ignored.
+func signalProcesses(pgid int, tree []process, signal syscall.Signal) error {
+	err := syscall.Kill(-pgid, signal)
+	if err != nil && err != syscall.ESRCH {
+		return errors.Wrapf(err, "cannot send %s to process group %d", signal, pgid)
+	}
+	for _, p := range tree {
+		if p.alive() {
+			syscall.Kill(p.pid, signal)
+		}
+	}
+	return nil
+}
+
+// alive returns true when the process is still running (it is not a zombie and its pid has not been reused).
+func (p process) alive() bool {
+	stat, err := readProcessStat(p.pid)
+	return err == nil && stat.startTime == p.startTime && stat.state != "Z"
+}
+
+// killLeftovers kills processes from the tree which are still alive and returns their number.
+// It is used to kill children which outlived the task (e.g. were re-parented to init).
+func killLeftovers(tree []process) int {
+	killed := 0
+	for _, p := range tree {
+		if p.alive() && syscall.Kill(p.pid, syscall.SIGKILL) == nil {
+			killed++
+		}
+	}
+	return killed
+}
+
+// mergeProcesses returns union of process lists.
+func mergeProcesses(a, b []process) []process {
+	seen := map[process]bool{}
+	var merged []process
+	for _, p := range append(a, b...) {
+		if !seen[p] {
+			seen[p] = true
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStopPolicy(t *testing.T) {
+	policy := StopPolicy{GracePeriod: time.Second, KillTimeout: time.Second}
+	calls := []string{}
+	terminate := func() error {
+		calls = append(calls, "terminate")
+		return nil
+	}
+	kill := func() error {
+		calls = append(calls, "kill")
+		return nil
+	}
+
+	Convey("When task exits within grace period", t, func() {
+		calls = []string{}
+		task := new(MockTaskControl)
+		task.On("Wait", time.Second).Return(true, nil)
+
+		Convey("It should be reported as stopped gracefully and not killed", func() {
+			stage, err := policy.Stop(task, terminate, kill)
+			So(err, ShouldBeNil)
+			So(stage, ShouldEqual, StoppedGracefully)
+			So(calls, ShouldResemble, []string{"terminate"})
+		})
+	})
+
+	Convey("When task ignores termination", t, func() {
+		calls = []string{}
+		task := new(MockTaskControl)
+		task.On("Wait", time.Second).Return(false, nil).Once()
+		task.On("Wait", time.Second).Return(true, nil).Once()
+
+		Convey("It should be killed", func() {
+			stage, err := policy.Stop(task, terminate, kill)
+			So(err, ShouldBeNil)
+			So(stage, ShouldEqual, StoppedForcefully)
+			So(calls, ShouldResemble, []string{"terminate", "kill"})
+		})
+	})
+
+	Convey("When task cannot be killed", t, func() {
+		calls = []string{}
+		task := new(MockTaskControl)
+		task.On("Wait", time.Second).Return(false, nil)
+
+		Convey("Error should be returned", func() {
+			stage, err := policy.Stop(task, terminate, kill)
+			So(err, ShouldNotBeNil)
+			So(stage, ShouldEqual, NotStopped)
+		})
+	})
+
+	Convey("When grace period is disabled", t, func() {
+		calls = []string{}
+		task := new(MockTaskControl)
+		task.On("Wait", time.Second).Return(true, nil)
+
+		Convey("Task should be killed immediately", func() {
+			stage, err := StopPolicy{KillTimeout: time.Second}.Stop(task, terminate, func() error {
+				calls = append(calls, "kill")
+				return errors.New("no such process")
+			})
+			So(err, ShouldNotBeNil)
+			So(stage, ShouldEqual, NotStopped)
+			So(calls, ShouldResemble, []string{"kill"})
+		})
+	})
+}
+
+func TestProcessTree(t *testing.T) {
+	Convey("When process has children and grandchildren", t, func() {
+		root, err := ioutil.TempDir("", "proc")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		oldProcRoot := procRoot
+		procRoot = root
+		defer func() { procRoot = oldProcRoot }()
+
+		writeStat := func(pid, parent int, comm string) {
+			So(os.MkdirAll(filepath.Join(root, fmt.Sprint(pid)), 0755), ShouldBeNil)
+			stat := fmt.Sprintf("%d (%s) S %d %d 0 0 -1 4194304 0 0 0 0 0 0 0 0 20 0 1 0 %d 0 0", pid, comm, parent, pid, 1000+pid)
+			So(ioutil.WriteFile(filepath.Join(root, fmt.Sprint(pid), "stat"), []byte(stat), 0644), ShouldBeNil)
+		}
+		writeStat(10, 1, "sh")
+		writeStat(11, 10, "xargs")
+		writeStat(12, 11, "stress) (ng")
+		writeStat(20, 1, "other")
+
+		Convey("All descendants should be found", func() {
+			tree := processTree(10)
+			So(tree, ShouldResemble, []process{{pid: 11, startTime: "1011"}, {pid: 12, startTime: "1012"}})
+		})
+
+		Convey("Process with reused pid should not be considered alive", func() {
+			So(process{pid: 11, startTime: "1011"}.alive(), ShouldBeTrue)
+			So(process{pid: 11, startTime: "42"}.alive(), ShouldBeFalse)
+		})
+	})
+}
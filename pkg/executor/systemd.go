@@ -0,0 +1,485 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/utils/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var systemdStopTimeoutFlag = conf.NewDurationFlag("systemd_stop_timeout", "Time given to systemd to stop transient unit when task is stopped.", 10*time.Second)
+
+// SystemdConfig describes transient service unit started by Systemd executor.
+type SystemdConfig struct {
+	// UnitPrefix is a prefix of random generated unit name.
+	UnitPrefix string
+	// CPUs limits CPUs available to the unit (CPUAffinity and AllowedCPUs). Tasksets and cpusets among Decorators are added to it.
+	CPUs isolation.IntSet
+	// CPUWeight is a relative CPU share of the unit (1-10000). Taken from CPU shares decorator or default when 0.
+	CPUWeight int
+	// MemoryMax in bytes. Lowered by memory size decorator, not limited when 0.
+	MemoryMax int64
+	// IOWeight is a relative IO share of the unit (1-10000). Default when 0.
+	IOWeight int
+	// Properties are additional unit properties, e.g. "IOReadBandwidthMax=/dev/sda 10M".
+	Properties []string
+	// Decorators are applied to the command run by the unit. Tasksets, cpusets, CPU shares and memory size
+	// decorators are replaced by unit properties, as moving the task to other cgroups would take it out of the unit.
+	Decorators isolation.Decorators
+}
+
+// DefaultSystemdConfig returns SystemdConfig without resource limits.
+func DefaultSystemdConfig() SystemdConfig {
+	return SystemdConfig{
+		UnitPrefix: "swan",
+		CPUs:       isolation.NewIntSet(),
+		Decorators: isolation.Decorators{},
+	}
+}
+
+// Systemd executor runs every task as a transient systemd service (systemd-run).
+// Systemd keeps the whole process tree in a dedicated cgroup, so stopping the unit reliably
+// kills all the processes spawned by the task. State and exit code of the task are taken from the unit.
+// Requires systemd 235 or newer (--pipe option) and 244 or newer when CPUs are limited (AllowedCPUs property).
+type Systemd struct {
+	config SystemdConfig
+}
+
+// NewSystemd returns Systemd executor.
+func NewSystemd(config SystemdConfig) Executor {
+	return Systemd{config: config}
+}
+
+// String returns user-friendly name of executor.
+func (systemd Systemd) String() string {
+	return "Systemd Executor"
+}
+
+// Execute runs the command in a new transient unit.
+func (systemd Systemd) Execute(command string) (TaskHandle, error) {
+	unitName := fmt.Sprintf("%s-%s.service", systemd.config.UnitPrefix, uuid.New()[:8])
+	systemdCommand := systemd.runCommand(unitName, command)
+	if strings.Contains(systemdCommand, "AllowedCPUs=") || strings.Contains(systemdCommand, "AllowedMemoryNodes=") {
+		err := requireSystemdVersion(allowedCPUsSystemdVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot limit CPUs of unit %q", unitName)
+		}
+	}
+
+	log.Debugf("Systemd Executor: starting %q in unit %q", command, unitName)
+	handle, err := NewLocal().Execute(systemdCommand)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot start unit %q", unitName)
+	}
+	recordArtifact(recordedArtifact{Kind: UnitArtifact, Host: localHost, Name: unitName})
+
+	taskHandle := &systemdTaskHandle{
+		TaskHandle: handle,
+		unitName:   unitName,
+		command:    command,
+		exitCode:   -1,
+		exited:     make(chan struct{}),
+	}
+	go taskHandle.watch()
+	RegisterTaskHandle(taskHandle)
+	return taskHandle, nil
+}
+
+// runCommand builds systemd-run command line. systemd-run connects stdout and stderr of the unit to the executor
+// output files. Unit remains active after its main process exits, so its exit status can be read before it is stopped.
+func (systemd Systemd) runCommand(unitName, command string) string {
+	arguments := []string{"systemd-run", "--quiet", "--pipe", "--unit", unitName, "--property", "RemainAfterExit=yes"}
+
+	var decorators isolation.Decorators
+	cpus := isolation.NewIntSet().Union(systemd.config.CPUs)
+	mems := isolation.NewIntSet()
+	cpuWeight := systemd.config.CPUWeight
+	memoryMax := systemd.config.MemoryMax
+	for _, decorator := range systemd.config.Decorators.Flatten() {
+		switch decorator := decorator.(type) {
+		case isolation.Taskset:
+			cpus = cpus.Union(decorator.CPUList)
+		case cpusetDecorator:
+			cpus = cpus.Union(decorator.Cpus())
+			mems = mems.Union(decorator.Mems())
+		case *isolation.CPUShares:
+			if cpuWeight == 0 {
+				cpuWeight = cpuSharesToWeight(decorator.Shares())
+			}
+		case *isolation.MemorySize:
+			if size := int64(decorator.Size()); size > 0 && (memoryMax == 0 || size < memoryMax) {
+				memoryMax = size
+			}
+		default:
+			decorators = append(decorators, decorator)
+		}
+	}
+
+	var properties []string
+	if !cpus.Empty() {
+		cpuList := strings.Replace(cpus.AsRangeString(), ",", " ", -1)
+		properties = append(properties, "CPUAffinity="+cpuList, "AllowedCPUs="+cpuList)
+	}
+	if !mems.Empty() {
+		properties = append(properties, "AllowedMemoryNodes="+strings.Replace(mems.AsRangeString(), ",", " ", -1))
+	}
+	if cpuWeight > 0 {
+		properties = append(properties, fmt.Sprintf("CPUWeight=%d", cpuWeight))
+	}
+	if memoryMax > 0 {
+		properties = append(properties, fmt.Sprintf("MemoryMax=%d", memoryMax))
+	}
+	if systemd.config.IOWeight > 0 {
+		properties = append(properties, fmt.Sprintf("IOWeight=%d", systemd.config.IOWeight))
+	}
+	properties = append(properties, systemd.config.Properties...)
+	for _, property := range properties {
+		arguments = append(arguments, "--property", shellQuote(property))
+	}
+
+	arguments = append(arguments, "/bin/sh", "-c", shellQuote(decorators.Decorate(command)))
+	return strings.Join(arguments, " ")
+}
+
+// cpusetDecorator is implemented by cpuset cgroups (see cgroup.CPUSet, which cannot be imported here).
+type cpusetDecorator interface {
+	isolation.Decorator
+	Cpus() isolation.IntSet
+	Mems() isolation.IntSet
+}
+
+// cpuSharesToWeight converts cgroup v1 cpu.shares (1024 by default) to systemd CPUWeight (100 by default).
+func cpuSharesToWeight(shares int) int {
+	weight := shares * 100 / 1024
+	if weight < 1 {
+		return 1
+	}
+	if weight > 10000 {
+		return 10000
+	}
+	return weight
+}
+
+const (
+	// allowedCPUsSystemdVersion is the first systemd version supporting AllowedCPUs and AllowedMemoryNodes properties.
+	allowedCPUsSystemdVersion = 244
+	// unitPollInterval is how often state of the unit is checked.
+	unitPollInterval = 100 * time.Millisecond
+)
+
+var (
+	systemdVersionOnce sync.Once
+	systemdVersion     int
+	systemdVersionErr  error
+)
+
+// requireSystemdVersion returns error when local systemd is older than minimal version.
+func requireSystemdVersion(minimal int) error {
+	systemdVersionOnce.Do(func() {
+		output, err := exec.Command("systemctl", "--version").CombinedOutput()
+		if err != nil {
+			systemdVersionErr = errors.Wrapf(err, "cannot get systemd version: %s", strings.TrimSpace(string(output)))
+			return
+		}
+		systemdVersion, systemdVersionErr = parseSystemdVersion(string(output))
+	})
+	if systemdVersionErr != nil {
+		return systemdVersionErr
+	}
+	if systemdVersion < minimal {
+		return errors.Errorf("systemd %d or newer is required, found %d", minimal, systemdVersion)
+	}
+	return nil
+}
+
+// parseSystemdVersion parses output of "systemctl --version", e.g. "systemd 245 (245.4-4ubuntu3)".
+func parseSystemdVersion(output string) (int, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 2 || fields[0] != "systemd" {
+		return 0, errors.Errorf("unexpected systemd version %q", strings.TrimSpace(output))
+	}
+	version, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, errors.Wrapf(err, "unexpected systemd version %q", fields[1])
+	}
+	return version, nil
+}
+
+// unitState is a subset of unit properties returned by "systemctl show".
+type unitState struct {
+	LoadState   string
+	ActiveState string
+	SubState    string
+	// ExecMainStatus is exit code of main process or number of signal which killed it.
+	ExecMainStatus int
+	// ExecMainCode is "exited" when main process exited or "killed" when it was killed by signal.
+	ExecMainCode string
+}
+
+// mainExited returns true when main process of loaded unit is not running anymore. Unit remains active
+// after successful exit (RemainAfterExit) and fails otherwise. Inactive unit is either not started yet or stopped.
+func (state unitState) mainExited(started bool) bool {
+	return state.ActiveState == "failed" || (state.ActiveState == "active" && state.SubState == "exited") ||
+		(started && state.ActiveState == "inactive")
+}
+
+// exitCode returns exit code of main process. Process killed by signal is reported like by shell (128 + signal).
+func (state unitState) exitCode() int {
+	if state.ExecMainCode == "killed" || state.ExecMainCode == "dumped" {
+		return 128 + state.ExecMainStatus
+	}
+	return state.ExecMainStatus
+}
+
+// parseUnitState parses "systemctl show" output (one property=value per line).
+func parseUnitState(output string) (unitState, error) {
+	var state unitState
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "LoadState":
+			state.LoadState = parts[1]
+		case "ActiveState":
+			state.ActiveState = parts[1]
+		case "SubState":
+			state.SubState = parts[1]
+		case "ExecMainCode":
+			// Code is numeric for old systemd versions.
+			state.ExecMainCode = execMainCodes[parts[1]]
+			if state.ExecMainCode == "" {
+				state.ExecMainCode = parts[1]
+			}
+		case "ExecMainStatus":
+			status, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return unitState{}, errors.Wrapf(err, "malformed ExecMainStatus %q", parts[1])
+			}
+			state.ExecMainStatus = status
+		}
+	}
+	if state.LoadState == "" {
+		return unitState{}, errors.Errorf("unit properties not found in %q", output)
+	}
+	return state, nil
+}
+
+// execMainCodes maps numeric si_code values (CLD_*) to their names.
+var execMainCodes = map[string]string{"1": "exited", "2": "killed", "3": "dumped"}
+
+// systemdTaskHandle follows the transient unit. Embedded handle of systemd-run process provides output files.
+type systemdTaskHandle struct {
+	TaskHandle
+	unitName string
+	command  string
+
+	// exited is closed when main process of the unit exits and the unit is stopped.
+	exited   chan struct{}
+	exitCode int
+	watchErr error
+
+	mutex     sync.Mutex
+	stopStage StopStage
+}
+
+// watch polls the unit until its main process exits, records exit code and stops the unit,
+// so it is unloaded and systemd-run flushes the output and exits.
+func (handle *systemdTaskHandle) watch() {
+	defer close(handle.exited)
+	ticker := time.NewTicker(unitPollInterval)
+	defer ticker.Stop()
+
+	started := false
+	for range ticker.C {
+		state, err := handle.show()
+		if err != nil {
+			if handle.TaskHandle.Status() == TERMINATED {
+				// systemd-run has exited without starting the unit.
+				handle.exitCode, handle.watchErr = handle.TaskHandle.ExitCode()
+				return
+			}
+			log.Debugf("Systemd Executor: cannot check unit %q: %s", handle.unitName, err.Error())
+			continue
+		}
+		if state.LoadState == "not-found" {
+			if handle.TaskHandle.Status() != TERMINATED {
+				// Unit is not loaded yet.
+				continue
+			}
+			// systemd-run has exited without starting the unit.
+			handle.exitCode, handle.watchErr = handle.TaskHandle.ExitCode()
+			return
+		}
+		if !state.mainExited(started) {
+			started = started || state.ActiveState != "inactive"
+			continue
+		}
+
+		handle.exitCode = state.exitCode()
+		log.Debugf("Systemd Executor: main process of unit %q exited with code %d", handle.unitName, handle.exitCode)
+		err = handle.release()
+		if err != nil {
+			log.Warnf("Systemd Executor: %s", err.Error())
+		}
+		return
+	}
+}
+
+// release stops the unit (resetting it when it failed) and waits for systemd-run to exit.
+func (handle *systemdTaskHandle) release() error {
+	if err := handle.systemctl("stop"); err != nil {
+		return err
+	}
+	// Failed units stay loaded until they are reset; unit may have been already unloaded.
+	exec.Command("systemctl", "reset-failed", handle.unitName).Run()
+
+	terminated, _ := handle.TaskHandle.Wait(systemdStopTimeoutFlag.Value())
+	if !terminated {
+		return errors.Errorf("systemd-run for unit %q has not exited after unit was stopped", handle.unitName)
+	}
+	return nil
+}
+
+func (handle *systemdTaskHandle) show() (unitState, error) {
+	output, err := exec.Command("systemctl", "show", "--property", "LoadState,ActiveState,SubState,ExecMainCode,ExecMainStatus", handle.unitName).CombinedOutput()
+	if err != nil {
+		return unitState{}, errors.Wrapf(err, "cannot show unit %q: %s", handle.unitName, strings.TrimSpace(string(output)))
+	}
+	return parseUnitState(string(output))
+}
+
+// Status returns RUNNING until main process of the unit exits.
+func (handle *systemdTaskHandle) Status() TaskState {
+	select {
+	case <-handle.exited:
+		return TERMINATED
+	default:
+		return RUNNING
+	}
+}
+
+// Wait waits for main process of the unit to exit.
+func (handle *systemdTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	select {
+	case <-handle.exited:
+		return true, nil
+	case <-getTimeoutChan(timeout):
+		return false, nil
+	}
+}
+
+// ExitCode returns exit code of main process of the unit.
+func (handle *systemdTaskHandle) ExitCode() (int, error) {
+	if handle.Status() != TERMINATED {
+		return -1, errors.Errorf("unit %q is not terminated", handle.unitName)
+	}
+	return handle.exitCode, handle.watchErr
+}
+
+// Stop sends SIGTERM to all processes of the unit, kills them when unit does not stop within grace period
+// and waits for the unit to be stopped.
+func (handle *systemdTaskHandle) Stop() error {
+	if handle.Status() == TERMINATED {
+		return nil
+	}
+
+	policy := DefaultStopPolicy()
+	policy.KillTimeout = systemdStopTimeoutFlag.Value()
+	stage, err := policy.Stop(handle,
+		func() error { return handle.systemctl("kill", "--signal=SIGTERM") },
+		func() error { return handle.systemctl("kill", "--signal=SIGKILL") })
+	if err != nil {
+		log.Warnf("Systemd Executor: cannot kill unit %q, stopping it: %s", handle.unitName, err.Error())
+		// Stopping the unit kills all its processes, so it is not left running.
+		if err := handle.systemctl("stop"); err != nil {
+			return err
+		}
+		if terminated, _ := handle.Wait(systemdStopTimeoutFlag.Value()); !terminated {
+			return errors.Errorf("unit %q has not terminated after it was stopped", handle.unitName)
+		}
+		stage = StoppedForcefully
+	}
+
+	handle.mutex.Lock()
+	handle.stopStage = stage
+	handle.mutex.Unlock()
+	return nil
+}
+
+// StopStage implements StopStageReporter interface.
+func (handle *systemdTaskHandle) StopStage() StopStage {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+	return handle.stopStage
+}
+
+// Pause freezes all processes of the unit (requires systemd 246 or newer).
+func (handle *systemdTaskHandle) Pause() error {
+	return handle.systemctl("freeze")
+}
+
+// Resume thaws all processes of the unit.
+func (handle *systemdTaskHandle) Resume() error {
+	return handle.systemctl("thaw")
+}
+
+func (handle *systemdTaskHandle) systemctl(command string, options ...string) error {
+	arguments := append([]string{command}, options...)
+	output, err := exec.Command("systemctl", append(arguments, handle.unitName)...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "cannot %s unit %q: %s", command, handle.unitName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Resize implements Resizable interface by changing properties of the running unit.
+func (handle *systemdTaskHandle) Resize(resources Resources) error {
+	arguments := []string{"set-property", "--runtime", handle.unitName}
+	if !resources.CPUSet.Empty() {
+		if err := requireSystemdVersion(allowedCPUsSystemdVersion); err != nil {
+			return errors.Wrapf(err, "cannot change CPUs of unit %q", handle.unitName)
+		}
+		arguments = append(arguments, "AllowedCPUs="+strings.Replace(resources.CPUSet.AsRangeString(), ",", " ", -1))
+	}
+	if resources.CPUQuota > 0 {
+		arguments = append(arguments, fmt.Sprintf("CPUQuota=%d%%", int(resources.CPUQuota*100)))
+	}
+	if resources.MemoryLimit > 0 {
+		arguments = append(arguments, fmt.Sprintf("MemoryMax=%d", resources.MemoryLimit))
+	}
+
+	output, err := exec.Command("systemctl", arguments...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "cannot change properties of unit %q: %s", handle.unitName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// String returns user-friendly name of the task.
+func (handle *systemdTaskHandle) String() string {
+	return fmt.Sprintf("Systemd unit %q running %q", handle.unitName, handle.command)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type prefixDecorator string
+
+func (prefix prefixDecorator) Decorate(command string) string {
+	return string(prefix) + " " + command
+}
+
+// cgroupCPUSet mimics cgroup.CPUSet, which moves the task to cpuset cgroup with cgexec.
+type cgroupCPUSet struct{}
+
+func (cgroupCPUSet) Decorate(command string) string {
+	return "cgexec -g cpuset:swan " + command
+}
+
+func (cgroupCPUSet) Cpus() isolation.IntSet {
+	return isolation.NewIntSet(5)
+}
+
+func (cgroupCPUSet) Mems() isolation.IntSet {
+	return isolation.NewIntSet(0)
+}
+
+func TestSystemdRunCommand(t *testing.T) {
+	Convey("When using Systemd executor without limits", t, func() {
+		systemd := NewSystemd(DefaultSystemdConfig()).(Systemd)
+
+		Convey("Command should be run in transient service", func() {
+			So(systemd.runCommand("swan-1.service", "memcached"), ShouldEqual,
+				"systemd-run --quiet --pipe --unit swan-1.service --property RemainAfterExit=yes /bin/sh -c 'memcached'")
+		})
+	})
+
+	Convey("When using Systemd executor with limits and decorators", t, func() {
+		config := DefaultSystemdConfig()
+		config.CPUWeight = 100
+		config.MemoryMax = 1024
+		config.IOWeight = 10
+		config.Properties = []string{"IOReadBandwidthMax=/dev/sda 10M"}
+		config.Decorators = isolation.Decorators{isolation.Taskset{CPUList: isolation.NewIntSet(1, 2)}, prefixDecorator("nice")}
+		systemd := NewSystemd(config).(Systemd)
+
+		Convey("Tasksets should be replaced by CPU properties and other decorators should be applied", func() {
+			So(systemd.runCommand("swan-1.service", "memcached"), ShouldEqual,
+				"systemd-run --quiet --pipe --unit swan-1.service --property RemainAfterExit=yes "+
+					"--property 'CPUAffinity=1 2' --property 'AllowedCPUs=1 2' --property 'CPUWeight=100' --property 'MemoryMax=1024' "+
+					"--property 'IOWeight=10' --property 'IOReadBandwidthMax=/dev/sda 10M' /bin/sh -c 'nice memcached'")
+		})
+	})
+
+	Convey("When using Systemd executor with nested cgroup decorators", t, func() {
+		config := DefaultSystemdConfig()
+		config.Decorators = isolation.Decorators{
+			isolation.Decorators{isolation.Taskset{CPUList: isolation.NewIntSet(3)}, cgroupCPUSet{}},
+			isolation.NewCPUShares("swan", 2048),
+			isolation.NewMemorySize("swan", 512),
+			prefixDecorator("nice"),
+		}
+		systemd := NewSystemd(config).(Systemd)
+
+		Convey("Cgroup decorators should be replaced by unit properties", func() {
+			So(systemd.runCommand("swan-1.service", "memcached"), ShouldEqual,
+				"systemd-run --quiet --pipe --unit swan-1.service --property RemainAfterExit=yes "+
+					"--property 'CPUAffinity=3 5' --property 'AllowedCPUs=3 5' --property 'AllowedMemoryNodes=0' "+
+					"--property 'CPUWeight=200' --property 'MemoryMax=512' /bin/sh -c 'nice memcached'")
+		})
+	})
+}
+
+func TestSystemdUnitState(t *testing.T) {
+	Convey("When unit main process exited successfully", t, func() {
+		state, err := parseUnitState("LoadState=loaded\nActiveState=active\nSubState=exited\nExecMainCode=1\nExecMainStatus=0\n")
+		So(err, ShouldBeNil)
+
+		Convey("Unit should be reported as exited with code 0", func() {
+			So(state.mainExited(true), ShouldBeTrue)
+			So(state.exitCode(), ShouldEqual, 0)
+		})
+	})
+
+	Convey("When unit main process was killed", t, func() {
+		state, err := parseUnitState("LoadState=loaded\nActiveState=failed\nSubState=failed\nExecMainCode=killed\nExecMainStatus=9\n")
+		So(err, ShouldBeNil)
+
+		Convey("Exit code should be reported like by shell", func() {
+			So(state.mainExited(true), ShouldBeTrue)
+			So(state.exitCode(), ShouldEqual, 137)
+		})
+	})
+
+	Convey("When unit is running or not started yet", t, func() {
+		running, err := parseUnitState("LoadState=loaded\nActiveState=active\nSubState=running\nExecMainCode=0\nExecMainStatus=0\n")
+		So(err, ShouldBeNil)
+		inactive, err := parseUnitState("LoadState=loaded\nActiveState=inactive\nSubState=dead\nExecMainCode=0\nExecMainStatus=0\n")
+		So(err, ShouldBeNil)
+
+		Convey("Main process should not be reported as exited", func() {
+			So(running.mainExited(true), ShouldBeFalse)
+			So(inactive.mainExited(false), ShouldBeFalse)
+			So(inactive.mainExited(true), ShouldBeTrue)
+		})
+	})
+
+	Convey("When output does not contain unit properties", t, func() {
+		_, err := parseUnitState("")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSystemdVersion(t *testing.T) {
+	Convey("Systemd version should be parsed", t, func() {
+		version, err := parseSystemdVersion("systemd 245 (245.4-4ubuntu3)\n+PAM +AUDIT +SELINUX")
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 245)
+
+		_, err = parseSystemdVersion("unknown")
+		So(err, ShouldNotBeNil)
+	})
+}
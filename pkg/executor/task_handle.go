@@ -64,10 +64,35 @@ type TaskControl interface {
 	// Returns `terminated` true when task terminates.
 	// Returns error if something wrong has happen during task execution.
 	Wait(timeout time.Duration) (terminated bool, err error)
+	// Pause suspends all processes of the task without terminating it.
+	// Returns error if task cannot be suspended.
+	Pause() error
+	// Resume continues execution of paused task.
+	Resume() error
 	// EraseOutput deletes the directory where output files resides.
 	EraseOutput() error
 }
 
+// WrappedTask is implemented by TaskHandles which decorate another task (e.g. deadline or service handles).
+// Optional capabilities of the task (e.g. MultiPodTask or Resizable) are looked up through all the wrappers.
+type WrappedTask interface {
+	// Unwrap returns the decorated task.
+	Unwrap() TaskHandle
+}
+
+// unwrapTask returns handle followed by all the tasks it wraps.
+func unwrapTask(handle TaskHandle) []TaskHandle {
+	handles := []TaskHandle{handle}
+	for {
+		wrapped, ok := handle.(WrappedTask)
+		if !ok {
+			return handles
+		}
+		handle = wrapped.Unwrap()
+		handles = append(handles, handle)
+	}
+}
+
 // StopAndEraseOutput run Stop and EraseOutput on TaskHandle and add errors to errorCollection
 func StopAndEraseOutput(handle TaskHandle) (errorCollection errcollection.ErrorCollection) {
 	if handle != nil {
@@ -0,0 +1,540 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/intelsdi-x/swan/pkg/utils/netutil"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// readinessPollInterval is a time between subsequent checks of task output.
+const readinessPollInterval = 100 * time.Millisecond
+
+// ReadinessCheck blocks until launched task is ready to be used by tasks depending on it.
+// Error is returned when task will never become ready.
+type ReadinessCheck func(handle TaskHandle) error
+
+// ListeningOn returns ReadinessCheck that passes when address accepts TCP connections.
+func ListeningOn(address string, timeout time.Duration) ReadinessCheck {
+	return func(handle TaskHandle) error {
+		if !netutil.IsListening(address, timeout) {
+			return errors.Errorf("%s is not listening on %s after %s", handle, address, timeout)
+		}
+		return nil
+	}
+}
+
+// OutputContains returns ReadinessCheck that passes when stdout or stderr of the task contains text.
+// Check fails when task terminates before writing the text.
+func OutputContains(text string, timeout time.Duration) ReadinessCheck {
+	return func(handle TaskHandle) error {
+		timeoutChannel := getTimeoutChan(timeout)
+		for {
+			// Status is checked before the output, so the text written just before termination is not missed.
+			terminated := handle.Status() == TERMINATED
+			found, err := outputContains(handle, text)
+			if err != nil || found {
+				return err
+			}
+			if terminated {
+				return errors.Errorf("%s terminated before writing %q", handle, text)
+			}
+
+			select {
+			case <-timeoutChannel:
+				return errors.Errorf("%s has not written %q within %s", handle, text, timeout)
+			case <-time.After(readinessPollInterval):
+			}
+		}
+	}
+}
+
+func outputContains(handle TaskHandle, text string) (bool, error) {
+	for _, outputFile := range []func() (*os.File, error){handle.StdoutFile, handle.StderrFile} {
+		file, err := outputFile()
+		if err != nil {
+			return false, err
+		}
+		output, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return false, errors.Wrapf(err, "cannot read output of %s", handle)
+		}
+		if strings.Contains(string(output), text) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ExitedSuccessfully returns ReadinessCheck that passes when task terminates with exit code 0.
+// Task is waited for infinitely when timeout is 0.
+func ExitedSuccessfully(timeout time.Duration) ReadinessCheck {
+	return func(handle TaskHandle) error {
+		terminated, err := handle.Wait(timeout)
+		if err != nil {
+			return err
+		}
+		if !terminated {
+			return errors.Errorf("%s has not terminated within %s", handle, timeout)
+		}
+		return checkExitCode(handle)
+	}
+}
+
+func checkExitCode(handle TaskHandle) error {
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		logOutput(handle)
+		return errors.Errorf("%s exited with code %d", handle, exitCode)
+	}
+	return nil
+}
+
+// WorkflowTask is a single task of Workflow.
+type WorkflowTask struct {
+	// Name identifies the task in DependsOn of other tasks.
+	Name     string
+	Launcher Launcher
+	// DependsOn are names of tasks that need to be ready before this task is launched.
+	DependsOn []string
+	// Service tasks run until the workflow is stopped; service terminating on its own fails the workflow.
+	// Other tasks are jobs, which are expected to exit with code 0.
+	Service bool
+	// Ready blocks until the task is ready for its dependents. When nil, services and jobs without dependents
+	// are ready as soon as they are launched and jobs with dependents when they exit successfully.
+	Ready ReadinessCheck
+}
+
+// Workflow is a Launcher starting tasks that depend on each other, e.g.
+// populate database -> start server -> warm up -> load.
+// Independent tasks are launched concurrently. When any task cannot be launched or does not become ready,
+// already launched tasks are stopped and error is returned.
+type Workflow struct {
+	name string
+	// tasks are sorted topologically.
+	tasks []WorkflowTask
+	// dependents tells which tasks other tasks depend on.
+	dependents map[string]bool
+}
+
+// NewWorkflow validates dependencies of tasks and returns Workflow. Tasks can be given in any order.
+func NewWorkflow(name string, tasks ...WorkflowTask) (*Workflow, error) {
+	if len(tasks) == 0 {
+		return nil, errors.Errorf("workflow %q has no tasks", name)
+	}
+	declared := map[string]bool{}
+	for _, task := range tasks {
+		if task.Name == "" {
+			return nil, errors.Errorf("workflow %q: task without name", name)
+		}
+		if task.Launcher == nil {
+			return nil, errors.Errorf("workflow %q: task %q has no launcher", name, task.Name)
+		}
+		if declared[task.Name] {
+			return nil, errors.Errorf("workflow %q: task %q is declared twice", name, task.Name)
+		}
+		declared[task.Name] = true
+	}
+	dependents := map[string]bool{}
+	for _, task := range tasks {
+		for _, dependency := range task.DependsOn {
+			if !declared[dependency] {
+				return nil, errors.Errorf("workflow %q: task %q depends on unknown task %q", name, task.Name, dependency)
+			}
+			dependents[dependency] = true
+		}
+	}
+
+	// Sort tasks topologically keeping the declaration order of independent tasks.
+	var sorted []WorkflowTask
+	placed := map[string]bool{}
+	for len(sorted) < len(tasks) {
+		progress := false
+		for _, task := range tasks {
+			if placed[task.Name] || !allPlaced(task.DependsOn, placed) {
+				continue
+			}
+			sorted = append(sorted, task)
+			placed[task.Name] = true
+			progress = true
+		}
+		if !progress {
+			var cyclic []string
+			for _, task := range tasks {
+				if !placed[task.Name] {
+					cyclic = append(cyclic, task.Name)
+				}
+			}
+			return nil, errors.Errorf("workflow %q: dependency cycle between tasks %s", name, strings.Join(cyclic, ", "))
+		}
+	}
+
+	return &Workflow{name: name, tasks: sorted, dependents: dependents}, nil
+}
+
+func allPlaced(names []string, placed map[string]bool) bool {
+	for _, name := range names {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns user-friendly name of the workflow.
+func (w *Workflow) String() string {
+	return fmt.Sprintf("Workflow %q", w.name)
+}
+
+// Launch implements Launcher interface. It returns when all the tasks are launched and ready
+// (so jobs that other tasks depend on have already finished).
+func (w *Workflow) Launch() (TaskHandle, error) {
+	handle := &WorkflowTaskHandle{
+		workflow: w,
+		finished: make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+
+	ready := map[string]chan struct{}{}
+	for _, task := range w.tasks {
+		ready[task.Name] = make(chan struct{})
+	}
+
+	var launchErr error
+	var failOnce sync.Once
+	failed := make(chan struct{})
+	fail := func(err error) {
+		failOnce.Do(func() {
+			launchErr = err
+			close(failed)
+		})
+	}
+
+	var wg sync.WaitGroup
+	for _, task := range w.tasks {
+		wg.Add(1)
+		go func(task WorkflowTask) {
+			defer wg.Done()
+			for _, dependency := range task.DependsOn {
+				select {
+				case <-ready[dependency]:
+				case <-failed:
+					return
+				}
+			}
+			err := handle.launch(task)
+			if err != nil {
+				fail(err)
+				return
+			}
+			close(ready[task.Name])
+		}(task)
+	}
+
+	launched := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(launched)
+	}()
+
+	select {
+	case <-launched:
+		go handle.watch()
+		return handle, nil
+	case <-failed:
+		// Stopping launched tasks interrupts readiness checks still waiting for them.
+		var errCollection errcollection.ErrorCollection
+		errCollection.Add(launchErr)
+		errCollection.Add(handle.teardown())
+		<-launched
+		return nil, errCollection.GetErrIfAny()
+	}
+}
+
+// workflowTaskHandle is a launched task of the workflow.
+type workflowTaskHandle struct {
+	WorkflowTask
+	handle TaskHandle
+}
+
+// WorkflowTaskHandle is a TaskHandle of all the tasks launched by Workflow.
+// - Wait waits for all the jobs to finish and then stops services (workflow whose jobs all have dependents runs until stopped).
+// - Stop stops tasks in reverse order of launching, so services are stopped after their clients.
+// - StdoutFile, StderrFile and Address are taken from the last task of the workflow.
+// Premature termination of a service or failure of a job stops the workflow and is returned by Wait and Stop.
+type WorkflowTaskHandle struct {
+	workflow *Workflow
+
+	mutex    sync.Mutex
+	launched []workflowTaskHandle
+	stopping bool
+
+	finished chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	err      error
+	// failed is the task which failed the workflow (job that has not exited successfully or service that has terminated).
+	failed *workflowTaskHandle
+}
+
+// launch starts the task and waits until it is ready.
+func (h *WorkflowTaskHandle) launch(task WorkflowTask) error {
+	log.Debugf("%s: launching task %q", h.workflow, task.Name)
+	taskHandle, err := task.Launcher.Launch()
+	if err != nil {
+		return errors.Wrapf(err, "%s: cannot launch task %q", h.workflow, task.Name)
+	}
+
+	h.mutex.Lock()
+	if h.stopping {
+		h.mutex.Unlock()
+		taskHandle.Stop()
+		return errors.Errorf("%s: task %q launched while workflow is being stopped", h.workflow, task.Name)
+	}
+	h.launched = append(h.launched, workflowTaskHandle{WorkflowTask: task, handle: taskHandle})
+	h.mutex.Unlock()
+
+	ready := task.Ready
+	if ready == nil && !task.Service && h.workflow.dependents[task.Name] {
+		ready = ExitedSuccessfully(0)
+	}
+	if ready != nil {
+		err = ready(taskHandle)
+		if err != nil {
+			return errors.Wrapf(err, "%s: task %q is not ready", h.workflow, task.Name)
+		}
+	}
+	log.Debugf("%s: task %q is ready", h.workflow, task.Name)
+	return nil
+}
+
+// watch waits for jobs to finish, services to terminate prematurely or Stop to be called and tears the workflow down.
+func (h *WorkflowTaskHandle) watch() {
+	var errCollection errcollection.ErrorCollection
+	tasks := h.tasks()
+
+	type termination struct {
+		workflowTaskHandle
+		err error
+	}
+	terminated := make(chan termination, len(tasks))
+	runningJobs := 0
+	// Workflow finishes on its own only when it has jobs which no task depends on; otherwise it runs until stopped.
+	waitForJobs := false
+	for _, task := range tasks {
+		if !task.Service {
+			runningJobs++
+			waitForJobs = waitForJobs || !h.workflow.dependents[task.Name]
+		}
+		go func(task workflowTaskHandle) {
+			_, err := task.handle.Wait(0)
+			terminated <- termination{task, err}
+		}(task)
+	}
+
+	for stopped := false; !stopped && (runningJobs > 0 || !waitForJobs); {
+		select {
+		case task := <-terminated:
+			if task.Service {
+				logOutput(task.handle)
+				errCollection.Add(errors.Errorf("%s: service %q has terminated prematurely", h.workflow, task.Name))
+				h.failed = &task.workflowTaskHandle
+				stopped = true
+				continue
+			}
+			runningJobs--
+			err := task.err
+			if err == nil {
+				err = checkExitCode(task.handle)
+			}
+			if err != nil {
+				errCollection.Add(errors.Wrapf(err, "%s: task %q failed", h.workflow, task.Name))
+				h.failed = &task.workflowTaskHandle
+				stopped = true
+			}
+		case <-h.stop:
+			stopped = true
+		}
+	}
+
+	errCollection.Add(h.teardown())
+	h.err = errCollection.GetErrIfAny()
+	close(h.finished)
+}
+
+// teardown stops launched tasks in reverse order of launching.
+func (h *WorkflowTaskHandle) teardown() error {
+	h.mutex.Lock()
+	h.stopping = true
+	h.mutex.Unlock()
+
+	var errCollection errcollection.ErrorCollection
+	tasks := h.tasks()
+	for i := len(tasks) - 1; i >= 0; i-- {
+		err := tasks[i].handle.Stop()
+		if err != nil {
+			errCollection.Add(errors.Wrapf(err, "%s: cannot stop task %q", h.workflow, tasks[i].Name))
+		}
+	}
+	return errCollection.GetErrIfAny()
+}
+
+func (h *WorkflowTaskHandle) tasks() []workflowTaskHandle {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]workflowTaskHandle(nil), h.launched...)
+}
+
+// Task returns handle of the task with given name or nil when the task was not launched.
+func (h *WorkflowTaskHandle) Task(name string) TaskHandle {
+	for _, task := range h.tasks() {
+		if task.Name == name {
+			return task.handle
+		}
+	}
+	return nil
+}
+
+// last returns handle of the last task of the workflow.
+func (h *WorkflowTaskHandle) last() TaskHandle {
+	return h.Task(h.workflow.tasks[len(h.workflow.tasks)-1].Name)
+}
+
+// Stop stops all the tasks of the workflow.
+func (h *WorkflowTaskHandle) Stop() error {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+
+	_, err := h.Wait(0)
+	return err
+}
+
+// Wait waits for all the jobs of the workflow to finish and for services to be stopped.
+func (h *WorkflowTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	select {
+	case <-getTimeoutChan(timeout):
+		return false, nil
+	case <-h.finished:
+		return true, h.err
+	}
+}
+
+// Status returns TERMINATED when all the tasks are terminated.
+func (h *WorkflowTaskHandle) Status() TaskState {
+	select {
+	case <-h.finished:
+		return TERMINATED
+	default:
+		return RUNNING
+	}
+}
+
+// ExitCode returns exit code of the task which failed the workflow, the first failed job or 0 when all the jobs succeeded.
+// Exit codes of services stopped by the workflow are ignored; service which terminated prematurely with code 0 is reported with -1.
+func (h *WorkflowTaskHandle) ExitCode() (int, error) {
+	if h.Status() != TERMINATED {
+		return -1, errors.Errorf("%s has not terminated yet", h.workflow)
+	}
+	if h.failed != nil {
+		exitCode, err := h.failed.handle.ExitCode()
+		if err == nil && exitCode == 0 && h.failed.Service {
+			exitCode = -1
+		}
+		return exitCode, err
+	}
+	for _, task := range h.tasks() {
+		if task.Service {
+			continue
+		}
+		exitCode, err := task.handle.ExitCode()
+		if err != nil || exitCode != 0 {
+			return exitCode, err
+		}
+	}
+	return 0, nil
+}
+
+// StdoutFile returns stdout of the last task of the workflow.
+func (h *WorkflowTaskHandle) StdoutFile() (*os.File, error) {
+	return h.last().StdoutFile()
+}
+
+// StderrFile returns stderr of the last task of the workflow.
+func (h *WorkflowTaskHandle) StderrFile() (*os.File, error) {
+	return h.last().StderrFile()
+}
+
+// Address returns address of the last task of the workflow.
+func (h *WorkflowTaskHandle) Address() string {
+	return h.last().Address()
+}
+
+// Pause suspends all the tasks, clients before services they depend on.
+func (h *WorkflowTaskHandle) Pause() error {
+	var errCollection errcollection.ErrorCollection
+	tasks := h.tasks()
+	for i := len(tasks) - 1; i >= 0; i-- {
+		errCollection.Add(tasks[i].handle.Pause())
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// Resume continues all the tasks, services before their clients.
+func (h *WorkflowTaskHandle) Resume() error {
+	var errCollection errcollection.ErrorCollection
+	for _, task := range h.tasks() {
+		errCollection.Add(task.handle.Resume())
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// EraseOutput removes output files of all the tasks.
+func (h *WorkflowTaskHandle) EraseOutput() error {
+	var errCollection errcollection.ErrorCollection
+	for _, task := range h.tasks() {
+		errCollection.Add(task.handle.EraseOutput())
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// StopStage implements StopStageReporter interface. The most severe stage of all the tasks is returned.
+func (h *WorkflowTaskHandle) StopStage() StopStage {
+	stage := NotStopped
+	for _, task := range h.tasks() {
+		if taskStage := GetStopStage(task.handle); taskStage > stage {
+			stage = taskStage
+		}
+	}
+	return stage
+}
+
+// String returns user-friendly name of the workflow.
+func (h *WorkflowTaskHandle) String() string {
+	return h.workflow.String()
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewWorkflow(t *testing.T) {
+	Convey("When creating Workflow", t, func() {
+		launcher := new(MockLauncher)
+
+		Convey("Dependency on unknown task should be rejected", func() {
+			_, err := NewWorkflow("test", WorkflowTask{Name: "server", Launcher: launcher, DependsOn: []string{"database"}})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "unknown task \"database\"")
+		})
+
+		Convey("Dependency cycle should be rejected", func() {
+			_, err := NewWorkflow("test",
+				WorkflowTask{Name: "populate", Launcher: launcher},
+				WorkflowTask{Name: "server", Launcher: launcher, DependsOn: []string{"populate", "load"}},
+				WorkflowTask{Name: "load", Launcher: launcher, DependsOn: []string{"server"}})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "dependency cycle between tasks server, load")
+		})
+
+		Convey("Tasks should be sorted by dependencies", func() {
+			workflow, err := NewWorkflow("test",
+				WorkflowTask{Name: "load", Launcher: launcher, DependsOn: []string{"server"}},
+				WorkflowTask{Name: "server", Launcher: launcher, DependsOn: []string{"populate"}},
+				WorkflowTask{Name: "populate", Launcher: launcher})
+			So(err, ShouldBeNil)
+			var names []string
+			for _, task := range workflow.tasks {
+				names = append(names, task.Name)
+			}
+			So(names, ShouldResemble, []string{"populate", "server", "load"})
+		})
+	})
+}
+
+// recorder records order of calls made to mocks by the workflow.
+type recorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(call string) func(mock.Arguments) {
+	return func(mock.Arguments) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.calls = append(r.calls, call)
+	}
+}
+
+// stoppedOnStop makes Wait of the handle block until the handle is stopped.
+func stoppedOnStop(handle *MockTaskHandle, onStop func(mock.Arguments)) {
+	stopped := make(chan struct{})
+	var once sync.Once
+	handle.On("Stop").Run(func(arguments mock.Arguments) {
+		onStop(arguments)
+		once.Do(func() { close(stopped) })
+	}).Return(nil)
+	handle.On("Wait", 0*time.Second).Run(func(mock.Arguments) { <-stopped }).Return(true, nil)
+}
+
+func TestWorkflow(t *testing.T) {
+	Convey("When launching database populate job, server and load generator job", t, func() {
+		calls := &recorder{}
+		populate, server, load := new(MockTaskHandle), new(MockTaskHandle), new(MockTaskHandle)
+		populateLauncher, serverLauncher, loadLauncher := new(MockLauncher), new(MockLauncher), new(MockLauncher)
+		populateLauncher.On("Launch").Run(calls.record("launch populate")).Return(populate, nil)
+		serverLauncher.On("Launch").Run(calls.record("launch server")).Return(server, nil)
+		loadLauncher.On("Launch").Run(calls.record("launch load")).Return(load, nil)
+		for name, handle := range map[string]*MockTaskHandle{"populate": populate, "server": server, "load": load} {
+			handle.On("String").Return(name)
+			handle.On("StdoutFile").Return(nil, errors.New("no output"))
+			handle.On("StderrFile").Return(nil, errors.New("no output"))
+		}
+		populate.On("Stop").Run(calls.record("stop populate")).Return(nil)
+
+		workflow, err := NewWorkflow("test",
+			WorkflowTask{Name: "populate", Launcher: populateLauncher},
+			WorkflowTask{Name: "server", Launcher: serverLauncher, DependsOn: []string{"populate"}, Service: true},
+			WorkflowTask{Name: "load", Launcher: loadLauncher, DependsOn: []string{"server"}, Ready: func(TaskHandle) error { return nil }})
+		So(err, ShouldBeNil)
+
+		Convey("When all the jobs succeed", func() {
+			populate.On("Wait", 0*time.Second).Return(true, nil)
+			populate.On("ExitCode").Return(0, nil)
+			stoppedOnStop(server, calls.record("stop server"))
+			load.On("Wait", 0*time.Second).Return(true, nil)
+			load.On("ExitCode").Return(0, nil)
+			load.On("Stop").Run(calls.record("stop load")).Return(nil)
+
+			handle, err := workflow.Launch()
+			So(err, ShouldBeNil)
+
+			Convey("Tasks should be launched in order and stopped in reverse order", func() {
+				terminated, err := handle.Wait(waitTimeout)
+				So(terminated, ShouldBeTrue)
+				So(err, ShouldBeNil)
+				So(calls.calls, ShouldResemble, []string{"launch populate", "launch server", "launch load", "stop load", "stop server", "stop populate"})
+
+				exitCode, err := handle.ExitCode()
+				So(err, ShouldBeNil)
+				So(exitCode, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When populate job fails", func() {
+			populate.On("Wait", 0*time.Second).Return(true, nil)
+			populate.On("ExitCode").Return(1, nil)
+
+			_, err := workflow.Launch()
+
+			Convey("Error should be returned and dependent tasks should not be launched", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "task \"populate\" is not ready")
+				So(calls.calls, ShouldResemble, []string{"launch populate", "stop populate"})
+			})
+		})
+
+		Convey("When server terminates prematurely", func() {
+			populate.On("Wait", 0*time.Second).Return(true, nil)
+			populate.On("ExitCode").Return(0, nil)
+			server.On("Wait", 0*time.Second).Return(true, nil)
+			server.On("ExitCode").Return(71, nil)
+			server.On("Stop").Run(calls.record("stop server")).Return(nil)
+			stoppedOnStop(load, calls.record("stop load"))
+
+			handle, err := workflow.Launch()
+			So(err, ShouldBeNil)
+
+			Convey("Running jobs should be stopped and error should be returned", func() {
+				terminated, err := handle.Wait(waitTimeout)
+				So(terminated, ShouldBeTrue)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "service \"server\" has terminated prematurely")
+				So(calls.calls, ShouldContain, "stop load")
+
+				Convey("Exit code of the server should be returned", func() {
+					exitCode, err := handle.ExitCode()
+					So(err, ShouldBeNil)
+					So(exitCode, ShouldEqual, 71)
+				})
+			})
+		})
+	})
+
+	Convey("When launching workflow without jobs that are waited for", t, func() {
+		calls := &recorder{}
+		populate, server := new(MockTaskHandle), new(MockTaskHandle)
+		populateLauncher, serverLauncher := new(MockLauncher), new(MockLauncher)
+		populateLauncher.On("Launch").Run(calls.record("launch populate")).Return(populate, nil)
+		serverLauncher.On("Launch").Run(calls.record("launch server")).Return(server, nil)
+		for name, handle := range map[string]*MockTaskHandle{"populate": populate, "server": server} {
+			handle.On("String").Return(name)
+			handle.On("StdoutFile").Return(nil, errors.New("no output"))
+			handle.On("StderrFile").Return(nil, errors.New("no output"))
+		}
+		populate.On("Wait", 0*time.Second).Return(true, nil)
+		populate.On("ExitCode").Return(0, nil)
+		populate.On("Stop").Run(calls.record("stop populate")).Return(nil)
+
+		workflow, err := NewWorkflow("test",
+			WorkflowTask{Name: "populate", Launcher: populateLauncher},
+			WorkflowTask{Name: "server", Launcher: serverLauncher, DependsOn: []string{"populate"}, Service: true})
+		So(err, ShouldBeNil)
+
+		Convey("When server keeps running", func() {
+			stoppedOnStop(server, calls.record("stop server"))
+
+			handle, err := workflow.Launch()
+			So(err, ShouldBeNil)
+
+			Convey("Workflow should run until it is stopped", func() {
+				terminated, err := handle.Wait(100 * time.Millisecond)
+				So(err, ShouldBeNil)
+				So(terminated, ShouldBeFalse)
+				So(handle.Status(), ShouldEqual, RUNNING)
+
+				So(handle.Stop(), ShouldBeNil)
+				So(calls.calls, ShouldResemble, []string{"launch populate", "launch server", "stop server", "stop populate"})
+				exitCode, err := handle.ExitCode()
+				So(err, ShouldBeNil)
+				So(exitCode, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When server exits prematurely with code 0", func() {
+			server.On("Wait", 0*time.Second).Return(true, nil)
+			server.On("ExitCode").Return(0, nil)
+			server.On("Stop").Run(calls.record("stop server")).Return(nil)
+
+			handle, err := workflow.Launch()
+			So(err, ShouldBeNil)
+
+			Convey("Workflow should fail", func() {
+				terminated, err := handle.Wait(waitTimeout)
+				So(terminated, ShouldBeTrue)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "service \"server\" has terminated prematurely")
+
+				exitCode, err := handle.ExitCode()
+				So(err, ShouldBeNil)
+				So(exitCode, ShouldEqual, -1)
+			})
+		})
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"os"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 	"github.com/sirupsen/logrus"
 )
@@ -33,6 +34,7 @@ var (
 
 // Configure handles configuration parsing, generation and restoration based on config-* flags.
 // Note: exits if configuration generation was requested.
+// Installs handler that reclaims all launched tasks when experiment is interrupted (see executor.CleanupOnExit).
 // This function must reside in experiment package because depends on metadata access.
 // Returns information about current log level.
 func Configure() bool {
@@ -66,5 +68,9 @@ func Configure() bool {
 		}
 		os.Exit(0)
 	}
+
+	// Stop all launched tasks and clean isolations when experiment is interrupted.
+	executor.CleanupOnExit()
+
 	return level == logrus.ErrorLevel
 }
@@ -0,0 +1,125 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hosttuning
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// MetadataKind is a type of metadata under which applied profile is stored.
+const MetadataKind = "host_tuning"
+
+// snapshot holds value of setting from before profile was applied.
+type snapshot struct {
+	setting  Setting
+	previous string
+	applied  string
+}
+
+// Manager applies profile and restores previous values afterwards.
+type Manager struct {
+	profile Profile
+
+	mutex    sync.Mutex
+	restored bool
+	applied  []snapshot
+}
+
+// NewManager returns Manager for given profile.
+func NewManager(profile Profile) *Manager {
+	return &Manager{profile: profile}
+}
+
+// Apply snapshots current values and applies the profile.
+// When mandatory value cannot be applied, already changed values are restored and error is returned.
+// Restore is registered in executor.DefaultRegistry, so previous values are also brought back
+// when experiment is interrupted (see executor.CleanupOnExit).
+func (m *Manager) Apply() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, value := range m.profile.Values {
+		previous, err := value.Setting.Get()
+		if err == nil && previous != value.Value {
+			err = value.Setting.Set(value.Value)
+		}
+		if err != nil {
+			if value.Optional {
+				logrus.Debugf("Host tuning: skipping %q: %s", value.Setting, err.Error())
+				continue
+			}
+			restoreErr := m.restore()
+			if restoreErr != nil {
+				logrus.Errorf("Host tuning: cannot restore settings: %s", restoreErr.Error())
+			}
+			return errors.Wrapf(err, "cannot apply %q host tuning profile", m.profile.Name)
+		}
+		if previous != value.Value {
+			logrus.Debugf("Host tuning: %q changed from %q to %q", value.Setting, previous, value.Value)
+			m.applied = append(m.applied, snapshot{setting: value.Setting, previous: previous, applied: value.Value})
+		}
+	}
+
+	logrus.Infof("Host tuning profile %q applied (%d settings changed)", m.profile.Name, len(m.applied))
+	executor.RegisterCleanup(fmt.Sprintf("host tuning profile %q", m.profile.Name), m.Restore)
+
+	return nil
+}
+
+// Restore brings back values from before Apply in reverse order. It is safe to call it multiple times.
+func (m *Manager) Restore() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.restore()
+}
+
+func (m *Manager) restore() error {
+	if m.restored {
+		return nil
+	}
+	m.restored = true
+
+	var errCollection errcollection.ErrorCollection
+	for i := len(m.applied) - 1; i >= 0; i-- {
+		snapshot := m.applied[i]
+		err := snapshot.setting.Set(snapshot.previous)
+		if err != nil {
+			errCollection.Add(errors.Wrapf(err, "cannot restore %q to %q", snapshot.setting, snapshot.previous))
+		}
+	}
+	if len(m.applied) > 0 {
+		logrus.Infof("Host tuning profile %q reverted", m.profile.Name)
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// Record stores profile name and changed settings in metadata.
+func (m *Manager) Record(metaData metadata.Metadata) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	records := map[string]string{"profile": m.profile.Name}
+	for _, snapshot := range m.applied {
+		records[snapshot.setting.String()] = fmt.Sprintf("%s -> %s", snapshot.previous, snapshot.applied)
+	}
+	return metaData.RecordMap(records, MetadataKind)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hosttuning
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManager(t *testing.T) {
+	Convey("When host settings are stored in files", t, func() {
+		root, err := ioutil.TempDir("", "hosttuning")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+
+		governor := File{path.Join(root, "scaling_governor")}
+		noTurbo := File{path.Join(root, "no_turbo")}
+		So(governor.Set("powersave"), ShouldBeNil)
+		So(noTurbo.Set("0"), ShouldBeNil)
+
+		Convey("Applied profile should be reverted", func() {
+			manager := NewManager(Profile{Name: "test", Values: []Value{
+				{Setting: governor, Value: "performance"},
+				{Setting: noTurbo, Value: "1"},
+				{Setting: File{path.Join(root, "missing", "file")}, Value: "1", Optional: true},
+			}})
+
+			So(manager.Apply(), ShouldBeNil)
+			value, err := governor.Get()
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "performance")
+
+			So(manager.Restore(), ShouldBeNil)
+			So(manager.Restore(), ShouldBeNil)
+			value, err = governor.Get()
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "powersave")
+			value, err = noTurbo.Get()
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "0")
+		})
+
+		Convey("Profile should be rolled back when mandatory setting cannot be applied", func() {
+			manager := NewManager(Profile{Name: "test", Values: []Value{
+				{Setting: governor, Value: "performance"},
+				{Setting: File{path.Join(root, "missing", "file")}, Value: "1"},
+			}})
+
+			err := manager.Apply()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "missing")
+			value, err := governor.Get()
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "powersave")
+		})
+
+		Convey("Interrupts should be pinned away from HP CPUs", func() {
+			if runtime.NumCPU() < 2 {
+				SkipSo("at least two CPUs are required")
+				return
+			}
+			So(os.Mkdir(path.Join(root, "24"), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(path.Join(root, "24", "smp_affinity_list"), []byte("0\n"), 0644), ShouldBeNil)
+
+			values, err := irqAffinityValues(root, isolation.NewIntSet(0))
+			So(err, ShouldBeNil)
+			So(values, ShouldHaveLength, 1)
+			So(values[0].Optional, ShouldBeTrue)
+			pinned, err := isolation.NewIntSetFromRange(values[0].Value)
+			So(err, ShouldBeNil)
+			So(pinned.Contains(0), ShouldBeFalse)
+		})
+	})
+
+	Convey("Unknown profile should be rejected", t, func() {
+		_, err := NewProfile("fastest", isolation.NewIntSet())
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hosttuning
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+)
+
+const (
+	// NoneProfile does not change any settings.
+	NoneProfile = "none"
+	// LatencyProfile applies settings recommended for latency sensitive High Priority workloads.
+	LatencyProfile = "latency"
+
+	latencyNOFILE = 10 * 1024
+
+	sysfsCPU           = "/sys/devices/system/cpu"
+	intelPstateNoTurbo = "/sys/devices/system/cpu/intel_pstate/no_turbo"
+	cpufreqBoost       = "/sys/devices/system/cpu/cpufreq/boost"
+	procIRQ            = "/proc/irq"
+)
+
+// ProfileFlag selects profile applied for experiment duration.
+var ProfileFlag = conf.NewStringFlag("experiment_host_tuning_profile", fmt.Sprintf("Host tuning profile applied for experiment duration and reverted afterwards. Supported: %q (performance governor, turbo disabled, tcp_syncookies disabled, interrupts pinned away from HP CPUs, raised NOFILE limit) and %q.", LatencyProfile, NoneProfile), NoneProfile)
+
+// Value is a desired value of a setting.
+type Value struct {
+	Setting Setting
+	Value   string
+	// Optional values are applied on best effort basis (e.g. some interrupts cannot be moved).
+	Optional bool
+}
+
+// Profile is a named set of values.
+type Profile struct {
+	Name   string
+	Values []Value
+}
+
+// NewProfile returns profile by name. hpThreads are CPUs reserved for High Priority workload.
+func NewProfile(name string, hpThreads isolation.IntSet) (Profile, error) {
+	switch name {
+	case NoneProfile, "":
+		return Profile{Name: NoneProfile}, nil
+	case LatencyProfile:
+		return newLatencyProfile(hpThreads)
+	default:
+		return Profile{}, errors.Errorf("unknown host tuning profile %q", name)
+	}
+}
+
+// NewProfileFromFlag returns profile selected by ProfileFlag.
+func NewProfileFromFlag(hpThreads isolation.IntSet) (Profile, error) {
+	return NewProfile(ProfileFlag.Value(), hpThreads)
+}
+
+func newLatencyProfile(hpThreads isolation.IntSet) (Profile, error) {
+	profile := Profile{Name: LatencyProfile}
+
+	for cpu := 0; cpu < runtime.NumCPU(); cpu++ {
+		governor := path.Join(sysfsCPU, fmt.Sprintf("cpu%d/cpufreq/scaling_governor", cpu))
+		if exists(governor) {
+			profile.Values = append(profile.Values, Value{Setting: File{governor}, Value: "performance"})
+		}
+	}
+
+	if exists(intelPstateNoTurbo) {
+		profile.Values = append(profile.Values, Value{Setting: File{intelPstateNoTurbo}, Value: "1"})
+	} else if exists(cpufreqBoost) {
+		profile.Values = append(profile.Values, Value{Setting: File{cpufreqBoost}, Value: "0"})
+	}
+
+	profile.Values = append(profile.Values, Value{Setting: Sysctl{"net.ipv4.tcp_syncookies"}, Value: "0"})
+
+	irqValues, err := irqAffinityValues(procIRQ, hpThreads)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile.Values = append(profile.Values, irqValues...)
+
+	profile.Values = append(profile.Values, Value{Setting: NOFILE{}, Value: strconv.Itoa(latencyNOFILE)})
+
+	return profile, nil
+}
+
+// irqAffinityValues returns values that pin all interrupts to CPUs other than hpThreads.
+func irqAffinityValues(irqRoot string, hpThreads isolation.IntSet) ([]Value, error) {
+	if hpThreads.Empty() {
+		return nil, nil
+	}
+
+	allCPUs := isolation.NewIntSet()
+	for cpu := 0; cpu < runtime.NumCPU(); cpu++ {
+		allCPUs.Add(cpu)
+	}
+	remaining := allCPUs.Difference(hpThreads)
+	if remaining.Empty() {
+		return nil, errors.Errorf("cannot pin interrupts away from CPUs %s: no CPUs left", hpThreads.AsRangeString())
+	}
+
+	affinityFiles, err := filepath.Glob(path.Join(irqRoot, "*", "smp_affinity_list"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list interrupts in %q", irqRoot)
+	}
+
+	var values []Value
+	for _, affinityFile := range affinityFiles {
+		values = append(values, Value{Setting: File{affinityFile}, Value: remaining.AsRangeString(), Optional: true})
+	}
+	return values, nil
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
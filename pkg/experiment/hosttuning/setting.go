@@ -0,0 +1,126 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hosttuning
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/intelsdi-x/swan/pkg/utils/sysctl"
+	"github.com/pkg/errors"
+)
+
+// Setting is a single host parameter that can be read and changed.
+type Setting interface {
+	fmt.Stringer
+	// Get returns current value.
+	Get() (string, error)
+	// Set changes the value.
+	Set(value string) error
+}
+
+// File is a setting backed by a sysfs or procfs file.
+type File struct {
+	Path string
+}
+
+// String returns path of the file.
+func (f File) String() string {
+	return f.Path
+}
+
+// Get returns file content without trailing newline.
+func (f File) Get() (string, error) {
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read file %q", f.Path)
+	}
+	return strings.TrimSuffix(string(content), "\n"), nil
+}
+
+// Set writes value to the file.
+func (f File) Set(value string) error {
+	err := ioutil.WriteFile(f.Path, []byte(value+"\n"), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write %q to file %q", value, f.Path)
+	}
+	return nil
+}
+
+// Sysctl is a setting backed by a sysctl key.
+type Sysctl struct {
+	Name string
+}
+
+// String returns sysctl key.
+func (s Sysctl) String() string {
+	return s.Name
+}
+
+// Get returns current sysctl value.
+func (s Sysctl) Get() (string, error) {
+	return sysctl.Get(s.Name)
+}
+
+// Set changes sysctl value.
+func (s Sysctl) Set(value string) error {
+	return sysctl.Set(s.Name, value)
+}
+
+// NOFILE is a setting of maximum number of open file descriptors of the experiment process.
+// The limit is inherited by all the workloads launched by local executor.
+type NOFILE struct{}
+
+// String returns name of the resource limit.
+func (NOFILE) String() string {
+	return "RLIMIT_NOFILE"
+}
+
+// Get returns current soft limit.
+func (NOFILE) Get() (string, error) {
+	var limit syscall.Rlimit
+	err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit)
+	if err != nil {
+		return "", errors.Wrap(err, "getrlimit failed")
+	}
+	return strconv.FormatUint(limit.Cur, 10), nil
+}
+
+// Set changes soft limit. Hard limit is raised when needed (requires root privileges).
+func (NOFILE) Set(value string) error {
+	nofile, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid number of open file descriptors %q", value)
+	}
+
+	var limit syscall.Rlimit
+	err = syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit)
+	if err != nil {
+		return errors.Wrap(err, "getrlimit failed")
+	}
+	limit.Cur = nofile
+	if limit.Max < nofile {
+		limit.Max = nofile
+	}
+
+	err = syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit)
+	if err != nil {
+		return errors.Wrapf(err, "setrlimit to %d failed", nofile)
+	}
+	return nil
+}
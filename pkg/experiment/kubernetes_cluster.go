@@ -40,7 +40,13 @@ func LaunchKubernetesCluster() (clusterHandle executor.TaskHandle, err error) {
 		return nil, err
 	}
 
-	k8sLauncher := kubernetes.New(masterExecutor, executor.NewLocal(), kubernetes.DefaultConfig())
+	var k8sLauncher executor.Launcher
+	if kubernetes.KubeadmFlag.Value() {
+		// Single-node cluster: kubelet runs on the control plane host.
+		k8sLauncher = kubernetes.NewKubeadm(masterExecutor, kubernetes.DefaultConfig())
+	} else {
+		k8sLauncher = kubernetes.New(masterExecutor, executor.NewLocal(), kubernetes.DefaultConfig())
+	}
 	k8sClusterTaskHandle, err := k8sLauncher.Launch()
 	if err != nil {
 		return nil, err
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/experiment"
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 	"github.com/sirupsen/logrus"
@@ -20,6 +21,7 @@ func Initialize(appName, uuid string) {
 	logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true, TimestampFormat: "2006-01-02 15:04:05.100"})
 	logrus.Infof("Working directory %q", experimentDirectory)
 	logrus.SetOutput(io.MultiWriter(logFile, os.Stderr))
+	logrus.Infof("Artifacts created by executors are recorded in %q (see swan-reap)", executor.EnableArtifactManifest(experimentDirectory))
 
 	// Logging and outputting experiment ID.
 	logrus.Info("Starting Experiment ", appName, " with uid ", uuid)
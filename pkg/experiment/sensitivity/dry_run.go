@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+)
+
+// DryRunExecutorFactory produces DryRun executors that record commands instead of executing them.
+type DryRunExecutorFactory struct {
+	recorder *executor.DryRunRecorder
+	address  string
+}
+
+// NewDryRunExecutorFactory returns DryRun Executor Factory instance.
+// Target address is Kubernetes node name when experiment is run on Kubernetes or localhost otherwise.
+func NewDryRunExecutorFactory(recorder *executor.DryRunRecorder) ExecutorFactory {
+	address := "127.0.0.1"
+	if experiment.RunOnKubernetesFlag.Value() {
+		address = kubernetesNodeName.Value()
+	}
+	return &DryRunExecutorFactory{recorder: recorder, address: address}
+}
+
+// BuildHighPriorityExecutor returns DryRun executor.
+func (factory DryRunExecutorFactory) BuildHighPriorityExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	return executor.NewDryRun(factory.recorder, factory.address, decorators...), nil
+}
+
+// BuildBestEffortExecutor returns DryRun executor.
+func (factory DryRunExecutorFactory) BuildBestEffortExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	return executor.NewDryRun(factory.recorder, factory.address, decorators...), nil
+}
+
+// DryRunPhase describes single experiment phase that would have been run.
+type DryRunPhase struct {
+	Name       string
+	LoadPoint  int
+	QPS        int
+	Duration   time.Duration
+	Commands   []executor.DryRunCommand
+	Repetition int
+}
+
+// DryRunPlan describes all the phases that would have been run by an experiment.
+type DryRunPlan struct {
+	Name string
+	// PeakLoad equal to RunTuningPhase means that peak load would have been found in tuning phase.
+	PeakLoad   int
+	LoadPoints int
+	Phases     []DryRunPhase
+}
+
+// EstimatedDuration returns sum of all phases durations.
+// Time needed to run tuning phase and to start workloads is not included.
+func (plan DryRunPlan) EstimatedDuration() (duration time.Duration) {
+	for _, phase := range plan.Phases {
+		duration += phase.Duration
+	}
+	return duration
+}
+
+// Print writes human readable plan to given writer.
+func (plan DryRunPlan) Print(w io.Writer) {
+	fmt.Fprintf(w, "Experiment %q plan\n", plan.Name)
+	if plan.PeakLoad == RunTuningPhase {
+		fmt.Fprintln(w, "Peak load: determined in tuning phase")
+	} else {
+		fmt.Fprintf(w, "Peak load: %d QPS\n", plan.PeakLoad)
+	}
+
+	for i, phase := range plan.Phases {
+		fmt.Fprintf(w, "\nPhase %d/%d: %s\n", i+1, len(plan.Phases), phase.Name)
+		if plan.PeakLoad == RunTuningPhase {
+			fmt.Fprintf(w, "  Load: %d/%d of peak load for %s\n", phase.LoadPoint+1, plan.LoadPoints, phase.Duration)
+		} else {
+			fmt.Fprintf(w, "  Load: %d QPS for %s\n", phase.QPS, phase.Duration)
+		}
+		for _, command := range phase.Commands {
+			fmt.Fprintf(w, "  [%s] %s\n", command.Address, command.Command)
+		}
+	}
+
+	fmt.Fprintf(w, "\nEstimated total runtime: %s (excluding tuning and workloads start up)\n", plan.EstimatedDuration())
+}
+
+// DryRunRepetition goes through single repetition of sensitivity experiment (HP, population, BE and load)
+// using launchers backed by DryRun executors and returns all the commands recorded.
+// beLauncher can be nil for baseline phase.
+func DryRunRepetition(recorder *executor.DryRunRecorder, hpLauncher, beLauncher executor.Launcher, loadGenerator executor.LoadGenerator, qps int, duration time.Duration) ([]executor.DryRunCommand, error) {
+	var handles []executor.TaskHandle
+	dryRun := func() error {
+		hpHandle, err := hpLauncher.Launch()
+		if err != nil {
+			return errors.Wrapf(err, "cannot launch %q", hpLauncher)
+		}
+		handles = append(handles, hpHandle)
+
+		err = loadGenerator.Populate()
+		if err != nil {
+			return errors.Wrap(err, "cannot populate high priority workload")
+		}
+
+		if beLauncher != nil {
+			beHandle, err := beLauncher.Launch()
+			if err != nil {
+				return errors.Wrapf(err, "cannot launch %q", beLauncher)
+			}
+			handles = append(handles, beHandle)
+		}
+
+		loadHandle, err := loadGenerator.Load(qps, duration)
+		if err != nil {
+			return errors.Wrap(err, "cannot start load generation")
+		}
+		handles = append(handles, loadHandle)
+
+		return nil
+	}
+
+	var errCollection errcollection.ErrorCollection
+	errCollection.Add(dryRun())
+	for _, handle := range handles {
+		errCollection.Add(handle.Stop())
+	}
+
+	return recorder.Flush(), errCollection.GetErrIfAny()
+}
@@ -18,12 +18,16 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/experiment"
 	"github.com/intelsdi-x/swan/pkg/isolation"
 	"github.com/intelsdi-x/swan/pkg/kubernetes"
+	"github.com/intelsdi-x/swan/pkg/workloads/memcached"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -42,6 +46,28 @@ var (
 
 	// hpKubernetesGuaranteedClassFlag indicates tha HP workload will run as guarateed class.
 	hpKubernetesGuaranteedClassFlag = conf.NewBoolFlag("kubernetes_hp_guaranteed_class", "Run HP workload on Kubernetes as Pod with \"QoS Guaranteed resources class\" (by default runs as \"Burstable class\").", false)
+	// hpKubernetesExclusiveCPUsFlag indicates number of whole CPUs requested by HP pod.
+	hpKubernetesExclusiveCPUsFlag = conf.NewIntFlag("kubernetes_hp_exclusive_cpus", "Run HP workload on Kubernetes as Guaranteed Pod requesting given number of whole CPUs, so kubelet with static CPU manager policy gives it exclusive cores "+
+		"(overrides kubernetes_hp_cpu_resource; 0 disables).", 0)
+	// hpKubernetesHugepagesFlag indicates amount of 2MiB hugepages memory requested by HP pod.
+	hpKubernetesHugepagesFlag = conf.NewIntFlag("kubernetes_hp_hugepages_2mi", "Sets hugepages-2Mi resource request and limit for HP workload on Kubernetes in bytes (0 disables).", 0)
+	// hpKubernetesExtendedResourcesFlag indicates extended resources requested by HP pod.
+	hpKubernetesExtendedResourcesFlag = conf.NewStringFlag("kubernetes_hp_extended_resources", "Comma separated extended resources requested by HP workload on Kubernetes, e.g. \"intel.com/rdt_class_hp=1\".", "")
+	// beKubernetesExtendedResourcesFlag indicates extended resources requested by BE pods.
+	beKubernetesExtendedResourcesFlag = conf.NewStringFlag("kubernetes_be_extended_resources", "Comma separated extended resources requested by BE workloads on Kubernetes, e.g. \"intel.com/rdt_class_be=1\". "+
+		"Extended resources do not change Best Effort QoS class of the pods.", "")
+	// beKubernetesJobsFlag indicates that BE workloads are run as Kubernetes Jobs.
+	beKubernetesJobsFlag = conf.NewBoolFlag("kubernetes_be_jobs", "Run BE workloads on Kubernetes as Jobs, so failed pods are retried (up to kubernetes_be_job_backoff_limit times).", false)
+	// beKubernetesJobBackoffLimitFlag indicates number of retries of BE jobs.
+	beKubernetesJobBackoffLimitFlag = conf.NewIntFlag("kubernetes_be_job_backoff_limit", "Number of retries of failed BE pods run as Kubernetes Jobs (requires Kubernetes 1.8 or newer).", 6)
+
+	// RunOnDockerFlag indicates that workloads should be run in Docker containers on local host.
+	RunOnDockerFlag = conf.NewBoolFlag("docker", fmt.Sprintf("Run HP and BE workloads in Docker containers on local host (ignored when %q flag is set).", experiment.RunOnKubernetesFlag.Name), false)
+
+	// RunOnSystemdFlag indicates that workloads should be run as transient systemd units on local host.
+	RunOnSystemdFlag = conf.NewBoolFlag("systemd", "Run HP and BE workloads as transient systemd services on local host (ignored when \"kubernetes\" or \"docker\" flag is set).", false)
+	// beSystemdCPUWeightFlag is a relative CPU share of BE workloads run by systemd.
+	beSystemdCPUWeightFlag = conf.NewIntFlag("systemd_be_cpu_weight", "CPU weight (1-10000) of BE workloads run as systemd services (default weight is 100).", 1)
 
 	kubernetesNodeName = conf.NewStringFlag("kubernetes_target_node_name", fmt.Sprintf("Experiment's Kubernetes pods will be run on this node. Helpful when used with %q flag. Default is `$HOSTNAME`", experiment.RunOnExistingKubernetesFlag.Name), hostname)
 )
@@ -54,12 +80,20 @@ type ExecutorFactory interface {
 	BuildBestEffortExecutor(decorator ...isolation.Decorator) (executor.Executor, error)
 }
 
-// NewExecutorFactory returns Local or Kubernetes executor factory, depending on flags.
+// NewExecutorFactory returns Local, Kubernetes, Docker or Systemd executor factory, depending on flags.
 func NewExecutorFactory() ExecutorFactory {
 	if experiment.RunOnKubernetesFlag.Value() {
 		return NewKubernetesExecutorFactory()
 	}
 
+	if RunOnDockerFlag.Value() {
+		return NewDockerExecutorFactory()
+	}
+
+	if RunOnSystemdFlag.Value() {
+		return NewSystemdExecutorFactory()
+	}
+
 	return NewLocalExecutorFactory()
 }
 
@@ -101,6 +135,14 @@ func (factory KubernetesExecutorFactory) BuildHighPriorityExecutor(decorators ..
 	k8sExecutorConfig.Decorators = decorators
 	k8sExecutorConfig.HostNetwork = true
 	k8sExecutorConfig.Address = clusterConfig.GetKubeAPIAddress()
+	k8sExecutorConfig.CgroupDriver = clusterConfig.KubeletCgroupDriver
+
+	// HP pod is reached through Service in Kubernetes-native mode.
+	if RunKubernetesNative() {
+		k8sExecutorConfig.HostNetwork = false
+		k8sExecutorConfig.Labels = map[string]string{roleLabel: hpRole}
+		k8sExecutorConfig.ReadinessProbe = tcpReadinessProbe(memcached.PortFlag.Value())
+	}
 	k8sExecutorConfig.CPURequest = int64(hpKubernetesCPUResourceFlag.Value())
 	k8sExecutorConfig.MemoryRequest = int64(hpKubernetesMemoryResourceFlag.Value())
 
@@ -110,6 +152,20 @@ func (factory KubernetesExecutorFactory) BuildHighPriorityExecutor(decorators ..
 		k8sExecutorConfig.MemoryLimit = int64(hpKubernetesMemoryResourceFlag.Value())
 	}
 
+	// Static CPU manager policy assigns exclusive cores only to guaranteed pods requesting integer CPUs.
+	if exclusiveCPUs := hpKubernetesExclusiveCPUsFlag.Value(); exclusiveCPUs > 0 {
+		k8sExecutorConfig.CPURequest = int64(exclusiveCPUs * 1000)
+		k8sExecutorConfig.CPULimit = int64(exclusiveCPUs * 1000)
+		k8sExecutorConfig.MemoryLimit = int64(hpKubernetesMemoryResourceFlag.Value())
+	}
+
+	k8sExecutorConfig.Hugepages2MiRequest = int64(hpKubernetesHugepagesFlag.Value())
+	extendedResources, err := parseExtendedResources(hpKubernetesExtendedResourcesFlag.Value())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q flag", hpKubernetesExtendedResourcesFlag.Name)
+	}
+	k8sExecutorConfig.ExtendedResources = extendedResources
+
 	k8sExecutorConfig.Privileged = true
 
 	return executor.NewKubernetes(k8sExecutorConfig)
@@ -121,9 +177,130 @@ func (factory KubernetesExecutorFactory) BuildBestEffortExecutor(decorators ...i
 
 	config := executor.DefaultKubernetesConfig()
 	config.Address = clusterConfig.GetKubeAPIAddress()
+	config.CgroupDriver = clusterConfig.KubeletCgroupDriver
 	config.PodNamePrefix = "swan-be"
 	config.NodeName = kubernetesNodeName.Value()
 	config.Decorators = decorators
 	config.Privileged = true // Best Effort workloads use unshare, which requires sudo.
+
+	extendedResources, err := parseExtendedResources(beKubernetesExtendedResourcesFlag.Value())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %q flag", beKubernetesExtendedResourcesFlag.Name)
+	}
+	config.ExtendedResources = extendedResources
+
+	if beKubernetesJobsFlag.Value() {
+		jobConfig := executor.DefaultKubernetesJobConfig()
+		jobConfig.BackoffLimit = int32(beKubernetesJobBackoffLimitFlag.Value())
+		config.Job = &jobConfig
+	}
 	return executor.NewKubernetes(config)
 }
+
+// UsesCacheAllocation returns true when workloads are isolated with RDT cache allocation, i.e. pods request
+// RDT classes (extended resources advertised by RDT device plugin, e.g. "intel.com/rdt_class_hp").
+func UsesCacheAllocation() bool {
+	if !experiment.RunOnKubernetesFlag.Value() {
+		return false
+	}
+	for _, flag := range []string{hpKubernetesExtendedResourcesFlag.Value(), beKubernetesExtendedResourcesFlag.Value()} {
+		resources, err := parseExtendedResources(flag)
+		if err != nil {
+			continue
+		}
+		for name := range resources {
+			if strings.Contains(name, "rdt") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseExtendedResources parses comma separated "name=count" pairs.
+func parseExtendedResources(value string) (map[string]int64, error) {
+	resources := map[string]int64{}
+	if value == "" {
+		return resources, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		nameAndCount := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(nameAndCount) != 2 || nameAndCount[0] == "" {
+			return nil, errors.Errorf("extended resource %q is not in name=count format", pair)
+		}
+		count, err := strconv.ParseInt(nameAndCount[1], 10, 64)
+		if err != nil || count <= 0 {
+			return nil, errors.Errorf("count of extended resource %q must be a positive integer", nameAndCount[0])
+		}
+		resources[nameAndCount[0]] = count
+	}
+	return resources, nil
+}
+
+// DockerExecutorFactory produces Docker Executors.
+type DockerExecutorFactory struct {
+}
+
+// NewDockerExecutorFactory returns Docker Executor Factory instance.
+func NewDockerExecutorFactory() ExecutorFactory {
+	return &DockerExecutorFactory{}
+}
+
+// BuildHighPriorityExecutor returns Docker executor with container limited to CPUs of HP workload.
+func (factory DockerExecutorFactory) BuildHighPriorityExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	config := executor.DefaultDockerConfig()
+	config.NamePrefix = "swan-hp"
+	config.Decorators = decorators
+	config.CPUSet = cpusOf(decorators)
+	config.HostNetwork = true
+	config.Privileged = true
+	return executor.NewDocker(config), nil
+}
+
+// BuildBestEffortExecutor returns Docker executor with container limited to CPUs of BE workload.
+func (factory DockerExecutorFactory) BuildBestEffortExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	config := executor.DefaultDockerConfig()
+	config.NamePrefix = "swan-be"
+	config.Decorators = decorators
+	config.CPUSet = cpusOf(decorators)
+	config.HostNetwork = true
+	config.Privileged = true // Best Effort workloads use unshare, which requires sudo.
+	return executor.NewDocker(config), nil
+}
+
+// cpusOf returns CPUs that tasksets among decorators (including nested ones) pin workload to.
+func cpusOf(decorators isolation.Decorators) isolation.IntSet {
+	cpus := isolation.NewIntSet()
+	for _, decorator := range decorators.Flatten() {
+		if taskset, ok := decorator.(isolation.Taskset); ok {
+			cpus = cpus.Union(taskset.CPUList)
+		}
+	}
+	return cpus
+}
+
+// SystemdExecutorFactory produces Systemd Executors.
+type SystemdExecutorFactory struct {
+}
+
+// NewSystemdExecutorFactory returns Systemd Executor Factory instance.
+func NewSystemdExecutorFactory() ExecutorFactory {
+	return &SystemdExecutorFactory{}
+}
+
+// BuildHighPriorityExecutor returns Systemd executor with default CPU weight.
+func (factory SystemdExecutorFactory) BuildHighPriorityExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	config := executor.DefaultSystemdConfig()
+	config.UnitPrefix = "swan-hp"
+	config.Decorators = decorators
+	return executor.NewSystemd(config), nil
+}
+
+// BuildBestEffortExecutor returns Systemd executor with CPU weight lowered (see beSystemdCPUWeightFlag).
+func (factory SystemdExecutorFactory) BuildBestEffortExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	config := executor.DefaultSystemdConfig()
+	config.UnitPrefix = "swan-be"
+	config.Decorators = decorators
+	config.CPUWeight = beSystemdCPUWeightFlag.Value()
+	return executor.NewSystemd(config), nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCPUsOf(t *testing.T) {
+	Convey("When workload factory isolates workloads with tasksets", t, func() {
+		hpIsolation := isolation.Taskset{CPUList: isolation.NewIntSet(0, 1)}
+		l1Isolation := isolation.Taskset{CPUList: isolation.NewIntSet(2, 3)}
+		l3Isolation := isolation.Taskset{CPUList: isolation.NewIntSet(4, 5)}
+		factory := NewWorkloadFactoryWithIsolation(NewLocalExecutorFactory(), hpIsolation, l1Isolation, l3Isolation)
+
+		Convey("CPUs of High Priority workload should be found", func() {
+			So(cpusOf(isolation.Decorators{factory.hpIsolation}), ShouldResemble, isolation.NewIntSet(0, 1))
+		})
+
+		Convey("CPUs of Best Effort workloads should be found in nested decorators", func() {
+			So(cpusOf(isolation.Decorators{factory.getDefaultBestEffortIsolation(l1d)}), ShouldResemble, isolation.NewIntSet(2, 3))
+			So(cpusOf(isolation.Decorators{factory.getDefaultBestEffortIsolation(caffeWorkloadWithIsolation)}), ShouldResemble, isolation.NewIntSet(4, 5))
+		})
+
+		Convey("No CPUs should be found for workloads without taskset", func() {
+			So(cpusOf(isolation.Decorators{factory.getDefaultBestEffortIsolation(caffeWorkload)}), ShouldBeEmpty)
+		})
+	})
+}
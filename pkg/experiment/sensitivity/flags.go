@@ -40,4 +40,6 @@ var (
 	PeakLoadFlag = conf.NewIntFlag("experiment_peak_load", "Maximum load that will be generated on HP workload. If value is `0`, then maximum possible load will be found by Swan.", RunTuningPhase)
 	// LoadGeneratorWaitTimeoutFlag is a flag that indicates how log experiment should wait for load generator to stop
 	LoadGeneratorWaitTimeoutFlag = conf.NewDurationFlag("experiment_load_generator_wait_timeout", "Amount of time to wait for load generator to stop before stopping it forcefully. In successful case, it should stop on it's own.", 0)
+	// DryRunFlag makes experiment print its plan instead of running it.
+	DryRunFlag = conf.NewBoolFlag("experiment_dry_run", "Print every phase with decorated commands, target hosts, load and estimated runtime without launching anything or touching metadata database.", false)
 )
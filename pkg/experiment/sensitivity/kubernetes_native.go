@@ -0,0 +1,173 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/kubernetes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	// hpServiceName is a name of Kubernetes Service exposing HP workload in Kubernetes-native mode.
+	hpServiceName = "swan-hp"
+	// roleLabel is set on experiment pods selected by Services.
+	roleLabel = "swan-role"
+	// hpRole is a value of roleLabel of HP pods.
+	hpRole = "hp"
+)
+
+var (
+	// KubernetesNativeFlag indicates that HP workload is reached through Kubernetes Service by load generator run in the cluster.
+	KubernetesNativeFlag = conf.NewBoolFlag("kubernetes_native", fmt.Sprintf("Expose HP workload through Kubernetes Service and run load generator as pods in the cluster (requires %q flag), "+
+		"so Service and pod network overhead is a part of the measurement. HP pod does not use host network then.", experiment.RunOnKubernetesFlag.Name), false)
+	// kubernetesLoadGeneratorNodeNameFlag is a node where load generator pods are run.
+	kubernetesLoadGeneratorNodeNameFlag = conf.NewStringFlag("kubernetes_load_generator_node_name", "Load generator pods are run on this node in Kubernetes-native mode. Pods are scheduled by Kubernetes when empty.", "")
+)
+
+// RunKubernetesNative returns true when experiment is run in Kubernetes-native mode.
+func RunKubernetesNative() bool {
+	return experiment.RunOnKubernetesFlag.Value() && KubernetesNativeFlag.Value()
+}
+
+// HighPriorityService is a Kubernetes Service of ClusterIP type forwarding traffic to HP pods.
+// It outlives HP pods, so its address does not change when HP workload is relaunched in every repetition.
+type HighPriorityService struct {
+	servicesAPI corev1.ServiceInterface
+	service     *v1.Service
+}
+
+// NewHighPriorityService creates Service forwarding port to HP pods on cluster from kubernetes flags.
+// Service is deleted by executor cleanup when experiment exits.
+func NewHighPriorityService(port int) (*HighPriorityService, error) {
+	clusterConfig := kubernetes.DefaultConfig()
+	client, err := executor.NewKubernetesClientset(clusterConfig.GetKubeAPIAddress())
+	if err != nil {
+		return nil, err
+	}
+	service, err := newHighPriorityService(client, v1.NamespaceDefault, port)
+	if err != nil {
+		return nil, err
+	}
+	executor.RegisterCleanup(fmt.Sprintf("Kubernetes service %q", hpServiceName), service.Delete)
+	return service, nil
+}
+
+func newHighPriorityService(client clientset.Interface, namespace string, port int) (*HighPriorityService, error) {
+	servicesAPI := client.CoreV1().Services(namespace)
+
+	// Service might be left behind by interrupted experiment.
+	err := servicesAPI.Delete(hpServiceName, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "cannot delete stale service %q", hpServiceName)
+	}
+
+	service, err := servicesAPI.Create(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hpServiceName,
+			Namespace: namespace,
+			Labels:    map[string]string{roleLabel: hpRole},
+		},
+		Spec: v1.ServiceSpec{
+			Type:     v1.ServiceTypeClusterIP,
+			Selector: map[string]string{roleLabel: hpRole},
+			Ports: []v1.ServicePort{{
+				Protocol:   v1.ProtocolTCP,
+				Port:       int32(port),
+				TargetPort: intstr.FromInt(port),
+			}},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create service %q in namespace %q", hpServiceName, namespace)
+	}
+	log.Debugf("HP workload is exposed by service %q at %s:%d", service.Name, service.Spec.ClusterIP, port)
+
+	return &HighPriorityService{
+		servicesAPI: servicesAPI,
+		service:     service,
+	}, nil
+}
+
+// Address returns cluster IP of the Service.
+func (s *HighPriorityService) Address() string {
+	return s.service.Spec.ClusterIP
+}
+
+// Delete removes the Service from the cluster. Service which has already been deleted is ignored.
+func (s *HighPriorityService) Delete() error {
+	err := s.servicesAPI.Delete(s.service.Name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "cannot delete service %q", s.service.Name)
+	}
+	return nil
+}
+
+// tcpReadinessProbe returns probe run by kubelet, which checks that the pod accepts connections on port.
+// Pods are on pod network in Kubernetes-native mode, so they are not necessarily reachable from experiment host.
+func tcpReadinessProbe(port int) *v1.Probe {
+	return &v1.Probe{
+		Handler: v1.Handler{
+			TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(port)},
+		},
+		PeriodSeconds: 1,
+	}
+}
+
+// BuildKubernetesLoadGeneratorExecutors returns executor of load generator master run as a pod and executor
+// of its agents run as a Kubernetes Job of parallel pods. Agent pods are ready when they listen on agentPort.
+// Agents executor is nil when no agents are requested.
+func BuildKubernetesLoadGeneratorExecutors(agents, agentPort int) (master executor.Executor, agentsExecutor executor.Executor, err error) {
+	clusterConfig := kubernetes.DefaultConfig()
+
+	config := executor.DefaultKubernetesConfig()
+	config.Address = clusterConfig.GetKubeAPIAddress()
+	config.CgroupDriver = clusterConfig.KubeletCgroupDriver
+	config.PodNamePrefix = "swan-lg-master"
+	config.NodeName = kubernetesLoadGeneratorNodeNameFlag.Value()
+	master, err = executor.NewKubernetes(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if agents == 0 {
+		return master, nil, nil
+	}
+
+	jobConfig := executor.DefaultKubernetesJobConfig()
+	jobConfig.Completions = int32(agents)
+	jobConfig.Parallelism = int32(agents)
+	// Agents are enlisted by master when launched, so a retried pod would not get any load to generate.
+	jobConfig.BackoffLimit = 0
+	config.PodNamePrefix = "swan-lg-agent"
+	config.Job = &jobConfig
+	// Master enlists agents by their pod addresses, so they have to listen before it is launched.
+	config.ReadinessProbe = tcpReadinessProbe(agentPort)
+	agentsExecutor, err = executor.NewKubernetes(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return master, agentsExecutor, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"encoding/json"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/pkg/errors"
+)
+
+// PodStatusMetadataKind is a type of metadata under which statuses of pods run in each phase are stored.
+const PodStatusMetadataKind = "pods"
+
+// RecordPodStatuses stores JSON encoded status (QoS class, node, cgroup path, restarts and termination reason)
+// of every pod of the tasks run in the phase. Tasks which are not run in Kubernetes pods are skipped.
+func RecordPodStatuses(metaData metadata.Metadata, phase string, handles ...executor.TaskHandle) error {
+	records := make(map[string]string)
+	for _, handle := range handles {
+		for _, status := range executor.GetPodStatuses(handle) {
+			encoded, err := json.Marshal(status)
+			if err != nil {
+				return errors.Wrapf(err, "cannot encode status of pod %q", status.Name)
+			}
+			records[phase+"; pod "+status.Name] = string(encoded)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return metaData.RecordMap(records, PodStatusMetadataKind)
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/snap"
+	"github.com/intelsdi-x/swan/pkg/utils/sysctl"
+	"github.com/intelsdi-x/swan/pkg/workloads/memcached"
+	"github.com/pkg/errors"
+)
+
+const (
+	sysfsCPU           = "/sys/devices/system/cpu"
+	intelPstateNoTurbo = "/sys/devices/system/cpu/intel_pstate/no_turbo"
+	cpufreqBoost       = "/sys/devices/system/cpu/cpufreq/boost"
+	intelIdleMaxCstate = "/sys/module/intel_idle/parameters/max_cstate"
+	procIRQ            = "/proc/irq"
+	transparentHuge    = "/sys/kernel/mm/transparent_hugepage/enabled"
+	procSwaps          = "/proc/swaps"
+	resctrlInfo        = "/sys/fs/resctrl/info"
+
+	reachabilityTimeout = 2 * time.Second
+)
+
+// PreflightConfig selects what is validated by Preflight.
+type PreflightConfig struct {
+	// Binaries that need to be available in PATH.
+	Binaries []string
+	// BinariesOnHost is set when workloads are run directly on this host. Otherwise they are run
+	// in Kubernetes pods or Docker containers and missing binaries on this host are reported as warning only.
+	BinariesOnHost bool
+	// HPThreads are CPUs reserved for High Priority workload.
+	HPThreads isolation.IntSet
+	// RequireSMT is set when L1 aggressors are run on HyperThreads of High Priority workload.
+	RequireSMT bool
+	// RequireResctrl is set when workloads are isolated with RDT cache allocation, which cannot work without resctrl.
+	RequireResctrl bool
+	// SnapteldAddress in `http://host:port` format.
+	SnapteldAddress string
+	// Ports that need to be free on the host.
+	Ports []int
+}
+
+// DefaultPreflightConfig returns configuration for sensitivity experiment with given High Priority workload based on flags.
+func DefaultPreflightConfig(hpWorkloadName string) PreflightConfig {
+	aggressors := sensitivity.AggressorsFlag.Value()
+	hpThreads, _, _ := sensitivity.GetWorkloadCPUThreads()
+
+	config := PreflightConfig{
+		Binaries:        sensitivity.RequiredBinaries(append([]string{hpWorkloadName}, aggressors...)...),
+		BinariesOnHost:  !experiment.RunOnKubernetesFlag.Value() && !sensitivity.RunOnDockerFlag.Value(),
+		HPThreads:       hpThreads,
+		RequireResctrl:  sensitivity.UsesCacheAllocation(),
+		SnapteldAddress: snap.SnapteldAddress.Value(),
+	}
+	for _, aggressor := range aggressors {
+		if sensitivity.IsL1Aggressor(aggressor) {
+			config.RequireSMT = true
+		}
+	}
+	if hpWorkloadName == sensitivity.Memcached {
+		config.Ports = append(config.Ports, memcached.PortFlag.Value())
+	}
+
+	return config
+}
+
+// Preflight runs all the checks selected by configuration and returns the report.
+// Report is not logged nor recorded.
+func Preflight(config PreflightConfig) Report {
+	binariesSeverity := Warning
+	if config.BinariesOnHost {
+		binariesSeverity = Error
+	}
+	smtSeverity := Info
+	if config.RequireSMT {
+		smtSeverity = Warning
+	}
+	resctrlSeverity := Info
+	if config.RequireResctrl {
+		resctrlSeverity = Error
+	}
+
+	return RunChecks(
+		Check{"binaries", binariesSeverity, func() (string, string, error) { return checkBinaries(config.Binaries) }},
+		Check{"cpu_governor", Warning, checkGovernor},
+		Check{"turbo", Warning, checkTurbo},
+		Check{"c_states", Warning, checkCStates},
+		Check{"irq_affinity", Warning, func() (string, string, error) { return checkIRQAffinity(procIRQ, config.HPThreads) }},
+		Check{"transparent_hugepages", Warning, checkTHP},
+		Check{"swap", Warning, checkSwap},
+		Check{"smt", smtSeverity, checkSMT},
+		Check{"resctrl", resctrlSeverity, checkResctrl},
+		Check{"snapteld", Error, func() (string, string, error) { return checkSnapteld(config.SnapteldAddress) }},
+		Check{"free_ports", Error, func() (string, string, error) { return checkFreePorts(config.Ports) }},
+		Check{"tcp_syncookies", Warning, checkSyncookies},
+		Check{"nofile", Warning, checkLocalNOFILE},
+	)
+}
+
+func readTrimmed(file string) (string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func checkBinaries(binaries []string) (string, string, error) {
+	var missing []string
+	for _, binary := range binaries {
+		if _, err := exec.LookPath(binary); err != nil {
+			missing = append(missing, binary)
+		}
+	}
+	if len(missing) > 0 {
+		return "", "install missing binaries or add them to PATH", errors.Errorf("binaries not found: %s", strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("all %d binaries found", len(binaries)), "", nil
+}
+
+func checkGovernor() (string, string, error) {
+	const remediation = "run 'cpupower frequency-set -g performance' as root"
+	var wrong []string
+	for i := 0; i < runtime.NumCPU(); i++ {
+		governor, err := readTrimmed(path.Join(sysfsCPU, fmt.Sprintf("cpu%d/cpufreq/scaling_governor", i)))
+		if err != nil {
+			return "", "check `dmesg | grep acpi_cpufreq` entry for hardware support", errors.Wrap(err, "cannot read scaling governor")
+		}
+		if governor != "performance" {
+			wrong = append(wrong, fmt.Sprintf("cpu%d=%s", i, governor))
+		}
+	}
+	if len(wrong) > 0 {
+		return "", remediation, errors.Errorf("scaling governor is not 'performance': %s", strings.Join(wrong, ", "))
+	}
+	return "all CPUs use 'performance' governor", "", nil
+}
+
+func checkTurbo() (string, string, error) {
+	if noTurbo, err := readTrimmed(intelPstateNoTurbo); err == nil {
+		if noTurbo != "1" {
+			return "", fmt.Sprintf("run 'echo 1 > %s' as root", intelPstateNoTurbo), errors.New("turbo boost is enabled")
+		}
+		return "turbo boost is disabled", "", nil
+	}
+	if boost, err := readTrimmed(cpufreqBoost); err == nil {
+		if boost != "0" {
+			return "", fmt.Sprintf("run 'echo 0 > %s' as root", cpufreqBoost), errors.New("frequency boost is enabled")
+		}
+		return "frequency boost is disabled", "", nil
+	}
+	return "turbo boost control is not available", "", nil
+}
+
+func checkCStates() (string, string, error) {
+	maxCstate, err := readTrimmed(intelIdleMaxCstate)
+	if err != nil {
+		return "intel_idle driver is not used", "", nil
+	}
+	if maxCstate != "0" && maxCstate != "1" {
+		return "", "add 'intel_idle.max_cstate=1' to kernel command line", errors.Errorf("deep C-states are allowed (intel_idle.max_cstate=%s)", maxCstate)
+	}
+	return fmt.Sprintf("intel_idle.max_cstate=%s", maxCstate), "", nil
+}
+
+// checkIRQAffinity looks for interrupts that may be handled on High Priority workload CPUs.
+func checkIRQAffinity(irqRoot string, hpThreads isolation.IntSet) (string, string, error) {
+	if hpThreads.Empty() {
+		return "no High Priority CPUs to check", "", nil
+	}
+	affinityFiles, err := filepath.Glob(path.Join(irqRoot, "*", "smp_affinity_list"))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "cannot list interrupts in %q", irqRoot)
+	}
+
+	var overlapping []string
+	for _, affinityFile := range affinityFiles {
+		content, err := readTrimmed(affinityFile)
+		if err != nil {
+			continue
+		}
+		cpus, err := isolation.NewIntSetFromRange(content)
+		if err != nil {
+			continue
+		}
+		if !cpus.Intersection(hpThreads).Empty() {
+			overlapping = append(overlapping, filepath.Base(filepath.Dir(affinityFile)))
+		}
+	}
+	if len(overlapping) > 0 {
+		return "", fmt.Sprintf("stop irqbalance and pin interrupts away from CPUs %s (e.g. 'echo <cpus> > %s/<irq>/smp_affinity_list')", hpThreads.AsRangeString(), irqRoot),
+			errors.Errorf("%d interrupts may be handled on High Priority CPUs: %s", len(overlapping), strings.Join(overlapping, ","))
+	}
+	return fmt.Sprintf("no interrupts are handled on CPUs %s", hpThreads.AsRangeString()), "", nil
+}
+
+// parseTHPMode returns selected (bracketed) mode from transparent_hugepage/enabled file.
+func parseTHPMode(content string) string {
+	for _, mode := range strings.Fields(content) {
+		if strings.HasPrefix(mode, "[") && strings.HasSuffix(mode, "]") {
+			return strings.Trim(mode, "[]")
+		}
+	}
+	return ""
+}
+
+func checkTHP() (string, string, error) {
+	content, err := readTrimmed(transparentHuge)
+	if err != nil {
+		return "transparent hugepages are not available", "", nil
+	}
+	mode := parseTHPMode(content)
+	if mode == "always" {
+		return "", fmt.Sprintf("run 'echo madvise > %s' as root", transparentHuge), errors.New("transparent hugepages are always enabled which causes latency spikes during compaction")
+	}
+	return fmt.Sprintf("transparent hugepages mode is %q", mode), "", nil
+}
+
+// parseSwaps returns swap devices from /proc/swaps content.
+func parseSwaps(content string) (devices []string) {
+	lines := strings.Split(content, "\n")
+	// First line is a header.
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			devices = append(devices, fields[0])
+		}
+	}
+	return devices
+}
+
+func checkSwap() (string, string, error) {
+	content, err := readTrimmed(procSwaps)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "cannot read %q", procSwaps)
+	}
+	devices := parseSwaps(content)
+	if len(devices) > 0 {
+		return "", "run 'swapoff -a' as root", errors.Errorf("swap is enabled: %s", strings.Join(devices, ", "))
+	}
+	return "swap is disabled", "", nil
+}
+
+func checkSMT() (string, string, error) {
+	siblings, err := readTrimmed(path.Join(sysfsCPU, "cpu0/topology/thread_siblings_list"))
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot read CPU topology")
+	}
+	threads, err := isolation.NewIntSetFromRange(siblings)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "cannot parse thread siblings %q", siblings)
+	}
+	if len(threads) < 2 {
+		return "", "enable HyperThreading in BIOS or remove L1 aggressors from experiment", errors.New("HyperThreading is not available - L1 aggressors will not share physical cores with High Priority workload")
+	}
+	return fmt.Sprintf("HyperThreading is available (cpu0 siblings: %s)", siblings), "", nil
+}
+
+func checkResctrl() (string, string, error) {
+	if _, err := os.Stat(resctrlInfo); err != nil {
+		return "", "run 'mount -t resctrl resctrl /sys/fs/resctrl' as root on platform supporting RDT", errors.New("resctrl filesystem is not mounted")
+	}
+	return "resctrl filesystem is mounted", "", nil
+}
+
+func checkSnapteld(address string) (string, string, error) {
+	endpoint, err := url.Parse(address)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "cannot parse snapteld address %q", address)
+	}
+	conn, err := net.DialTimeout("tcp", endpoint.Host, reachabilityTimeout)
+	if err != nil {
+		return "", fmt.Sprintf("start snapteld or change %q flag", snap.SnapteldAddress.Name), errors.Wrapf(err, "snapteld is not reachable at %q", address)
+	}
+	conn.Close()
+	return fmt.Sprintf("snapteld is reachable at %q", address), "", nil
+}
+
+func checkFreePorts(ports []int) (string, string, error) {
+	var used []string
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			used = append(used, fmt.Sprintf("%d", port))
+			continue
+		}
+		listener.Close()
+	}
+	if len(used) > 0 {
+		return "", "stop processes listening on these ports (e.g. left over from previous experiment)", errors.Errorf("ports already in use: %s", strings.Join(used, ", "))
+	}
+	return fmt.Sprintf("%d ports are free", len(ports)), "", nil
+}
+
+func checkSyncookies() (string, string, error) {
+	value, err := sysctl.Get("net.ipv4.tcp_syncookies")
+	if err != nil {
+		return "", "", err
+	}
+	if value == "1" {
+		return "", "run 'echo 0 > /proc/sys/net/ipv4/tcp_syncookies' as root", errors.New("net.ipv4.tcp_syncookies is enabled and may lead to SYN flooding detection closing mutilate connections")
+	}
+	return "net.ipv4.tcp_syncookies is disabled", "", nil
+}
+
+func checkLocalNOFILE() (string, string, error) {
+	local := executor.NewLocal()
+	nofile := getNOFILE(local)
+	if nofile < minimalNOFILERequirement {
+		return "", "run 'ulimit -n 10000' or modify /etc/security/limits.conf", errors.Errorf("maximum number of open file descriptors (%d) is lower than required (%d)", nofile, minimalNOFILERequirement)
+	}
+	return fmt.Sprintf("maximum number of open file descriptors is %d", nofile), "", nil
+}
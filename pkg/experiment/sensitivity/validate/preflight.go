@@ -0,0 +1,169 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"encoding/json"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// MetadataKind is a type of metadata under which pre-flight report is stored.
+const MetadataKind = "preflight"
+
+// StrictFlag makes experiment abort when pre-flight checks report warnings.
+var StrictFlag = conf.NewBoolFlag("strict", "Abort experiment when pre-flight environment validation reports warnings (errors always abort the experiment).", false)
+
+// Severity describes how serious is failure of a check.
+type Severity int
+
+const (
+	// Info is reported for checks that do not affect results.
+	Info Severity = iota
+	// Warning is reported for checks that may affect stability of results.
+	Warning
+	// Error is reported for checks that will make experiment fail.
+	Error
+)
+
+// String returns name of severity.
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes severity as its name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Result is an outcome of single pre-flight check.
+type Result struct {
+	Check       string   `json:"check"`
+	Severity    Severity `json:"severity"`
+	Passed      bool     `json:"passed"`
+	Message     string   `json:"message"`
+	Remediation string   `json:"remediation,omitempty"`
+}
+
+// Check is a single pre-flight validation of experiment environment.
+type Check struct {
+	Name     string
+	Severity Severity
+	// Validate returns nil when environment is fine or error describing the problem and remediation hint.
+	Validate func() (message string, remediation string, err error)
+}
+
+// Run runs check and returns its result.
+func (c Check) Run() Result {
+	result := Result{Check: c.Name, Severity: c.Severity}
+	message, remediation, err := c.Validate()
+	if err != nil {
+		result.Message = err.Error()
+		result.Remediation = remediation
+		return result
+	}
+	result.Passed = true
+	result.Message = message
+	return result
+}
+
+// Report holds results of all pre-flight checks.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// RunChecks runs all the checks and returns the report.
+func RunChecks(checks ...Check) Report {
+	report := Report{}
+	for _, check := range checks {
+		result := check.Run()
+		logrus.Debugf("Pre-flight check %q passed: %t (%s)", result.Check, result.Passed, result.Message)
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// Failures returns failed checks with severity equal or higher than given.
+func (r Report) Failures(minimum Severity) (failures []Result) {
+	for _, result := range r.Results {
+		if !result.Passed && result.Severity >= minimum {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Log writes failed checks to the experiment log.
+func (r Report) Log() {
+	for _, result := range r.Failures(Info) {
+		fields := logrus.Fields{"check": result.Check, "severity": result.Severity.String()}
+		message := result.Message
+		if result.Remediation != "" {
+			message += " - " + result.Remediation
+		}
+		switch result.Severity {
+		case Error:
+			logrus.WithFields(fields).Error(message)
+		case Warning:
+			logrus.WithFields(fields).Warn(message)
+		default:
+			logrus.WithFields(fields).Info(message)
+		}
+	}
+}
+
+// Record stores JSON encoded result of each check in metadata.
+func (r Report) Record(metaData metadata.Metadata) error {
+	records := make(map[string]string)
+	for _, result := range r.Results {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return errors.Wrapf(err, "cannot encode result of %q check", result.Check)
+		}
+		records[result.Check] = string(encoded)
+	}
+	return metaData.RecordMap(records, MetadataKind)
+}
+
+// Err returns error when report contains failures that should abort the experiment.
+// In strict mode warnings abort the experiment as well.
+func (r Report) Err(strict bool) error {
+	minimum := Error
+	if strict {
+		minimum = Warning
+	}
+	failures := r.Failures(minimum)
+	if len(failures) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, failure := range failures {
+		names = append(names, failure.Check)
+	}
+	return errors.Errorf("pre-flight validation failed: %v", names)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func passing() (string, string, error) {
+	return "ok", "", nil
+}
+
+func failing() (string, string, error) {
+	return "", "fix it", errors.New("broken")
+}
+
+func TestReport(t *testing.T) {
+	Convey("When running checks with different severities", t, func() {
+		report := RunChecks(
+			Check{"passing", Error, passing},
+			Check{"info", Info, failing},
+			Check{"warning", Warning, failing},
+		)
+
+		So(report.Results, ShouldHaveLength, 3)
+		So(report.Results[0].Passed, ShouldBeTrue)
+		So(report.Results[2].Remediation, ShouldEqual, "fix it")
+
+		Convey("Only warnings and errors should abort in strict mode", func() {
+			So(report.Err(false), ShouldBeNil)
+			So(report.Err(true), ShouldNotBeNil)
+			So(report.Err(true).Error(), ShouldContainSubstring, "warning")
+			So(report.Err(true).Error(), ShouldNotContainSubstring, "info")
+		})
+
+		Convey("Errors should abort also in non strict mode", func() {
+			report := RunChecks(Check{"error", Error, failing})
+			So(report.Err(false), ShouldNotBeNil)
+		})
+
+		Convey("Results should be encoded with severity names", func() {
+			encoded, err := json.Marshal(report.Results[2])
+			So(err, ShouldBeNil)
+			So(string(encoded), ShouldContainSubstring, `"severity":"warning"`)
+			So(string(encoded), ShouldContainSubstring, `"passed":false`)
+		})
+	})
+}
+
+func TestParsers(t *testing.T) {
+	Convey("Transparent hugepages mode should be parsed", t, func() {
+		So(parseTHPMode("always [madvise] never"), ShouldEqual, "madvise")
+		So(parseTHPMode("[always] madvise never"), ShouldEqual, "always")
+		So(parseTHPMode(""), ShouldEqual, "")
+	})
+
+	Convey("Swap devices should be parsed", t, func() {
+		header := "Filename\t\t\t\tType\t\tSize\tUsed\tPriority"
+		So(parseSwaps(header), ShouldBeEmpty)
+		So(parseSwaps(header+"\n/dev/sda2 partition 8388604 0 -1"), ShouldResemble, []string{"/dev/sda2"})
+	})
+}
+
+func TestIRQAffinity(t *testing.T) {
+	Convey("When interrupts are assigned to CPUs", t, func() {
+		irqRoot, err := ioutil.TempDir("", "irq")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(irqRoot)
+
+		for irq, affinity := range map[string]string{"24": "0-1\n", "25": "4\n"} {
+			So(os.Mkdir(path.Join(irqRoot, irq), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(path.Join(irqRoot, irq, "smp_affinity_list"), []byte(affinity), 0644), ShouldBeNil)
+		}
+
+		Convey("Interrupts handled on HP CPUs should be reported", func() {
+			_, remediation, err := checkIRQAffinity(irqRoot, isolation.NewIntSet(1, 2))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "24")
+			So(err.Error(), ShouldNotContainSubstring, "25")
+			So(remediation, ShouldNotBeEmpty)
+		})
+
+		Convey("Check should pass when interrupts are pinned away", func() {
+			_, _, err := checkIRQAffinity(irqRoot, isolation.NewIntSet(2, 3))
+			So(err, ShouldBeNil)
+		})
+	})
+}
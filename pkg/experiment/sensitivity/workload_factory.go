@@ -106,10 +106,41 @@ var (
 	)
 )
 
+// RequiredBinaries returns names of binaries that need to be available on the host to run given workloads.
+func RequiredBinaries(workloadNames ...string) (binaries []string) {
+	for _, name := range workloadNames {
+		switch name {
+		case Memcached:
+			binaries = append(binaries, memcached.DefaultMemcachedConfig().PathToBinary)
+		case Specjbb:
+			binaries = append(binaries, "java")
+		case l1d:
+			binaries = append(binaries, l1data.DefaultL1dConfig().Path)
+		case l1i:
+			binaries = append(binaries, l1instruction.DefaultL1iConfig().Path)
+		case llc:
+			binaries = append(binaries, l3.DefaultL3Config().Path)
+		case membw:
+			binaries = append(binaries, memoryBandwidth.DefaultMemBwConfig().Path)
+		case streambw:
+			binaries = append(binaries, stream.DefaultConfig().Path)
+		case caffeWorkload, caffeWorkloadWithIsolation:
+			binaries = append(binaries, caffe.DefaultConfig().BinaryPath)
+		case stressngL1, strssngL3, stressngMemcpy, stressngStream:
+			binaries = append(binaries, "stress-ng")
+		}
+	}
+	return binaries
+}
+
 // WorkloadFactory is creator for High Priority and Best Effort workloads with
 // default or custom isolation.
 type WorkloadFactory struct {
 	executorFactory ExecutorFactory
+	// hpAddress overrides address HP workload is reached at (e.g. with Kubernetes Service).
+	hpAddress string
+	// skipMetricsSessions disables Snap sessions wrapping Best Effort workloads (e.g. when Snap daemon is not available).
+	skipMetricsSessions bool
 
 	hpIsolation isolation.Decorator
 	l1Isolation isolation.Decorator
@@ -142,6 +173,18 @@ func NewWorkloadFactoryWithIsolation(
 	}
 }
 
+// SetHighPriorityAddress makes High Priority workloads be reached at given address (e.g. cluster IP of
+// Kubernetes Service exposing them) instead of their listening address.
+func (factory *WorkloadFactory) SetHighPriorityAddress(address string) {
+	factory.hpAddress = address
+}
+
+// DisableMetricsSessions makes factory return Best Effort workloads that are not wrapped in Snap sessions
+// collecting their metrics.
+func (factory *WorkloadFactory) DisableMetricsSessions() {
+	factory.skipMetricsSessions = true
+}
+
 // BuildDefaultHighPriorityLauncher builds High Priority workload launcher with predefined isolation.
 func (factory *WorkloadFactory) BuildDefaultHighPriorityLauncher(
 	workloadName string, tags snap.Tags) (launcher executor.Launcher, err error) {
@@ -183,7 +226,11 @@ func (factory *WorkloadFactory) createHighPriorityWorkload(
 
 	switch name {
 	case Memcached:
-		return executor.NewServiceLauncher(memcached.New(exec, memcached.DefaultMemcachedConfig())), nil
+		config := memcached.DefaultMemcachedConfig()
+		if factory.hpAddress != "" {
+			config.IP = factory.hpAddress
+		}
+		return executor.NewServiceLauncher(memcached.New(exec, config)), nil
 	case Specjbb:
 		return executor.NewServiceLauncher(specjbb.NewBackend(exec, specjbb.DefaultSPECjbbBackendConfig())), nil
 	default:
@@ -201,11 +248,13 @@ func (factory *WorkloadFactory) createBestEffortWorkload(
 	}
 
 	var workload executor.Launcher
-	additionalDecorators := factory.getBestEffortAdditionalDecorators(name)
-	exec, err := factory.executorFactory.BuildBestEffortExecutor(isolation, additionalDecorators)
+	exec, err := factory.executorFactory.BuildBestEffortExecutor(isolation)
 	if err != nil {
 		return nil, err
 	}
+	if clones := factory.getBestEffortClones(name); clones != 1 {
+		exec = executor.NewParallel(exec, clones)
+	}
 
 	// Best Effort workloads.
 	switch name {
@@ -216,15 +265,11 @@ func (factory *WorkloadFactory) createBestEffortWorkload(
 	case membw:
 		workload = memoryBandwidth.New(exec, memoryBandwidth.DefaultMemBwConfig())
 	case caffeWorkload:
-		workload, err = caffeinferencesession.NewSessionLauncher(
-			caffe.New(exec, caffe.DefaultConfig()),
-			caffeinferencesession.DefaultConfig())
+		workload, err = factory.newCaffeLauncher(exec, caffe.DefaultConfig())
 	case caffeWorkloadWithIsolation:
 		config := caffe.DefaultConfig()
 		config.Name = "Caffe isolated"
-		workload, err = caffeinferencesession.NewSessionLauncher(
-			caffe.New(exec, config),
-			caffeinferencesession.DefaultConfig())
+		workload, err = factory.newCaffeLauncher(exec, config)
 	case llc:
 		workload = l3.New(exec, l3.DefaultL3Config())
 	case streambw:
@@ -248,6 +293,27 @@ func (factory *WorkloadFactory) createBestEffortWorkload(
 	return workload, err
 }
 
+// newCaffeLauncher returns Caffe wrapped in Snap session that collects inference metrics,
+// unless metrics sessions are disabled.
+func (factory *WorkloadFactory) newCaffeLauncher(exec executor.Executor, config caffe.Config) (executor.Launcher, error) {
+	if factory.skipMetricsSessions {
+		return caffe.New(exec, config), nil
+	}
+	return caffeinferencesession.NewSessionLauncher(
+		caffe.New(exec, config),
+		caffeinferencesession.DefaultConfig())
+}
+
+// IsL1Aggressor returns true for Best Effort workloads that are run on HyperThreads of High Priority workload.
+func IsL1Aggressor(workloadName string) bool {
+	switch workloadName {
+	case l1d, l1i, stressngL1:
+		return true
+	default:
+		return false
+	}
+}
+
 func (factory *WorkloadFactory) getDefaultBestEffortIsolation(workloadName string) isolation.Decorator {
 	switch workloadName {
 	case l1d:
@@ -265,25 +331,18 @@ func (factory *WorkloadFactory) getDefaultBestEffortIsolation(workloadName strin
 	}
 }
 
-func (factory *WorkloadFactory) getBestEffortAdditionalDecorators(workloadName string) isolation.Decorator {
+// getBestEffortClones returns number of processes of Best Effort workload to be run in parallel.
+func (factory *WorkloadFactory) getBestEffortClones(workloadName string) int {
 	switch workloadName {
 	case l1d:
-		if L1dProcessNumber.Value() != 1 {
-			return executor.NewParallel(L1dProcessNumber.Value())
-		}
+		return L1dProcessNumber.Value()
 	case l1i:
-		if L1iProcessNumber.Value() != 1 {
-			return executor.NewParallel(L1iProcessNumber.Value())
-		}
+		return L1iProcessNumber.Value()
 	case llc:
-		if L3ProcessNumber.Value() != 1 {
-			return executor.NewParallel(L3ProcessNumber.Value())
-		}
+		return L3ProcessNumber.Value()
 	case membw:
-		if MembwProcessNumber.Value() != 1 {
-			return executor.NewParallel(MembwProcessNumber.Value())
-		}
+		return MembwProcessNumber.Value()
 	}
 
-	return isolation.Decorators{}
+	return 1
 }
@@ -221,7 +221,11 @@ func (cg *cgroup) Exists() (bool, error) {
 
 func (cg *cgroup) Create() error {
 	_, err := cg.cmdOutput("cgcreate", "-g", cg.Spec())
-	return err
+	if err != nil {
+		return err
+	}
+	executor.RegisterIsolation(cg)
+	return nil
 }
 
 func (cg *cgroup) Destroy(recursive bool) error {
@@ -322,7 +326,12 @@ func (cg *cgroup) SetAndCheck(name string, value string) error {
 }
 
 func (cg *cgroup) Clean() error {
-	return cg.Destroy(true)
+	err := cg.Destroy(true)
+	if err != nil {
+		return err
+	}
+	executor.UnregisterIsolation(cg)
+	return nil
 }
 
 func (cg *cgroup) Decorate(command string) string {
@@ -39,6 +39,11 @@ func (cpu *CPUShares) Decorate(command string) string {
 	return "cgexec -g cpu:" + cpu.name + " " + command
 }
 
+// Shares returns value of cpu.shares set for the cgroup.
+func (cpu *CPUShares) Shares() int {
+	return cpu.shares
+}
+
 // Clean removes the specified cgroup
 func (cpu *CPUShares) Clean() error {
 	cmd := exec.Command("sh", "-c", "cgdelete -g cpu"+":"+cpu.name)
@@ -30,3 +30,16 @@ func (d Decorators) Decorate(command string) string {
 	}
 	return command
 }
+
+//Flatten returns decorators with nested Decorators replaced by their elements, in order they are applied.
+func (d Decorators) Flatten() Decorators {
+	var flat Decorators
+	for _, decorator := range d {
+		if nested, ok := decorator.(Decorators); ok {
+			flat = append(flat, nested.Flatten()...)
+			continue
+		}
+		flat = append(flat, decorator)
+	}
+	return flat
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecoratorsFlatten(t *testing.T) {
+	Convey("When decorators are nested", t, func() {
+		taskset := Taskset{NewIntSet(1)}
+		rdtset := Rdtset{CPURange: "1", Mask: 0xf}
+		decorators := Decorators{Decorators{taskset, Decorators{}}, Decorators{Decorators{rdtset}}}
+
+		Convey("Flattened decorators should keep order and decorate command the same way", func() {
+			So(decorators.Flatten(), ShouldResemble, Decorators{taskset, rdtset})
+			So(decorators.Flatten().Decorate("test"), ShouldEqual, decorators.Decorate("test"))
+		})
+	})
+}
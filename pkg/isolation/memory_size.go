@@ -42,6 +42,11 @@ func (memorySize *MemorySize) Decorate(command string) string {
 	return "cgexec -g memory:" + memorySize.name + " " + command
 }
 
+// Size returns memory limit of the cgroup in bytes.
+func (memorySize *MemorySize) Size() int {
+	return memorySize.size
+}
+
 // Clean removes specified cgroup.
 func (memorySize *MemorySize) Clean() error {
 	cmd := exec.Command("cgdelete", "-g", "memory:"+memorySize.name)
@@ -0,0 +1,619 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8sfake provides fake Kubernetes cluster for unit tests of code talking to the API server.
+// Cluster is a fake clientset with pods and nodes served by the harness: tests drive lifecycle of pods
+// (pending, running, succeeded, failed, evicted, deleted) and nodes, and code under test observes
+// the transitions through regular Get, List and Watch calls as well as pod log streams.
+package k8sfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+const (
+	// DefaultNodeName is a name of the node fake cluster starts with.
+	DefaultNodeName = "fake-node"
+	// DefaultNodeAddress is an address of the node fake cluster starts with.
+	DefaultNodeAddress = "127.0.0.1"
+
+	// EvictedExitCode is an exit code of containers of evicted pods (killed with SIGKILL).
+	EvictedExitCode = 128 + int32(syscall.SIGKILL)
+	// TerminatedExitCode is a default exit code of containers of deleted pods (stopped with SIGTERM).
+	TerminatedExitCode = 128 + int32(syscall.SIGTERM)
+
+	createdPodsBuffer = 100
+)
+
+// Cluster is a fake Kubernetes cluster. It implements kubernetes.Interface, so it can be passed
+// to code expecting clientset. Pods and nodes are served by the cluster, other resources by embedded fake clientset.
+type Cluster struct {
+	*fake.Clientset
+
+	// TerminationExitCode is an exit code of running containers of pods deleted through API.
+	// Set it to 137 to simulate containers killed after grace period.
+	TerminationExitCode int32
+
+	mutex       sync.Mutex
+	pods        map[string]*v1.Pod
+	nodes       []*v1.Node
+	logs        map[string][]logLine
+	watchers    []*podWatcher
+	created     chan string
+	objectCount int
+}
+
+// NewCluster returns fake cluster with one ready node (DefaultNodeName) and no pods.
+func NewCluster() *Cluster {
+	cluster := &Cluster{
+		Clientset:           fake.NewSimpleClientset(),
+		TerminationExitCode: TerminatedExitCode,
+		pods:                map[string]*v1.Pod{},
+		logs:                map[string][]logLine{},
+		created:             make(chan string, createdPodsBuffer),
+	}
+	cluster.AddNode(DefaultNodeName, DefaultNodeAddress)
+
+	cluster.PrependReactor("*", "pods", cluster.reactPods)
+	cluster.PrependReactor("*", "nodes", cluster.reactNodes)
+	cluster.PrependWatchReactor("pods", cluster.watchPods)
+	return cluster
+}
+
+// PodCreated returns channel receiving names of pods created through API.
+func (c *Cluster) PodCreated() <-chan string {
+	return c.created
+}
+
+// Pod returns copy of the pod with given name.
+func (c *Cluster) Pod(name string) (*v1.Pod, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return nil, err
+	}
+	return copyPod(pod), nil
+}
+
+// PodNames returns names of all the pods in the cluster.
+func (c *Cluster) PodNames() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var names []string
+	for name := range c.pods {
+		names = append(names, name)
+	}
+	return names
+}
+
+// AddNode adds ready node with the address.
+func (c *Cluster) AddNode(name, address string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.objectCount++
+	c.nodes = append(c.nodes, &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			UID:               types.UID(fmt.Sprintf("node-%d", c.objectCount)),
+			CreationTimestamp: metav1.Now(),
+		},
+		Status: v1.NodeStatus{
+			Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: address}},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	})
+}
+
+// SetNodeReady changes readiness of the node. Running pods scheduled on the node which is not ready
+// are moved to Unknown phase (as node controller does) and back to Running when node is ready again.
+func (c *Cluster) SetNodeReady(name string, ready bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	node := c.getNode(name)
+	if node == nil {
+		return errors.Errorf("node %q does not exist", name)
+	}
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+
+	for _, pod := range c.pods {
+		if pod.Spec.NodeName != name {
+			continue
+		}
+		switch {
+		case !ready && pod.Status.Phase == v1.PodRunning:
+			pod.Status.Phase = v1.PodUnknown
+			setPodReady(pod, false)
+		case ready && pod.Status.Phase == v1.PodUnknown:
+			pod.Status.Phase = v1.PodRunning
+			setPodReady(pod, true)
+		default:
+			continue
+		}
+		c.notify(watch.Modified, pod)
+	}
+	return nil
+}
+
+// RunPod schedules pending pod on the node (first ready node when pod does not request any)
+// and starts all its containers. Pod becomes Running and ready.
+func (c *Cluster) RunPod(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return err
+	}
+	if pod.Status.Phase != v1.PodPending {
+		return errors.Errorf("pod %q is %s, not pending", name, pod.Status.Phase)
+	}
+	node, err := c.scheduleNode(pod)
+	if err != nil {
+		return err
+	}
+
+	pod.Spec.NodeName = node.Name
+	pod.Status.HostIP = node.Status.Addresses[0].Address
+	pod.Status.PodIP = node.Status.Addresses[0].Address
+	startTime := metav1.Now()
+	pod.Status.StartTime = &startTime
+	pod.Status.Phase = v1.PodRunning
+	for i := range pod.Status.ContainerStatuses {
+		pod.Status.ContainerStatuses[i].State = v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: startTime}}
+		pod.Status.ContainerStatuses[i].Ready = true
+	}
+	setPodReady(pod, !hasReadinessProbe(pod))
+	c.notify(watch.Modified, pod)
+	return nil
+}
+
+// SetPodReady simulates result of readiness probe of the running pod. Pods with readiness probe
+// are not ready until it is called.
+func (c *Cluster) SetPodReady(name string, ready bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return err
+	}
+	if pod.Status.Phase != v1.PodRunning {
+		return errors.Errorf("pod %q is %s, not running", name, pod.Status.Phase)
+	}
+	setPodReady(pod, ready)
+	c.notify(watch.Modified, pod)
+	return nil
+}
+
+// TerminateContainer terminates running container of the pod with exit code.
+// When all the containers are terminated, pod becomes Succeeded (all exit codes are 0) or Failed.
+func (c *Cluster) TerminateContainer(name, container string, exitCode int32) error {
+	return c.killContainer(name, container, exitCode, "")
+}
+
+// OOMKillContainer simulates kernel OOM killer terminating running container of the pod.
+// Container is killed with SIGKILL and its termination reason is OOMKilled.
+func (c *Cluster) OOMKillContainer(name, container string) error {
+	return c.killContainer(name, container, EvictedExitCode, "OOMKilled")
+}
+
+func (c *Cluster) killContainer(name, container string, exitCode int32, reason string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return err
+	}
+	status := containerStatus(pod, container)
+	if status == nil || status.State.Running == nil {
+		return errors.Errorf("container %q of pod %q is not running", container, name)
+	}
+	terminateContainer(status, exitCode, reason)
+	updatePhase(pod)
+	c.notify(watch.Modified, pod)
+	return nil
+}
+
+// SucceedPod terminates all running containers of the pod with exit code 0.
+func (c *Cluster) SucceedPod(name string) error {
+	return c.terminatePod(name, 0, "", "")
+}
+
+// FailPod terminates all running containers of the pod with exit code.
+func (c *Cluster) FailPod(name string, exitCode int32) error {
+	return c.terminatePod(name, exitCode, "", "")
+}
+
+// EvictPod simulates kubelet eviction of the pod: its containers are killed and pod becomes Failed with Evicted reason.
+// Evicted pod is not deleted from API server.
+func (c *Cluster) EvictPod(name string) error {
+	return c.terminatePod(name, EvictedExitCode, "Evicted", "The node was low on resource: memory.")
+}
+
+func (c *Cluster) terminatePod(name string, exitCode int32, reason, message string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return err
+	}
+	if pod.Status.Phase != v1.PodRunning && pod.Status.Phase != v1.PodUnknown {
+		return errors.Errorf("pod %q is %s, not running", name, pod.Status.Phase)
+	}
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].State.Running != nil {
+			terminateContainer(&pod.Status.ContainerStatuses[i], exitCode, reason)
+		}
+	}
+	updatePhase(pod)
+	if reason != "" {
+		pod.Status.Phase = v1.PodFailed
+		pod.Status.Reason = reason
+		pod.Status.Message = message
+	}
+	c.notify(watch.Modified, pod)
+	return nil
+}
+
+// DeletePod removes the pod from API server, as if it was deleted by another client.
+func (c *Cluster) DeletePod(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return err
+	}
+	c.deletePod(pod)
+	return nil
+}
+
+// deletePod terminates running containers with TerminationExitCode and removes pod.
+func (c *Cluster) deletePod(pod *v1.Pod) {
+	deletionTimestamp := metav1.Now()
+	pod.DeletionTimestamp = &deletionTimestamp
+	if pod.Status.Phase == v1.PodRunning || pod.Status.Phase == v1.PodUnknown {
+		for i := range pod.Status.ContainerStatuses {
+			if pod.Status.ContainerStatuses[i].State.Running != nil {
+				terminateContainer(&pod.Status.ContainerStatuses[i], c.TerminationExitCode, "")
+			}
+		}
+		updatePhase(pod)
+		c.notify(watch.Modified, pod)
+	}
+	delete(c.pods, pod.Name)
+	c.notify(watch.Deleted, pod)
+}
+
+func (c *Cluster) getPod(name string) (*v1.Pod, error) {
+	pod, ok := c.pods[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1.Resource("pods"), name)
+	}
+	return pod, nil
+}
+
+func (c *Cluster) getNode(name string) *v1.Node {
+	for _, node := range c.nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// scheduleNode returns node requested by the pod or first ready node.
+func (c *Cluster) scheduleNode(pod *v1.Pod) (*v1.Node, error) {
+	if pod.Spec.NodeName != "" {
+		node := c.getNode(pod.Spec.NodeName)
+		if node == nil {
+			return nil, errors.Errorf("node %q requested by pod %q does not exist", pod.Spec.NodeName, pod.Name)
+		}
+		return node, nil
+	}
+	for _, node := range c.nodes {
+		if isNodeReady(node) {
+			return node, nil
+		}
+	}
+	return nil, errors.Errorf("there is no ready node to run pod %q", pod.Name)
+}
+
+// reactPods serves pod requests.
+func (c *Cluster) reactPods(action clienttesting.Action) (bool, runtime.Object, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if action.GetSubresource() != "" {
+		return true, nil, apierrors.NewMethodNotSupported(v1.Resource("pods/"+action.GetSubresource()), action.GetVerb())
+	}
+
+	switch action.GetVerb() {
+	case "create":
+		pod, ok := action.(clienttesting.CreateAction).GetObject().(*v1.Pod)
+		if !ok {
+			return true, nil, errors.Errorf("unexpected object %T", action.(clienttesting.CreateAction).GetObject())
+		}
+		created, err := c.createPod(action.GetNamespace(), pod)
+		return true, created, err
+
+	case "get":
+		pod, err := c.podInNamespace(action.GetNamespace(), action.(clienttesting.GetAction).GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		return true, copyPod(pod), nil
+
+	case "list":
+		selector := action.(clienttesting.ListAction).GetListRestrictions().Labels
+		list := &v1.PodList{}
+		for _, pod := range c.pods {
+			if matches(pod, action.GetNamespace(), selector) {
+				list.Items = append(list.Items, *copyPod(pod))
+			}
+		}
+		return true, list, nil
+
+	case "update":
+		updated, ok := action.(clienttesting.UpdateAction).GetObject().(*v1.Pod)
+		if !ok {
+			return true, nil, errors.Errorf("unexpected object %T", action.(clienttesting.UpdateAction).GetObject())
+		}
+		pod, err := c.podInNamespace(action.GetNamespace(), updated.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		// Status is owned by the fake cluster.
+		pod.Spec = copyPod(updated).Spec
+		pod.Labels = updated.Labels
+		pod.Annotations = updated.Annotations
+		c.notify(watch.Modified, pod)
+		return true, copyPod(pod), nil
+
+	case "delete":
+		pod, err := c.podInNamespace(action.GetNamespace(), action.(clienttesting.DeleteAction).GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		c.deletePod(pod)
+		return true, nil, nil
+	}
+	return true, nil, apierrors.NewMethodNotSupported(v1.Resource("pods"), action.GetVerb())
+}
+
+// createPod stores new pending pod.
+func (c *Cluster) createPod(namespace string, manifest *v1.Pod) (*v1.Pod, error) {
+	pod := copyPod(manifest)
+	c.objectCount++
+	if pod.Name == "" {
+		pod.Name = fmt.Sprintf("%s%d", pod.GenerateName, c.objectCount)
+	}
+	if _, exists := c.pods[pod.Name]; exists {
+		return nil, apierrors.NewAlreadyExists(v1.Resource("pods"), pod.Name)
+	}
+	pod.Namespace = namespace
+	pod.UID = types.UID(fmt.Sprintf("pod-%d", c.objectCount))
+	pod.CreationTimestamp = metav1.Now()
+	pod.Status = v1.PodStatus{Phase: v1.PodPending}
+	for _, container := range pod.Spec.Containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:  container.Name,
+			Image: container.Image,
+			State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+		})
+	}
+	c.pods[pod.Name] = pod
+	c.notify(watch.Added, pod)
+
+	select {
+	case c.created <- pod.Name:
+	default:
+	}
+	return copyPod(pod), nil
+}
+
+func (c *Cluster) podInNamespace(namespace, name string) (*v1.Pod, error) {
+	pod, err := c.getPod(name)
+	if err != nil {
+		return nil, err
+	}
+	if namespace != "" && pod.Namespace != namespace {
+		return nil, apierrors.NewNotFound(v1.Resource("pods"), name)
+	}
+	return pod, nil
+}
+
+// reactNodes serves node requests.
+func (c *Cluster) reactNodes(action clienttesting.Action) (bool, runtime.Object, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch action.GetVerb() {
+	case "get":
+		name := action.(clienttesting.GetAction).GetName()
+		node := c.getNode(name)
+		if node == nil {
+			return true, nil, apierrors.NewNotFound(v1.Resource("nodes"), name)
+		}
+		return true, copyNode(node), nil
+
+	case "list":
+		list := &v1.NodeList{}
+		for _, node := range c.nodes {
+			list.Items = append(list.Items, *copyNode(node))
+		}
+		return true, list, nil
+	}
+	return true, nil, apierrors.NewMethodNotSupported(v1.Resource("nodes"), action.GetVerb())
+}
+
+// watchPods opens watch over pods. Like API server, it starts with Added events for existing pods.
+func (c *Cluster) watchPods(action clienttesting.Action) (bool, watch.Interface, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	watchAction, ok := action.(clienttesting.WatchAction)
+	if !ok {
+		return true, nil, errors.Errorf("unexpected action %T", action)
+	}
+	watcher := newPodWatcher(action.GetNamespace(), watchAction.GetWatchRestrictions().Labels)
+	for _, pod := range c.pods {
+		watcher.send(watch.Added, pod)
+	}
+	c.watchers = append(c.watchers, watcher)
+	return true, watcher, nil
+}
+
+// notify sends event to watchers. Stopped watchers are dropped.
+func (c *Cluster) notify(eventType watch.EventType, pod *v1.Pod) {
+	c.objectCount++
+	pod.ResourceVersion = fmt.Sprintf("%d", c.objectCount)
+
+	watchers := c.watchers[:0]
+	for _, watcher := range c.watchers {
+		if watcher.isStopped() {
+			continue
+		}
+		watcher.send(eventType, pod)
+		watchers = append(watchers, watcher)
+	}
+	c.watchers = watchers
+}
+
+func matches(pod *v1.Pod, namespace string, selector labels.Selector) bool {
+	if namespace != "" && pod.Namespace != namespace {
+		return false
+	}
+	return selector == nil || selector.Matches(labels.Set(pod.Labels))
+}
+
+func containerStatus(pod *v1.Pod, container string) *v1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == container {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+func terminateContainer(status *v1.ContainerStatus, exitCode int32, reason string) {
+	if reason == "" {
+		reason = "Completed"
+		if exitCode != 0 {
+			reason = "Error"
+		}
+	}
+	var startedAt metav1.Time
+	if status.State.Running != nil {
+		startedAt = status.State.Running.StartedAt
+	}
+	status.State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+		ExitCode:   exitCode,
+		Reason:     reason,
+		StartedAt:  startedAt,
+		FinishedAt: metav1.Now(),
+	}}
+	status.Ready = false
+}
+
+// updatePhase sets phase of the pod with all containers terminated, as kubelet does for pods which are never restarted.
+func updatePhase(pod *v1.Pod) {
+	phase := v1.PodSucceeded
+	for _, status := range pod.Status.ContainerStatuses {
+		switch {
+		case status.State.Terminated == nil:
+			return
+		case status.State.Terminated.ExitCode != 0:
+			phase = v1.PodFailed
+		}
+	}
+	pod.Status.Phase = phase
+	setPodReady(pod, false)
+}
+
+func setPodReady(pod *v1.Pod, ready bool) {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == v1.PodReady {
+			pod.Status.Conditions[i].Status = status
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, v1.PodCondition{
+		Type:               v1.PodReady,
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+}
+
+func hasReadinessProbe(pod *v1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.ReadinessProbe != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// copyPod returns deep copy of the pod, so objects returned to clients are not shared with the cluster.
+func copyPod(pod *v1.Pod) *v1.Pod {
+	copied := &v1.Pod{}
+	deepCopy(pod, copied)
+	return copied
+}
+
+func copyNode(node *v1.Node) *v1.Node {
+	copied := &v1.Node{}
+	deepCopy(node, copied)
+	return copied
+}
+
+func deepCopy(in, out interface{}) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sfake
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const eventTimeout = 5 * time.Second
+
+func nextEvent(watcher watch.Interface) (watch.EventType, v1.PodPhase) {
+	select {
+	case event, ok := <-watcher.ResultChan():
+		if !ok {
+			return watch.Error, ""
+		}
+		return event.Type, event.Object.(*v1.Pod).Status.Phase
+	case <-time.After(eventTimeout):
+		return "", ""
+	}
+}
+
+func TestCluster(t *testing.T) {
+	Convey("When pod is created in fake cluster", t, func() {
+		cluster := NewCluster()
+		podsAPI := cluster.CoreV1().Pods(v1.NamespaceDefault)
+
+		_, err := podsAPI.Create(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "task", Labels: map[string]string{"name": "task"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "swan"}, {Name: "sidecar"}}},
+		})
+		So(err, ShouldBeNil)
+		So(<-cluster.PodCreated(), ShouldEqual, "task")
+
+		watcher, err := podsAPI.Watch(metav1.ListOptions{LabelSelector: "name=task"})
+		So(err, ShouldBeNil)
+		defer watcher.Stop()
+
+		Convey("Watch should start with pending pod", func() {
+			eventType, phase := nextEvent(watcher)
+			So(eventType, ShouldEqual, watch.Added)
+			So(phase, ShouldEqual, v1.PodPending)
+
+			Convey("Pod should be running on the node after it is started", func() {
+				So(cluster.RunPod("task"), ShouldBeNil)
+				eventType, phase := nextEvent(watcher)
+				So(eventType, ShouldEqual, watch.Modified)
+				So(phase, ShouldEqual, v1.PodRunning)
+
+				pod, err := podsAPI.Get("task", metav1.GetOptions{})
+				So(err, ShouldBeNil)
+				So(pod.Spec.NodeName, ShouldEqual, DefaultNodeName)
+				So(pod.Status.HostIP, ShouldEqual, DefaultNodeAddress)
+
+				Convey("Pod should succeed when all containers exit with 0", func() {
+					So(cluster.TerminateContainer("task", "sidecar", 0), ShouldBeNil)
+					_, phase := nextEvent(watcher)
+					So(phase, ShouldEqual, v1.PodRunning)
+
+					So(cluster.SucceedPod("task"), ShouldBeNil)
+					_, phase = nextEvent(watcher)
+					So(phase, ShouldEqual, v1.PodSucceeded)
+				})
+
+				Convey("Pod should fail when a container fails", func() {
+					So(cluster.FailPod("task", 1), ShouldBeNil)
+					_, phase := nextEvent(watcher)
+					So(phase, ShouldEqual, v1.PodFailed)
+				})
+
+				Convey("Evicted pod should fail, but stay in API server", func() {
+					So(cluster.EvictPod("task"), ShouldBeNil)
+					_, phase := nextEvent(watcher)
+					So(phase, ShouldEqual, v1.PodFailed)
+
+					pod, err := podsAPI.Get("task", metav1.GetOptions{})
+					So(err, ShouldBeNil)
+					So(pod.Status.Reason, ShouldEqual, "Evicted")
+					So(pod.Status.ContainerStatuses[0].State.Terminated.ExitCode, ShouldEqual, EvictedExitCode)
+				})
+
+				Convey("Pod should be in unknown phase when its node is not ready", func() {
+					So(cluster.SetNodeReady(DefaultNodeName, false), ShouldBeNil)
+					_, phase := nextEvent(watcher)
+					So(phase, ShouldEqual, v1.PodUnknown)
+
+					nodes, err := cluster.CoreV1().Nodes().List(metav1.ListOptions{})
+					So(err, ShouldBeNil)
+					So(nodes.Items, ShouldHaveLength, 1)
+					So(nodes.Items[0].Status.Conditions[0].Status, ShouldEqual, v1.ConditionFalse)
+				})
+
+				Convey("Deleted pod should be terminated and removed", func() {
+					So(podsAPI.Delete("task", &metav1.DeleteOptions{}), ShouldBeNil)
+					eventType, phase := nextEvent(watcher)
+					So(eventType, ShouldEqual, watch.Modified)
+					So(phase, ShouldEqual, v1.PodFailed)
+					eventType, _ = nextEvent(watcher)
+					So(eventType, ShouldEqual, watch.Deleted)
+
+					_, err := podsAPI.Get("task", metav1.GetOptions{})
+					So(apierrors.IsNotFound(err), ShouldBeTrue)
+				})
+
+				Convey("Followed logs should be streamed until container terminates", func() {
+					So(cluster.WriteLog("task", "swan", "first\n"), ShouldBeNil)
+					logs, err := cluster.StreamPodLogs(v1.NamespaceDefault, "task", &v1.PodLogOptions{Container: "swan", Follow: true})
+					So(err, ShouldBeNil)
+					defer logs.Close()
+
+					So(cluster.WriteLog("task", "swan", "second\n"), ShouldBeNil)
+					So(cluster.TerminateContainer("task", "swan", 0), ShouldBeNil)
+					output, err := ioutil.ReadAll(logs)
+					So(err, ShouldBeNil)
+					So(string(output), ShouldEqual, "first\nsecond\n")
+				})
+			})
+		})
+	})
+}
+
+func TestClusterReadinessProbe(t *testing.T) {
+	Convey("When pod with readiness probe is run in fake cluster", t, func() {
+		cluster := NewCluster()
+		podsAPI := cluster.CoreV1().Pods(v1.NamespaceDefault)
+
+		_, err := podsAPI.Create(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "probed"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "swan", ReadinessProbe: &v1.Probe{}}}},
+		})
+		So(err, ShouldBeNil)
+		So(cluster.RunPod("probed"), ShouldBeNil)
+
+		podReady := func() v1.ConditionStatus {
+			pod, err := podsAPI.Get("probed", metav1.GetOptions{})
+			So(err, ShouldBeNil)
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == v1.PodReady {
+					return condition.Status
+				}
+			}
+			return v1.ConditionUnknown
+		}
+
+		Convey("Pod should not be ready until its probe succeeds", func() {
+			So(podReady(), ShouldEqual, v1.ConditionFalse)
+			So(cluster.SetPodReady("probed", true), ShouldBeNil)
+			So(podReady(), ShouldEqual, v1.ConditionTrue)
+		})
+
+		Convey("Readiness of pod which is not running cannot be changed", func() {
+			So(cluster.FailPod("probed", 1), ShouldBeNil)
+			So(cluster.SetPodReady("probed", true), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sfake
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// followPollInterval is a time between checks for new lines of followed logs.
+const followPollInterval = 10 * time.Millisecond
+
+// logLine is a line written by container with time of writing.
+type logLine struct {
+	timestamp time.Time
+	text      string
+}
+
+// WriteLog appends text to logs of the container of the pod. Every line is timestamped with current time.
+func (c *Cluster) WriteLog(name, container, text string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.getPod(name)
+	if err != nil {
+		return err
+	}
+	if containerStatus(pod, container) == nil {
+		return errors.Errorf("pod %q has no container %q", name, container)
+	}
+
+	key := logsKey(name, container)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			c.logs[key] = append(c.logs[key], logLine{timestamp: time.Now().UTC(), text: line})
+		}
+	}
+	return nil
+}
+
+// StreamPodLogs implements executor.PodLogsStreamer, because log requests of fake clientset cannot be streamed.
+// Logs of running container are followed until the container terminates or the pod is deleted.
+// Fake containers are never restarted, so logs of previous instances are not available.
+func (c *Cluster) StreamPodLogs(namespace, name string, options *v1.PodLogOptions) (io.ReadCloser, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pod, err := c.podInNamespace(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	container := options.Container
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+	status := containerStatus(pod, container)
+	switch {
+	case status == nil:
+		return nil, apierrors.NewBadRequest("container " + container + " is not valid for pod " + name)
+	case options.Previous:
+		return nil, apierrors.NewBadRequest("previous terminated container " + container + " in pod " + name + " not found")
+	case status.State.Waiting != nil:
+		return nil, apierrors.NewBadRequest("container " + container + " in pod " + name + " is waiting to start")
+	}
+
+	var sinceTime time.Time
+	if options.SinceTime != nil {
+		sinceTime = options.SinceTime.Time
+	}
+	lines, finished := c.logsFrom(name, container, 0)
+	if !options.Follow || finished {
+		return ioutil.NopCloser(strings.NewReader(formatLogs(lines, sinceTime, options.Timestamps))), nil
+	}
+
+	reader, writer := io.Pipe()
+	go c.followLogs(name, container, lines, sinceTime, options.Timestamps, writer)
+	return reader, nil
+}
+
+// followLogs writes lines of container logs as they appear until the container terminates or reader is closed.
+func (c *Cluster) followLogs(name, container string, lines []logLine, sinceTime time.Time, timestamps bool, writer *io.PipeWriter) {
+	written := 0
+	for {
+		if _, err := io.WriteString(writer, formatLogs(lines, sinceTime, timestamps)); err != nil {
+			// Reader has been closed.
+			return
+		}
+		written += len(lines)
+
+		var finished bool
+		c.mutex.Lock()
+		lines, finished = c.logsFrom(name, container, written)
+		c.mutex.Unlock()
+		if finished && len(lines) == 0 {
+			writer.Close()
+			return
+		}
+		if len(lines) == 0 {
+			time.Sleep(followPollInterval)
+		}
+	}
+}
+
+// logsFrom returns lines of container logs starting with given one and whether no more lines will be written.
+func (c *Cluster) logsFrom(name, container string, from int) (lines []logLine, finished bool) {
+	logs := c.logs[logsKey(name, container)]
+	if from < len(logs) {
+		lines = logs[from:]
+	}
+	pod, ok := c.pods[name]
+	if !ok {
+		return lines, true
+	}
+	status := containerStatus(pod, container)
+	return lines, status == nil || status.State.Terminated != nil
+}
+
+func formatLogs(lines []logLine, sinceTime time.Time, timestamps bool) string {
+	var output []string
+	for _, line := range lines {
+		if line.timestamp.Before(sinceTime) {
+			continue
+		}
+		if timestamps {
+			output = append(output, line.timestamp.Format(time.RFC3339Nano)+" "+line.text)
+		} else {
+			output = append(output, line.text)
+		}
+	}
+	return strings.Join(output, "")
+}
+
+func logsKey(pod, container string) string {
+	return pod + "/" + container
+}
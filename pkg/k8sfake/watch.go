@@ -0,0 +1,112 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sfake
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// podWatcher implements watch.Interface over pods matching selector.
+// Events are queued without limit, so the cluster never blocks on slow or stopped clients.
+type podWatcher struct {
+	namespace string
+	selector  labels.Selector
+
+	result  chan watch.Event
+	pending chan struct{}
+	stopped chan struct{}
+
+	mutex    sync.Mutex
+	queue    []watch.Event
+	stopOnce sync.Once
+}
+
+func newPodWatcher(namespace string, selector labels.Selector) *podWatcher {
+	watcher := &podWatcher{
+		namespace: namespace,
+		selector:  selector,
+		result:    make(chan watch.Event),
+		pending:   make(chan struct{}, 1),
+		stopped:   make(chan struct{}),
+	}
+	go watcher.deliver()
+	return watcher
+}
+
+// ResultChan implements watch.Interface.
+func (w *podWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *podWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopped)
+	})
+}
+
+func (w *podWatcher) isStopped() bool {
+	select {
+	case <-w.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// send queues event with copy of the pod when the pod matches the watch.
+func (w *podWatcher) send(eventType watch.EventType, pod *v1.Pod) {
+	if !matches(pod, w.namespace, w.selector) {
+		return
+	}
+	w.mutex.Lock()
+	w.queue = append(w.queue, watch.Event{Type: eventType, Object: copyPod(pod)})
+	w.mutex.Unlock()
+
+	select {
+	case w.pending <- struct{}{}:
+	default:
+	}
+}
+
+// deliver passes queued events to result channel until watcher is stopped.
+func (w *podWatcher) deliver() {
+	defer close(w.result)
+	for {
+		w.mutex.Lock()
+		if len(w.queue) == 0 {
+			w.mutex.Unlock()
+			select {
+			case <-w.pending:
+				continue
+			case <-w.stopped:
+				return
+			}
+		}
+		event := w.queue[0]
+		w.queue = w.queue[1:]
+		w.mutex.Unlock()
+
+		select {
+		case w.result <- event:
+		case <-w.stopped:
+			return
+		}
+	}
+}
@@ -16,6 +16,9 @@
 package k8sports
 
 import (
+	"fmt"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/pkg/api"
 	"k8s.io/client-go/pkg/api/v1"
@@ -140,3 +143,36 @@ func GetPodQOS(pod *v1.Pod) api.PodQOSClass {
 
 	return api.PodQOSBurstable
 }
+
+// GetPodQOSClass returns QoS class assigned to the pod by API server or, when it is not assigned yet, computed from pod resources.
+func GetPodQOSClass(pod *v1.Pod) string {
+	if pod.Status.QOSClass != "" {
+		return string(pod.Status.QOSClass)
+	}
+	return string(GetPodQOS(pod))
+}
+
+// PodCgroupPath returns path of the cgroup kubelet creates for the pod, relative to cgroup hierarchy root.
+// Cgroups are named differently by "systemd" and "cgroupfs" kubelet cgroup drivers.
+// Empty path is returned for pods without UID (not created yet).
+func PodCgroupPath(pod *v1.Pod, cgroupDriver string) string {
+	if pod.UID == "" {
+		return ""
+	}
+	qos := strings.ToLower(GetPodQOSClass(pod))
+	guaranteed := qos == strings.ToLower(string(v1.PodQOSGuaranteed))
+	uid := string(pod.UID)
+
+	if cgroupDriver == "systemd" {
+		uid = strings.Replace(uid, "-", "_", -1)
+		if guaranteed {
+			return fmt.Sprintf("kubepods.slice/kubepods-pod%s.slice", uid)
+		}
+		return fmt.Sprintf("kubepods.slice/kubepods-%s.slice/kubepods-%s-pod%s.slice", qos, qos, uid)
+	}
+
+	if guaranteed {
+		return fmt.Sprintf("kubepods/pod%s", uid)
+	}
+	return fmt.Sprintf("kubepods/%s/pod%s", qos, uid)
+}
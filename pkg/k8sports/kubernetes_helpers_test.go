@@ -245,3 +245,30 @@ func TestGetPodQOS(t *testing.T) {
 	})
 
 }
+
+func TestPodCgroupPath(t *testing.T) {
+	Convey("When pod is created by kubelet", t, func() {
+		pod := newPod("BestEffort-Pod", []v1.Container{newContainer("BestEffort-Container", newRes("", ""), newRes("", ""))})
+		pod.UID = "0f3e-41aa"
+
+		Convey("QoS class computed from resources should be returned until it is assigned", func() {
+			So(GetPodQOSClass(pod), ShouldEqual, "BestEffort")
+			pod.Status.QOSClass = v1.PodQOSBurstable
+			So(GetPodQOSClass(pod), ShouldEqual, "Burstable")
+		})
+
+		Convey("Cgroup path should depend on QoS class and cgroup driver", func() {
+			So(PodCgroupPath(pod, "cgroupfs"), ShouldEqual, "kubepods/besteffort/pod0f3e-41aa")
+			So(PodCgroupPath(pod, "systemd"), ShouldEqual, "kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod0f3e_41aa.slice")
+
+			pod.Status.QOSClass = v1.PodQOSGuaranteed
+			So(PodCgroupPath(pod, "cgroupfs"), ShouldEqual, "kubepods/pod0f3e-41aa")
+			So(PodCgroupPath(pod, "systemd"), ShouldEqual, "kubepods.slice/kubepods-pod0f3e_41aa.slice")
+		})
+
+		Convey("Cgroup path of pod without UID should be empty", func() {
+			pod.UID = ""
+			So(PodCgroupPath(pod, "cgroupfs"), ShouldBeEmpty)
+		})
+	})
+}
@@ -34,11 +34,21 @@ func getReadyNodes(k8sAPIAddress string) ([]v1.Node, error) {
 		return nil, errors.Wrapf(err, "could not create new Kubernetes client on %q", k8sAPIAddress)
 	}
 
-	nodes, err := k8sClientset.Nodes().List(metav1.ListOptions{})
+	readyNodes, err := getReadyNodesFromClientset(k8sClientset)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not obtain Kubernetes node list on %q", k8sAPIAddress)
 	}
 
+	return readyNodes, nil
+}
+
+// getReadyNodesFromClientset returns nodes which are ready to run pods.
+func getReadyNodesFromClientset(clientset kubernetes.Interface) ([]v1.Node, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
 	var readyNodes []v1.Node
 	for _, node := range nodes.Items {
 		for _, condition := range node.Status.Conditions {
@@ -0,0 +1,454 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/intelsdi-x/swan/pkg/utils/netutil"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	kubeadmManifestsDir      = "/etc/kubernetes/manifests"
+	kubeadmPKIDir            = "/etc/kubernetes/pki"
+	kubeadmAdminKubeconfig   = "/etc/kubernetes/admin.conf"
+	kubeadmKubeletKubeconfig = "/etc/kubernetes/kubelet.conf"
+	bridgeNetworkConfigPath  = "/etc/cni/net.d/10-swan-bridge.conflist"
+
+	// kubeadmCommandTimeout is a time after which kubeadm phase or cleanup command is considered hanging.
+	kubeadmCommandTimeout = 5 * time.Minute
+)
+
+// getReadyNodesFromKubeconfigFunc returns ready nodes of the cluster reached with kubeconfig.
+type getReadyNodesFromKubeconfigFunc func(kubeconfig string) ([]v1.Node, error)
+
+// kubeadm launches single-node cluster bootstrapped with kubeadm phases. Etcd and control plane
+// components are static pods run by kubelet, which is the only process launched directly.
+type kubeadm struct {
+	*k8s
+	getReadyNodesFromKubeconfig getReadyNodesFromKubeconfigFunc // For mocking purposes.
+
+	// Node changes made by the launcher, so that teardown reverts only them.
+	createdConfigDir  bool
+	writtenFiles      []string
+	createdBridge     bool
+	ranKubeadm        bool
+	writtenKubeconfig bool
+}
+
+// kubeadmNodeState describes Kubernetes artifacts found on the node before the cluster is bootstrapped.
+type kubeadmNodeState struct {
+	manifests bool // Static pod manifests are present.
+	kubelet   bool // Kubelet is running.
+	configDir bool // Directory for kubeadm and kubelet configuration exists.
+	bridge    bool // Bridge of pod network exists.
+}
+
+// NewKubeadm returns a launcher of single-node Kubernetes cluster bootstrapped with kubeadm on host of the executor.
+// Cluster is described declaratively (kubeadm and kubelet configuration files generated from the config),
+// certificates and etcd are created by kubeadm. Launcher refuses to bootstrap the cluster on node which already
+// runs kubelet or has static pod manifests. Stopping the cluster resets the node with "kubeadm reset" and removes
+// files created by the launcher. Admin kubeconfig of the cluster is written to config.Kubeconfig.
+func NewKubeadm(exec executor.Executor, config Config) executor.Launcher {
+	return &kubeadm{
+		k8s: &k8s{
+			master:      exec,
+			minion:      exec,
+			config:      config,
+			isListening: netutil.IsListening,
+		},
+		getReadyNodesFromKubeconfig: getReadyNodesFromKubeconfig,
+	}
+}
+
+// String returns human readable name for job.
+func (m *kubeadm) String() string {
+	return "Kubernetes [kubeadm single-node]"
+}
+
+// Launch bootstraps the cluster. It returns the cluster represented as a Task Handle instance.
+// Node is reset when cluster cannot be started.
+func (m *kubeadm) Launch() (handle executor.TaskHandle, err error) {
+	for retry := uint64(0); retry <= m.config.RetryCount; retry++ {
+		handle, err = m.tryLaunchCluster()
+		if err != nil {
+			log.Warningf("could not bootstrap Kubernetes cluster with kubeadm: %q. Retry number: %d", err.Error(), retry)
+			continue
+		}
+		return handle, nil
+	}
+	log.Errorf("Could not bootstrap Kubernetes cluster with kubeadm: %q", err.Error())
+	return nil, err
+}
+
+func (m *kubeadm) tryLaunchCluster() (executor.TaskHandle, error) {
+	if m.config.Kubeconfig == "" {
+		return nil, errors.New("path of admin kubeconfig is required to bootstrap cluster with kubeadm")
+	}
+	err := m.config.validateKubeletPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	// Node is not touched (nor reset) when Kubernetes is already present there.
+	state, err := m.getNodeState()
+	if err != nil {
+		return nil, err
+	}
+	if state.manifests {
+		return nil, errors.Errorf("static pod manifests found in %q on %q: refusing to bootstrap cluster with kubeadm on node with existing Kubernetes", kubeadmManifestsDir, m.master)
+	}
+	if state.kubelet {
+		return nil, errors.Errorf("kubelet is already running on %q: refusing to bootstrap cluster with kubeadm on node with existing Kubernetes", m.master)
+	}
+	m.createdConfigDir = !state.configDir
+	m.createdBridge = !state.bridge
+
+	// Node is reset only once: by failed launch, by stopping the cluster or when experiment is interrupted.
+	teardown := &onceTeardown{teardown: m.teardown}
+	executor.RegisterCleanup(fmt.Sprintf("Kubernetes node %q bootstrapped with kubeadm", m.master), teardown.run)
+
+	handle, err := m.bootstrap(teardown.run)
+	if err != nil {
+		if teardownErr := teardown.run(); teardownErr != nil {
+			log.Warningf("Errors while resetting Kubernetes node: %v", teardownErr)
+		}
+		return nil, err
+	}
+	return handle, nil
+}
+
+// bootstrap writes configuration files, runs kubeadm phases and kubelet and waits until the node is ready.
+// Returned cluster runs teardown when it is stopped.
+func (m *kubeadm) bootstrap(teardown func() error) (executor.TaskHandle, error) {
+	files, err := m.configFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		m.writtenFiles = append(m.writtenFiles, file.path)
+		_, err = m.run(writeFileCommand(file.path, file.content))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot write %q", file.path)
+		}
+	}
+
+	m.ranKubeadm = true
+	// Certificates, kubeconfigs and static pod manifests of etcd and control plane.
+	for _, phase := range []string{"certs all", "kubeconfig all", "etcd local", "control-plane all"} {
+		_, err = m.run(m.getKubeadmPhaseCommand(phase))
+		if err != nil {
+			return nil, errors.Wrapf(err, "kubeadm phase %q failed", phase)
+		}
+	}
+
+	kubeletHandle, err := m.launchService(m.getKubeadmKubeletCommand())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot launch kubelet")
+	}
+
+	err = m.configureCluster(kubeletHandle.Address())
+	if err != nil {
+		// Node is reset by the caller.
+		if stopErr := kubeletHandle.Stop(); stopErr != nil {
+			log.Warningf("Errors while stopping kubelet: %v", stopErr)
+		}
+		return nil, err
+	}
+	return newKubeadmClusterHandle(executor.NewClusterTaskHandle(kubeletHandle, []executor.TaskHandle{}), teardown), nil
+}
+
+// configureCluster waits for control plane, installs add-ons and stores admin kubeconfig.
+func (m *kubeadm) configureCluster(host string) error {
+	apiServerAddress := fmt.Sprintf("%s:%d", host, m.config.KubeAPISecurePort)
+	if !m.isListening(apiServerAddress, m.config.ControlPlaneTimeout) {
+		return errors.Errorf("apiserver is not listening on %q after %s", apiServerAddress, m.config.ControlPlaneTimeout)
+	}
+
+	for _, phase := range []string{"upload-config all", "addon all"} {
+		_, err := m.run(m.getKubeadmPhaseCommand(phase))
+		if err != nil {
+			return errors.Wrapf(err, "kubeadm phase %q failed", phase)
+		}
+	}
+	if m.config.PodNetworkManifest != "" {
+		_, err := m.run(fmt.Sprintf("kubectl --kubeconfig=%s apply -f %s", kubeadmAdminKubeconfig, m.config.PodNetworkManifest))
+		if err != nil {
+			return errors.Wrap(err, "cannot install pod network add-on")
+		}
+	}
+
+	kubeconfig, err := m.run("cat " + kubeadmAdminKubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "cannot read admin kubeconfig")
+	}
+	m.writtenKubeconfig = true
+	err = ioutil.WriteFile(m.config.Kubeconfig, []byte(kubeconfig), 0600)
+	if err != nil {
+		return errors.Wrapf(err, "cannot write admin kubeconfig to %q", m.config.Kubeconfig)
+	}
+
+	return m.waitForReadyNode(m.config.Kubeconfig)
+}
+
+// waitForReadyNode waits until the node is registered and ready (pod network is configured).
+// Apiserver might be temporarily unavailable while add-ons are being installed, so errors are retried.
+func (m *kubeadm) waitForReadyNode(kubeconfig string) error {
+	timeout := time.After(m.config.ControlPlaneTimeout)
+	for {
+		nodes, err := m.getReadyNodesFromKubeconfig(kubeconfig)
+		if err != nil {
+			log.Debugf("Cannot get ready nodes: %s", err.Error())
+		} else if len(nodes) == expectedKubeletNodesCount {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return errors.Errorf("node has not become ready within %s", m.config.ControlPlaneTimeout)
+		case <-time.After(waitForReadyNodeBackOffPeriod):
+		}
+	}
+}
+
+// getNodeState checks which Kubernetes artifacts are already present on the node.
+func (m *kubeadm) getNodeState() (kubeadmNodeState, error) {
+	output, err := m.run(fmt.Sprint(
+		fmt.Sprintf(`[ -n "$(ls -A %s 2>/dev/null)" ] && echo manifests;`, kubeadmManifestsDir),
+		" pgrep -x kubelet >/dev/null && echo kubelet;",
+		fmt.Sprintf(" [ -e %s ] && echo config;", m.config.KubeadmConfigDir),
+		fmt.Sprintf(" ip link show %s >/dev/null 2>&1 && echo bridge;", bridgeInterface),
+		" true",
+	))
+	if err != nil {
+		return kubeadmNodeState{}, errors.Wrap(err, "cannot check state of the node")
+	}
+
+	var state kubeadmNodeState
+	for _, field := range strings.Fields(output) {
+		switch field {
+		case "manifests":
+			state.manifests = true
+		case "kubelet":
+			state.kubelet = true
+		case "config":
+			state.configDir = true
+		case "bridge":
+			state.bridge = true
+		}
+	}
+	return state, nil
+}
+
+// teardown reverts changes made by the launcher: kubeadm removes static pods and their containers, etcd data,
+// certificates and kubeconfigs; configuration files and bridge of pod network are removed afterwards.
+func (m *kubeadm) teardown() error {
+	var errCollection errcollection.ErrorCollection
+
+	if m.ranKubeadm {
+		_, err := m.run(fmt.Sprintf("kubeadm reset --force --cri-socket=%s", m.config.CRISocket))
+		errCollection.Add(err)
+	}
+
+	var cleanup []string
+	if m.createdConfigDir {
+		cleanup = append(cleanup, fmt.Sprintf("rm -rf %s", m.config.KubeadmConfigDir))
+	}
+	for _, file := range m.writtenFiles {
+		if !m.createdConfigDir || path.Dir(file) != m.config.KubeadmConfigDir {
+			cleanup = append(cleanup, fmt.Sprintf("rm -f %s", file))
+		}
+	}
+	if m.createdBridge && m.ranKubeadm && m.config.PodNetworkManifest == "" {
+		cleanup = append(cleanup, fmt.Sprintf("(ip link delete %s 2>/dev/null || true)", bridgeInterface))
+	}
+	if len(cleanup) > 0 {
+		_, err := m.run(strings.Join(cleanup, " && "))
+		errCollection.Add(err)
+	}
+
+	if m.writtenKubeconfig {
+		err := os.Remove(m.config.Kubeconfig)
+		if err != nil && !os.IsNotExist(err) {
+			errCollection.Add(err)
+		}
+	}
+
+	m.createdConfigDir, m.writtenFiles, m.createdBridge, m.ranKubeadm, m.writtenKubeconfig = false, nil, false, false, false
+	return errCollection.GetErrIfAny()
+}
+
+type kubeadmFile struct {
+	path    string
+	content string
+}
+
+// configFiles returns configuration files written to the node before kubeadm is run.
+func (m *kubeadm) configFiles() ([]kubeadmFile, error) {
+	kubeadmConfig, err := m.config.kubeadmConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot prepare kubeadm configuration")
+	}
+	kubeletConfig, err := m.config.kubeletConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot prepare kubelet configuration")
+	}
+
+	files := []kubeadmFile{
+		{m.kubeadmConfigPath(), kubeadmConfig},
+		{m.kubeletConfigPath(), kubeletConfig},
+	}
+	if m.config.PodNetworkManifest == "" {
+		files = append(files, kubeadmFile{bridgeNetworkConfigPath, m.config.bridgeNetworkConfig()})
+	}
+	return files, nil
+}
+
+func (m *kubeadm) kubeadmConfigPath() string {
+	return path.Join(m.config.KubeadmConfigDir, "kubeadm.yaml")
+}
+
+func (m *kubeadm) kubeletConfigPath() string {
+	return path.Join(m.config.KubeadmConfigDir, "kubelet.yaml")
+}
+
+// getKubeadmPhaseCommand returns command running phase of "kubeadm init".
+func (m *kubeadm) getKubeadmPhaseCommand(phase string) string {
+	return fmt.Sprint(
+		fmt.Sprintf("kubeadm init phase %s", phase),
+		fmt.Sprintf(" --config=%s", m.kubeadmConfigPath()),
+		fmt.Sprintf(" --v=%d", m.config.LogLevel),
+	)
+}
+
+// getKubeadmKubeletCommand returns command for kubelet using configuration file and credentials created by kubeadm.
+func (m *kubeadm) getKubeadmKubeletCommand() kubeCommand {
+	return kubeCommand{m.minion,
+		fmt.Sprint(
+			fmt.Sprintf("kubelet"),
+			fmt.Sprintf(" --config=%s", m.kubeletConfigPath()),
+			fmt.Sprintf(" --kubeconfig=%s", kubeadmKubeletKubeconfig),
+			fmt.Sprintf(" --v=%d", m.config.LogLevel),
+			fmt.Sprintf(" %s", m.config.KubeletArgs),
+		), m.config.KubeletPort}
+}
+
+// run executes command on the node, waits until it finishes and returns its output.
+func (m *kubeadm) run(command string) (string, error) {
+	handle, err := m.master.Execute(command)
+	if err != nil {
+		return "", errors.Wrapf(err, "execution of command %q on %q failed", command, m.master)
+	}
+	defer handle.EraseOutput()
+
+	terminated, err := handle.Wait(kubeadmCommandTimeout)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot wait for command %q", command)
+	}
+	if !terminated {
+		handle.Stop()
+		return "", errors.Errorf("command %q on %q has not finished within %s", command, m.master, kubeadmCommandTimeout)
+	}
+
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot get exit code of command %q", command)
+	}
+	if exitCode != 0 {
+		stderr, _ := readOutput(handle.StderrFile)
+		return "", errors.Errorf("command %q on %q failed with exit code %d: %s", command, m.master, exitCode, strings.TrimSpace(stderr))
+	}
+	return readOutput(handle.StdoutFile)
+}
+
+func readOutput(openFile func() (*os.File, error)) (string, error) {
+	file, err := openFile()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	output, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// writeFileCommand returns command writing content to the file. Content is encoded, so it does not need to be escaped.
+func writeFileCommand(filePath, content string) string {
+	return fmt.Sprintf("mkdir -p %s && echo %s | base64 -d > %s",
+		path.Dir(filePath), base64.StdEncoding.EncodeToString([]byte(content)), filePath)
+}
+
+// getReadyNodesFromKubeconfig returns ready nodes of the cluster reached with kubeconfig.
+func getReadyNodesFromKubeconfig(kubeconfig string) ([]v1.Node, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot load kubeconfig %q", kubeconfig)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create new Kubernetes client from %q", kubeconfig)
+	}
+	return getReadyNodesFromClientset(clientset)
+}
+
+// onceTeardown resets the node at most once, no matter how many times run is called.
+type onceTeardown struct {
+	teardown func() error
+	once     sync.Once
+}
+
+func (t *onceTeardown) run() (err error) {
+	t.once.Do(func() {
+		err = t.teardown()
+	})
+	return err
+}
+
+// kubeadmClusterHandle is a cluster task handle which resets the node when the cluster is stopped.
+type kubeadmClusterHandle struct {
+	*executor.ClusterTaskHandle
+	teardown func() error
+}
+
+func newKubeadmClusterHandle(cluster *executor.ClusterTaskHandle, teardown func() error) *kubeadmClusterHandle {
+	return &kubeadmClusterHandle{
+		ClusterTaskHandle: cluster,
+		teardown:          teardown,
+	}
+}
+
+// Stop stops kubelet and resets the node.
+func (h *kubeadmClusterHandle) Stop() error {
+	var errCollection errcollection.ErrorCollection
+	errCollection.Add(h.ClusterTaskHandle.Stop())
+	errCollection.Add(h.teardown())
+	return errCollection.GetErrIfAny()
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	kubeletConfigAPIVersion = "kubelet.config.k8s.io/v1beta1"
+	clusterDomain           = "cluster.local"
+	// clusterDNSHostIndex is an index of cluster DNS service address in service address range (kubeadm convention).
+	clusterDNSHostIndex = 10
+	bridgeNetworkName   = "swan"
+	bridgeInterface     = "swan0"
+)
+
+// kubeadmConfig returns declarative configuration of single-node cluster consumed by kubeadm.
+// Control plane is not tainted, so the only node runs workloads.
+func (c *Config) kubeadmConfig() (string, error) {
+	kubeletConfig, err := c.kubeletConfig()
+	if err != nil {
+		return "", err
+	}
+
+	var config bytes.Buffer
+	fmt.Fprintf(&config, "apiVersion: %s\n", c.KubeadmAPIVersion)
+	fmt.Fprintf(&config, "kind: InitConfiguration\n")
+	fmt.Fprintf(&config, "localAPIEndpoint:\n")
+	if c.advertiseAddress() != "" {
+		fmt.Fprintf(&config, "  advertiseAddress: %q\n", c.advertiseAddress())
+	}
+	fmt.Fprintf(&config, "  bindPort: %d\n", c.KubeAPISecurePort)
+	fmt.Fprintf(&config, "nodeRegistration:\n")
+	fmt.Fprintf(&config, "  criSocket: %q\n", c.CRISocket)
+	fmt.Fprintf(&config, "  taints: []\n")
+	fmt.Fprintf(&config, "---\n")
+
+	fmt.Fprintf(&config, "apiVersion: %s\n", c.KubeadmAPIVersion)
+	fmt.Fprintf(&config, "kind: ClusterConfiguration\n")
+	fmt.Fprintf(&config, "clusterName: swan\n")
+	if c.KubernetesVersion != "" {
+		fmt.Fprintf(&config, "kubernetesVersion: %q\n", c.KubernetesVersion)
+	}
+	if c.advertiseAddress() != "" {
+		fmt.Fprintf(&config, "controlPlaneEndpoint: %q\n", fmt.Sprintf("%s:%d", c.advertiseAddress(), c.KubeAPISecurePort))
+	}
+	fmt.Fprintf(&config, "certificatesDir: %s\n", kubeadmPKIDir)
+	fmt.Fprintf(&config, "etcd:\n")
+	fmt.Fprintf(&config, "  local:\n")
+	fmt.Fprintf(&config, "    dataDir: %q\n", c.KubeadmConfigDir+"/etcd")
+	fmt.Fprintf(&config, "networking:\n")
+	fmt.Fprintf(&config, "  dnsDomain: %s\n", clusterDomain)
+	fmt.Fprintf(&config, "  podSubnet: %q\n", c.PodSubnet)
+	fmt.Fprintf(&config, "  serviceSubnet: %q\n", c.ServiceAddresses)
+	fmt.Fprintf(&config, "---\n")
+
+	config.WriteString(kubeletConfig)
+	return config.String(), nil
+}
+
+// kubeletConfig returns configuration file of kubelet in current (KubeletConfiguration) format.
+// Resource management policies are set only when configured, like flags of kubelet run from hyperkube.
+func (c *Config) kubeletConfig() (string, error) {
+	clusterDNS, err := serviceAddress(c.ServiceAddresses, clusterDNSHostIndex)
+	if err != nil {
+		return "", err
+	}
+	kubeReserved, err := parseKeyValues(c.KubeletKubeReserved)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid kube reserved resources")
+	}
+	featureGates, err := parseKeyValues(c.KubeletFeatureGates)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid kubelet feature gates")
+	}
+
+	var config bytes.Buffer
+	fmt.Fprintf(&config, "apiVersion: %s\n", kubeletConfigAPIVersion)
+	fmt.Fprintf(&config, "kind: KubeletConfiguration\n")
+	fmt.Fprintf(&config, "staticPodPath: %s\n", kubeadmManifestsDir)
+	fmt.Fprintf(&config, "containerRuntimeEndpoint: %q\n", c.CRISocket)
+	fmt.Fprintf(&config, "cgroupDriver: %q\n", c.KubeletCgroupDriver)
+	fmt.Fprintf(&config, "port: %d\n", c.KubeletPort)
+	fmt.Fprintf(&config, "readOnlyPort: 0\n")
+	fmt.Fprintf(&config, "authentication:\n")
+	fmt.Fprintf(&config, "  anonymous:\n")
+	fmt.Fprintf(&config, "    enabled: false\n")
+	fmt.Fprintf(&config, "  webhook:\n")
+	fmt.Fprintf(&config, "    enabled: true\n")
+	fmt.Fprintf(&config, "  x509:\n")
+	fmt.Fprintf(&config, "    clientCAFile: %s/ca.crt\n", kubeadmPKIDir)
+	fmt.Fprintf(&config, "authorization:\n")
+	fmt.Fprintf(&config, "  mode: Webhook\n")
+	fmt.Fprintf(&config, "clusterDomain: %s\n", clusterDomain)
+	fmt.Fprintf(&config, "clusterDNS:\n")
+	fmt.Fprintf(&config, "- %q\n", clusterDNS)
+	if c.KubeletCPUManagerPolicy != "" {
+		fmt.Fprintf(&config, "cpuManagerPolicy: %q\n", c.KubeletCPUManagerPolicy)
+	}
+	if c.KubeletTopologyManagerPolicy != "" {
+		fmt.Fprintf(&config, "topologyManagerPolicy: %q\n", c.KubeletTopologyManagerPolicy)
+	}
+	if len(kubeReserved) > 0 {
+		fmt.Fprintf(&config, "kubeReserved:\n")
+		for _, resource := range sortedKeys(kubeReserved) {
+			fmt.Fprintf(&config, "  %s: %q\n", resource, kubeReserved[resource])
+		}
+	}
+	if len(featureGates) > 0 {
+		fmt.Fprintf(&config, "featureGates:\n")
+		for _, gate := range sortedKeys(featureGates) {
+			enabled, err := strconv.ParseBool(featureGates[gate])
+			if err != nil {
+				return "", errors.Errorf("feature gate %q must be set to true or false", gate)
+			}
+			fmt.Fprintf(&config, "  %s: %t\n", gate, enabled)
+		}
+	}
+	return config.String(), nil
+}
+
+// bridgeNetworkConfig returns CNI configuration connecting pods of the single node with a bridge.
+func (c *Config) bridgeNetworkConfig() string {
+	return fmt.Sprintf(`{
+  "cniVersion": "0.4.0",
+  "name": %q,
+  "plugins": [
+    {
+      "type": "bridge",
+      "bridge": %q,
+      "isGateway": true,
+      "ipMasq": true,
+      "hairpinMode": true,
+      "ipam": {
+        "type": "host-local",
+        "ranges": [[{"subnet": %q}]],
+        "routes": [{"dst": "0.0.0.0/0"}]
+      }
+    },
+    {
+      "type": "portmap",
+      "capabilities": {"portMappings": true}
+    }
+  ]
+}
+`, bridgeNetworkName, bridgeInterface, c.PodSubnet)
+}
+
+// advertiseAddress returns address advertised by apiserver. Loopback addresses and host names
+// cannot be advertised, so kubeadm detects address of the default interface then.
+func (c *Config) advertiseAddress() string {
+	ip := net.ParseIP(c.KubeAPIAddr)
+	if ip == nil || ip.IsLoopback() {
+		return ""
+	}
+	return ip.String()
+}
+
+// serviceAddress returns address with given index in service address range.
+func serviceAddress(addressRange string, index byte) (string, error) {
+	ip, network, err := net.ParseCIDR(addressRange)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid service address range %q", addressRange)
+	}
+	address := ip.Mask(network.Mask).To4()
+	if address == nil {
+		return "", errors.Errorf("service address range %q is not IPv4", addressRange)
+	}
+	address[3] += index
+	if !network.Contains(address) {
+		return "", errors.Errorf("service address range %q is too small", addressRange)
+	}
+	return address.String(), nil
+}
+
+// parseKeyValues parses comma separated "key=value" pairs.
+func parseKeyValues(value string) (map[string]string, error) {
+	result := map[string]string{}
+	if value == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		keyAndValue := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(keyAndValue) != 2 || keyAndValue[0] == "" {
+			return nil, errors.Errorf("%q is not in key=value format", pair)
+		}
+		result[keyAndValue[0]] = keyAndValue[1]
+	}
+	return result, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
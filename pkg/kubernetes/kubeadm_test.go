@@ -0,0 +1,242 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// commandIndex returns index of the first command containing text.
+func commandIndex(commands []string, text string) int {
+	for i, command := range commands {
+		if strings.Contains(command, text) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestKubeadmConfig(t *testing.T) {
+	Convey("When kubeadm configuration is prepared", t, func() {
+		config := DefaultConfig()
+		config.KubeAPIAddr = "192.168.1.1"
+		config.KubernetesVersion = "v1.30.2"
+		config.KubeletCPUManagerPolicy = "static"
+		config.KubeletKubeReserved = "cpu=1,memory=1Gi"
+		config.KubeletFeatureGates = "CPUManager=true"
+
+		Convey("Untainted control plane should be advertised on control plane host", func() {
+			kubeadmConfig, err := config.kubeadmConfig()
+			So(err, ShouldBeNil)
+			So(kubeadmConfig, ShouldContainSubstring, "apiVersion: kubeadm.k8s.io/v1beta4")
+			So(kubeadmConfig, ShouldContainSubstring, `advertiseAddress: "192.168.1.1"`)
+			So(kubeadmConfig, ShouldContainSubstring, "taints: []")
+			So(kubeadmConfig, ShouldContainSubstring, `kubernetesVersion: "v1.30.2"`)
+			So(kubeadmConfig, ShouldContainSubstring, `serviceSubnet: "10.2.0.0/16"`)
+			So(kubeadmConfig, ShouldContainSubstring, "kind: KubeletConfiguration")
+		})
+
+		Convey("Loopback address should not be advertised", func() {
+			config.KubeAPIAddr = "127.0.0.1"
+			kubeadmConfig, err := config.kubeadmConfig()
+			So(err, ShouldBeNil)
+			So(kubeadmConfig, ShouldNotContainSubstring, "advertiseAddress")
+			So(kubeadmConfig, ShouldNotContainSubstring, "controlPlaneEndpoint")
+		})
+
+		Convey("Kubelet should be configured with resource management policies", func() {
+			kubeletConfig, err := config.kubeletConfig()
+			So(err, ShouldBeNil)
+			So(kubeletConfig, ShouldContainSubstring, `cpuManagerPolicy: "static"`)
+			So(kubeletConfig, ShouldContainSubstring, "kubeReserved:\n  cpu: \"1\"\n  memory: \"1Gi\"\n")
+			So(kubeletConfig, ShouldContainSubstring, "featureGates:\n  CPUManager: true\n")
+			So(kubeletConfig, ShouldContainSubstring, "clusterDNS:\n- \"10.2.0.10\"\n")
+			So(kubeletConfig, ShouldNotContainSubstring, "topologyManagerPolicy")
+		})
+
+		Convey("Invalid feature gates should be rejected", func() {
+			config.KubeletFeatureGates = "CPUManager=yes"
+			_, err := config.kubeletConfig()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestKubeadmLauncher(t *testing.T) {
+	Convey("When kubeadm launcher bootstraps the cluster", t, func() {
+		outputFile, err := ioutil.TempFile(os.TempDir(), "kubeadm-ut")
+		So(err, ShouldBeNil)
+		outputFile.WriteString("admin kubeconfig")
+		outputFileName := outputFile.Name()
+		outputFile.Close()
+		defer os.Remove(outputFileName)
+		openOutput := func() *os.File {
+			file, _ := os.Open(outputFileName)
+			return file
+		}
+
+		kubeconfigDir, err := ioutil.TempDir(os.TempDir(), "kubeadm-ut")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(kubeconfigDir)
+
+		handle := new(executor.MockTaskHandle)
+		handle.On("Wait", mock.Anything).Return(true, nil)
+		handle.On("ExitCode").Return(0, nil)
+		handle.On("Status").Return(executor.TERMINATED)
+		handle.On("StdoutFile").Return(openOutput, nil)
+		handle.On("StderrFile").Return(openOutput, nil)
+		handle.On("EraseOutput").Return(nil)
+		handle.On("Address").Return("127.0.0.1")
+		handle.On("Stop").Return(nil)
+
+		// Output of the command checking state of the node.
+		nodeState := ""
+		stateHandle := new(executor.MockTaskHandle)
+		stateHandle.On("Wait", mock.Anything).Return(true, nil)
+		stateHandle.On("ExitCode").Return(0, nil)
+		stateHandle.On("StdoutFile").Return(func() *os.File {
+			file, _ := ioutil.TempFile(kubeconfigDir, "state")
+			file.WriteString(nodeState)
+			file.Seek(0, 0)
+			return file
+		}, nil)
+		stateHandle.On("EraseOutput").Return(nil)
+
+		var commands []string
+		recordCommand := func(args mock.Arguments) {
+			commands = append(commands, args.String(0))
+		}
+		node := new(executor.MockExecutor)
+		node.On("String").Return("Node Executor")
+		node.On("Execute", mock.MatchedBy(func(command string) bool {
+			return strings.Contains(command, "pgrep -x kubelet")
+		})).Return(stateHandle, nil).Run(recordCommand)
+		node.On("Execute", mock.AnythingOfType("string")).Return(handle, nil).Run(recordCommand)
+
+		config := DefaultConfig()
+		config.Kubeconfig = path.Join(kubeconfigDir, "admin.conf")
+		launcher := NewKubeadm(node, config).(*kubeadm)
+		launcher.isListening = getIsListeningFunc(true)
+		launcher.getReadyNodesFromKubeconfig = getReadyNodesFromKubeconfigFunc(getNodeListFunc([]v1.Node{{}}, nil))
+
+		Convey("Control plane should be created before kubelet is launched and add-ons after", func() {
+			cluster, err := launcher.Launch()
+			So(err, ShouldBeNil)
+			So(cluster, ShouldNotBeNil)
+
+			So(commandIndex(commands, "pgrep -x kubelet"), ShouldEqual, 0)
+			So(commandIndex(commands, "base64 -d > /var/lib/swan/kubeadm/kubeadm.yaml"), ShouldEqual, 1)
+			certs := commandIndex(commands, "kubeadm init phase certs all --config=/var/lib/swan/kubeadm/kubeadm.yaml")
+			controlPlane := commandIndex(commands, "kubeadm init phase control-plane all")
+			kubelet := commandIndex(commands, "kubelet --config=/var/lib/swan/kubeadm/kubelet.yaml --kubeconfig=/etc/kubernetes/kubelet.conf")
+			addons := commandIndex(commands, "kubeadm init phase addon all")
+			So(certs, ShouldBeGreaterThan, 0)
+			So(controlPlane, ShouldBeGreaterThan, certs)
+			So(kubelet, ShouldBeGreaterThan, controlPlane)
+			So(addons, ShouldBeGreaterThan, kubelet)
+			So(commandIndex(commands, "kubeadm reset"), ShouldEqual, -1)
+
+			kubeconfig, err := ioutil.ReadFile(config.Kubeconfig)
+			So(err, ShouldBeNil)
+			So(string(kubeconfig), ShouldEqual, "admin kubeconfig")
+
+			Convey("Stopping the cluster should reset the node", func() {
+				So(cluster.Stop(), ShouldBeNil)
+				So(commandIndex(commands, "kubeadm reset --force"), ShouldBeGreaterThan, addons)
+				So(commandIndex(commands, "rm -rf /var/lib/swan/kubeadm"), ShouldBeGreaterThan, addons)
+				_, err := os.Stat(config.Kubeconfig)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("When experiment is interrupted, node should be reset only once", func() {
+			registry := executor.DefaultRegistry
+			executor.DefaultRegistry = executor.NewRegistry()
+			defer func() { executor.DefaultRegistry = registry }()
+
+			cluster, err := launcher.Launch()
+			So(err, ShouldBeNil)
+			So(executor.DefaultRegistry.Cleanup(time.Second), ShouldBeNil)
+			So(commandIndex(commands, "kubeadm reset --force"), ShouldBeGreaterThan, 0)
+
+			So(cluster.Stop(), ShouldBeNil)
+			resets := 0
+			for _, command := range commands {
+				if strings.Contains(command, "kubeadm reset") {
+					resets++
+				}
+			}
+			So(resets, ShouldEqual, 1)
+		})
+
+		Convey("When configuration directory already exists, only files written by the launcher should be removed", func() {
+			nodeState = "config\n"
+			cluster, err := launcher.Launch()
+			So(err, ShouldBeNil)
+			So(cluster.Stop(), ShouldBeNil)
+			So(commandIndex(commands, "rm -rf /var/lib/swan/kubeadm"), ShouldEqual, -1)
+			So(commandIndex(commands, "rm -f /var/lib/swan/kubeadm/kubeadm.yaml && rm -f /var/lib/swan/kubeadm/kubelet.yaml"), ShouldBeGreaterThan, 0)
+		})
+
+		Convey("When static pod manifests are present, node should not be touched", func() {
+			nodeState = "manifests\n"
+			cluster, err := launcher.Launch()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "/etc/kubernetes/manifests")
+			So(cluster, ShouldBeNil)
+			for _, command := range commands {
+				So(command, ShouldContainSubstring, "pgrep -x kubelet")
+			}
+		})
+
+		Convey("When kubelet is already running, node should not be touched", func() {
+			nodeState = "kubelet\n"
+			_, err := launcher.Launch()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "kubelet is already running")
+			So(commandIndex(commands, "kubeadm"), ShouldEqual, -1)
+			_, err = os.Stat(config.Kubeconfig)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("When apiserver does not start, node should be reset", func() {
+			launcher.isListening = func(address string, _ time.Duration) bool {
+				return !strings.HasSuffix(address, ":6443")
+			}
+			cluster, err := launcher.Launch()
+			So(err, ShouldNotBeNil)
+			So(cluster, ShouldBeNil)
+			So(commandIndex(commands, "kubeadm reset --force"), ShouldBeGreaterThan, 0)
+			handle.AssertCalled(t, "Stop")
+		})
+
+		Convey("When admin kubeconfig path is not provided, error should be returned", func() {
+			launcher.config.Kubeconfig = ""
+			_, err := launcher.Launch()
+			So(err, ShouldNotBeNil)
+			So(commands, ShouldBeEmpty)
+		})
+	})
+}
@@ -42,9 +42,26 @@ var (
 	kubeEtcdDataFormatFlag = conf.NewStringFlag("kubernetes_cluster_etcd_data_format", "Data format for etcd cluster (etvd3 or etcd2)", "etcd3")
 	kubeCgroupDriverFlag   = conf.NewStringFlag("kubernetes_kubelet_cgroup_driver", "Cgroup driver that kubelet should use (systemd or cgroupfs)", "cgroupfs")
 
+	kubeletCPUManagerPolicyFlag = conf.NewStringFlag("kubernetes_kubelet_cpu_manager_policy", "CPU manager policy of kubelet (none or static). "+
+		"Static policy gives exclusive cores to containers of Guaranteed pods requesting integer number of CPUs and requires kubernetes_kubelet_kube_reserved. "+
+		"Remove /var/lib/kubelet/cpu_manager_state after changing the policy.", "none")
+	kubeletTopologyManagerPolicyFlag = conf.NewStringFlag("kubernetes_kubelet_topology_manager_policy", "(optional) Topology manager policy of kubelet (none, best-effort, restricted or single-numa-node).", "")
+	kubeletKubeReservedFlag          = conf.NewStringFlag("kubernetes_kubelet_kube_reserved", "(optional) Resources reserved for Kubernetes components, e.g. \"cpu=1,memory=1Gi\".", "")
+	kubeletFeatureGatesFlag          = conf.NewStringFlag("kubernetes_kubelet_feature_gates", "(optional) Feature gates of kubelet, e.g. \"CPUManager=true,HugePages=true\" (required by older Kubernetes versions for alpha features).", "")
+
 	//KubernetesMasterFlag indicates where Kubernetes control plane will be launched.
 	KubernetesMasterFlag = conf.NewStringFlag("kubernetes_cluster_run_control_plane_on_host", "Address of a host where Kubernetes control plane will be run (when using -kubernetes and not connecting to existing cluster).", "127.0.0.1")
 
+	// KubeadmFlag indicates that Kubernetes cluster is bootstrapped with kubeadm instead of running hyperkube binaries.
+	KubeadmFlag = conf.NewBoolFlag("kubernetes_cluster_kubeadm", "Bootstrap single-node Kubernetes cluster with kubeadm (control plane and etcd as static pods, generated certificates, kubelet config file) "+
+		"on the control plane host instead of running hyperkube binaries. Admin kubeconfig is written to kubernetes_kubeconfig.", false)
+	kubeadmKubernetesVersionFlag = conf.NewStringFlag("kubernetes_kubeadm_version", "(optional) Kubernetes version bootstrapped by kubeadm, e.g. \"v1.30.2\" (kubeadm version when empty).", "")
+	kubeadmAPIVersionFlag        = conf.NewStringFlag("kubernetes_kubeadm_api_version", "Version of kubeadm configuration format (kubeadm.k8s.io/v1beta4 for Kubernetes 1.31+, kubeadm.k8s.io/v1beta3 for 1.22-1.30).", "kubeadm.k8s.io/v1beta4")
+	kubeadmCRISocketFlag         = conf.NewStringFlag("kubernetes_kubeadm_cri_socket", "Container runtime endpoint used by kubelet bootstrapped by kubeadm.", "unix:///run/containerd/containerd.sock")
+	kubeadmPodSubnetFlag         = conf.NewStringFlag("kubernetes_kubeadm_pod_subnet", "Address range of pods in cluster bootstrapped by kubeadm.", "10.244.0.0/16")
+	kubeadmPodNetworkFlag        = conf.NewStringFlag("kubernetes_kubeadm_pod_network_manifest", "(optional) Manifest of pod network add-on applied to cluster bootstrapped by kubeadm. "+
+		"Single-node bridge network is configured when empty (requires standard CNI plugins in /opt/cni/bin).", "")
+
 	kubeCleanLeftPods = conf.NewBoolFlag("kubernetes_cluster_clean_left_pods_on_startup", "Delete all pods which are detected during cluster startup. Useful after dirty shutdown when some pods may not be properly deleted.", false)
 )
 
@@ -82,8 +99,25 @@ type Config struct {
 	KubeletCgroupDriver string
 	KubeProxyArgs       string
 
+	// Kubelet resource management policies. Flags are not passed to kubelet when empty.
+	KubeletCPUManagerPolicy      string
+	KubeletTopologyManagerPolicy string
+	KubeletKubeReserved          string
+	KubeletFeatureGates          string
+
 	// Launcher configuration
 	RetryCount uint64
+
+	// Kubeadm launcher configuration (see NewKubeadm).
+	KubernetesVersion   string
+	KubeadmAPIVersion   string
+	KubeadmConfigDir    string // Directory for configuration files and etcd data on the node.
+	KubeAPISecurePort   int
+	CRISocket           string
+	PodSubnet           string
+	PodNetworkManifest  string // Bridge network is configured when empty.
+	Kubeconfig          string // Local path where admin kubeconfig is written.
+	ControlPlaneTimeout time.Duration
 }
 
 // DefaultConfig is a constructor for Config with default parameters.
@@ -103,6 +137,21 @@ func DefaultConfig() Config {
 		ServiceAddresses:    "10.2.0.0/16",
 		RetryCount:          0,
 		KubeletCgroupDriver: kubeCgroupDriverFlag.Value(),
+
+		KubeletCPUManagerPolicy:      kubeletCPUManagerPolicyFlag.Value(),
+		KubeletTopologyManagerPolicy: kubeletTopologyManagerPolicyFlag.Value(),
+		KubeletKubeReserved:          kubeletKubeReservedFlag.Value(),
+		KubeletFeatureGates:          kubeletFeatureGatesFlag.Value(),
+
+		KubernetesVersion:   kubeadmKubernetesVersionFlag.Value(),
+		KubeadmAPIVersion:   kubeadmAPIVersionFlag.Value(),
+		KubeadmConfigDir:    "/var/lib/swan/kubeadm",
+		KubeAPISecurePort:   6443,
+		CRISocket:           kubeadmCRISocketFlag.Value(),
+		PodSubnet:           kubeadmPodSubnetFlag.Value(),
+		PodNetworkManifest:  kubeadmPodNetworkFlag.Value(),
+		Kubeconfig:          executor.KubeconfigFlag.Value(),
+		ControlPlaneTimeout: 5 * time.Minute,
 	}
 }
 
@@ -111,6 +160,23 @@ func (c *Config) GetKubeAPIAddress() string {
 	return fmt.Sprintf("http://%s:%d", c.KubeAPIAddr, c.KubeAPIPort)
 }
 
+// validateKubeletPolicies checks if kubelet resource management policies can be applied.
+func (c *Config) validateKubeletPolicies() error {
+	if c.KubeletCPUManagerPolicy == "static" && c.KubeletKubeReserved == "" {
+		return errors.New("static CPU manager policy requires CPUs reserved for Kubernetes components (KubeletKubeReserved)")
+	}
+	return nil
+}
+
+// validateHyperkubeKubelet rejects kubelet resource management policies in cluster run from hyperkube binaries:
+// kubelet is given --api-servers flag there, which was removed in Kubernetes 1.8, before the policies were introduced.
+func (c *Config) validateHyperkubeKubelet() error {
+	if (c.KubeletCPUManagerPolicy != "" && c.KubeletCPUManagerPolicy != "none") || c.KubeletTopologyManagerPolicy != "" || c.KubeletKubeReserved != "" {
+		return errors.Errorf("kubelet CPU manager, topology manager and kube-reserved require cluster bootstrapped with kubeadm (set %q flag)", KubeadmFlag.Name)
+	}
+	return nil
+}
+
 // Type used for UT mocking purposes.
 type getReadyNodesFunc func(k8sAPIAddress string) ([]v1.Node, error)
 
@@ -206,6 +272,11 @@ func (m *k8s) tryLaunchCluster() (executor.TaskHandle, error) {
 }
 
 func (m *k8s) launchCluster() (executor.TaskHandle, error) {
+	err := m.config.validateHyperkubeKubelet()
+	if err != nil {
+		return nil, err
+	}
+
 	// Launch apiserver using master executor.
 	kubeAPIServer := m.getKubeAPIServerCommand()
 	apiHandle, err := m.launchService(kubeAPIServer)
@@ -339,10 +410,20 @@ func (m *k8s) getKubeletCommand() kubeCommand {
 			fmt.Sprintf(" --read-only-port=0"),
 			fmt.Sprintf(" --api-servers=%s", m.config.GetKubeAPIAddress()),
 			fmt.Sprintf(" --cgroup-driver=%s", m.config.KubeletCgroupDriver),
+			m.getKubeletFeatureGatesArg(),
 			fmt.Sprintf(" %s", m.config.KubeletArgs),
 		), m.config.KubeletPort}
 }
 
+// getKubeletFeatureGatesArg returns kubelet feature gates argument; it is omitted when not configured.
+// CPU and topology manager policies are available only in cluster bootstrapped with kubeadm (see validateHyperkubeKubelet).
+func (m *k8s) getKubeletFeatureGatesArg() string {
+	if m.config.KubeletFeatureGates == "" {
+		return ""
+	}
+	return fmt.Sprintf(" --feature-gates=%s", m.config.KubeletFeatureGates)
+}
+
 // getKubeProxyCommand returns command for proxy.
 func (m *k8s) getKubeProxyCommand() kubeCommand {
 	return kubeCommand{m.minion,
@@ -21,9 +21,11 @@ import (
 	"time"
 
 	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/k8sfake"
 	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/pkg/api/v1"
 )
 
@@ -98,6 +100,24 @@ func TestKubernetesLauncher(t *testing.T) {
 				})
 			})
 
+			Convey("Kubelet resource management policies are not set by default", func() {
+				k8sLauncher.config.KubeletCPUManagerPolicy = "none"
+				k8sLauncher.config.KubeletFeatureGates = "HugePages=true"
+				kubeletCommand := k8sLauncher.getKubeletCommand()
+				So(kubeletCommand.raw, ShouldNotContainSubstring, "--cpu-manager-policy")
+				So(kubeletCommand.raw, ShouldNotContainSubstring, "--topology-manager-policy")
+				So(kubeletCommand.raw, ShouldContainSubstring, "--feature-gates=HugePages=true")
+
+				Convey("And static CPU manager and topology manager should be rejected without kubeadm", func() {
+					k8sLauncher.config.KubeletCPUManagerPolicy = "static"
+					k8sLauncher.config.KubeletTopologyManagerPolicy = "single-numa-node"
+					k8sLauncher.config.KubeletKubeReserved = "cpu=1"
+					_, err := k8sLauncher.launchCluster()
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "require cluster bootstrapped with kubeadm")
+				})
+			})
+
 			Convey("Default etcd server address points to http://127.0.0.1:2379", func() {
 				kubeAPICommand := k8sLauncher.getKubeAPIServerCommand()
 				So(kubeAPICommand.raw, ShouldContainSubstring, "--etcd-servers=http://127.0.0.1:2379")
@@ -195,3 +215,42 @@ func TestKubernetesLauncher(t *testing.T) {
 		})
 	})
 }
+
+func TestKubernetesNodes(t *testing.T) {
+	Convey("When Kubernetes cluster is running", t, func() {
+		cluster := k8sfake.NewCluster()
+
+		Convey("Ready nodes should be found", func() {
+			nodes, err := getReadyNodesFromClientset(cluster)
+			So(err, ShouldBeNil)
+			So(nodes, ShouldHaveLength, 1)
+			So(nodes[0].Name, ShouldEqual, k8sfake.DefaultNodeName)
+
+			Convey("But node which is not ready should be skipped", func() {
+				So(cluster.SetNodeReady(k8sfake.DefaultNodeName, false), ShouldBeNil)
+				nodes, err := getReadyNodesFromClientset(cluster)
+				So(err, ShouldBeNil)
+				So(nodes, ShouldBeEmpty)
+			})
+		})
+
+		Convey("Hanging pods should be found on node and killed", func() {
+			_, err := cluster.CoreV1().Pods(v1.NamespaceDefault).Create(&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "hanging"},
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "swan"}}},
+			})
+			So(err, ShouldBeNil)
+			So(cluster.RunPod("hanging"), ShouldBeNil)
+
+			podAPI := &k8sPodAPIImplementation{client: cluster}
+			pods, err := podAPI.getPodsFromNode(k8sfake.DefaultNodeName)
+			So(err, ShouldBeNil)
+			So(pods, ShouldHaveLength, 1)
+
+			So(podAPI.killPods(pods), ShouldBeNil)
+			pods, err = podAPI.getPodsFromNode(k8sfake.DefaultNodeName)
+			So(err, ShouldBeNil)
+			So(pods, ShouldBeEmpty)
+		})
+	})
+}
@@ -19,7 +19,7 @@ type k8sPodAPI interface {
 }
 
 type k8sPodAPIImplementation struct {
-	client *kubernetes.Clientset
+	client kubernetes.Interface
 }
 
 func newK8sPodAPI(config Config) k8sPodAPI {
@@ -71,7 +71,7 @@ func (m *k8sPodAPIImplementation) getPodsFromNode(nodeName string) (result []v1.
 		}
 		nodeName = hostname
 	}
-	pods, err := m.client.Pods(v1.NamespaceDefault).List(metav1.ListOptions{})
+	pods, err := m.client.CoreV1().Pods(v1.NamespaceDefault).List(metav1.ListOptions{})
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot retrieve pods running on cluster")
 	}
@@ -84,7 +84,7 @@ func (m *k8sPodAPIImplementation) getPodsFromNode(nodeName string) (result []v1.
 }
 
 func (m *k8sPodAPIImplementation) killPods(pods []v1.Pod) error {
-	podsAPI := m.client.Core().Pods(v1.NamespaceDefault)
+	podsAPI := m.client.CoreV1().Pods(v1.NamespaceDefault)
 
 	for _, pod := range pods {
 		var gracePeriod int64 = 1
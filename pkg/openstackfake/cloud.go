@@ -0,0 +1,354 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openstackfake provides fake OpenStack cloud for unit tests of code using gophercloud.
+// Cloud is a local HTTP server implementing the subset of Keystone (v2.0 tokens), Nova and Neutron APIs
+// used by Swan: flavors, keypairs, images, floating IPs, host aggregates, hypervisors and servers in Nova
+// and floating IPs and ports of servers in Neutron.
+// Tests point gophercloud at it with AuthOptions and drive status of servers.
+package openstackfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Region is a region of compute and network endpoints in service catalog.
+	Region = "RegionOne"
+	// DefaultImage is a name of the image cloud starts with.
+	DefaultImage = "cirros"
+	// DefaultHostAggregateID is an ID of the host aggregate cloud starts with.
+	DefaultHostAggregateID = 1
+	// DefaultAvailabilityZone is an availability zone of the default host aggregate.
+	DefaultAvailabilityZone = "fake-zone"
+	// DefaultHypervisorHostname is a hostname of the hypervisor running all the servers.
+	DefaultHypervisorHostname = "fake-hypervisor"
+	// DefaultHypervisorAddress is an address of the hypervisor running all the servers.
+	DefaultHypervisorAddress = "127.0.0.1"
+
+	tokenID     = "fake-token"
+	password    = "admin"
+	computePath = "/compute/v2.1/"
+	networkPath = "/network/"
+)
+
+// Server is a server (instance) created through API.
+type Server struct {
+	ID               string
+	Name             string
+	FlavorID         string
+	ImageID          string
+	KeyName          string
+	AvailabilityZone string
+	Status           string
+	FloatingIP       string
+	// InstanceName is a libvirt domain name of the server on the hypervisor.
+	InstanceName string
+}
+
+// Flavor is a flavor known to the cloud.
+type Flavor struct {
+	ID    string
+	Name  string
+	RAM   int
+	VCPUs int
+	Disk  int
+}
+
+type floatingIP struct {
+	id         string
+	ip         string
+	instanceID string
+}
+
+// failure makes Nova API fail requests with the method and path prefix.
+type failure struct {
+	method string
+	path   string
+	status int
+}
+
+type aggregate struct {
+	name             string
+	availabilityZone string
+	metadata         map[string]string
+}
+
+// Cloud is a fake OpenStack cloud served over HTTP on local address.
+type Cloud struct {
+	server *httptest.Server
+
+	// BootStatus is a status servers get after they are created (ACTIVE by default).
+	// Set it to ERROR to simulate failing boot or BUILD to simulate boot that never finishes.
+	BootStatus string
+
+	mutex       sync.Mutex
+	flavors     []Flavor
+	keypairs    []string
+	images      map[string]string
+	floatingIPs []*floatingIP
+	aggregates  map[int]aggregate
+	servers     map[string]*Server
+	deleted     []string
+	failures    []failure
+	objectCount int
+}
+
+// NewCloud starts fake cloud with default image, host aggregate and hypervisor, and without floating IPs.
+func NewCloud() *Cloud {
+	cloud := &Cloud{
+		BootStatus: "ACTIVE",
+		images:     map[string]string{},
+		aggregates: map[int]aggregate{},
+		servers:    map[string]*Server{},
+	}
+	cloud.AddImage(DefaultImage)
+	cloud.AddHostAggregate(DefaultHostAggregateID, "fake-aggregate", DefaultAvailabilityZone, nil)
+	cloud.server = httptest.NewServer(http.HandlerFunc(cloud.serveHTTP))
+	return cloud
+}
+
+// Close shuts down the cloud API server.
+func (c *Cloud) Close() {
+	c.server.Close()
+}
+
+// AuthOptions returns options authenticating to Keystone of the cloud.
+func (c *Cloud) AuthOptions() gophercloud.AuthOptions {
+	return gophercloud.AuthOptions{
+		IdentityEndpoint: c.server.URL + "/identity/v2.0/",
+		Username:         "admin",
+		Password:         password,
+		TenantName:       "admin",
+	}
+}
+
+// AddImage adds image with the name and returns its ID.
+func (c *Cloud) AddImage(name string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id := c.newID("image")
+	c.images[id] = name
+	return id
+}
+
+// AddHostAggregate adds host aggregate with metadata (e.g. configuration_id, cpu_threads).
+func (c *Cloud) AddHostAggregate(id int, name, availabilityZone string, metadata map[string]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.aggregates[id] = aggregate{name: name, availabilityZone: availabilityZone, metadata: metadata}
+}
+
+// AddFloatingIP adds floating IP which is not associated with any server.
+func (c *Cloud) AddFloatingIP(ip string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.floatingIPs = append(c.floatingIPs, &floatingIP{id: c.newID("fip"), ip: ip})
+}
+
+// Flavors returns flavors known to the cloud.
+func (c *Cloud) Flavors() []Flavor {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Flavor(nil), c.flavors...)
+}
+
+// Keypairs returns names of keypairs known to the cloud.
+func (c *Cloud) Keypairs() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.keypairs...)
+}
+
+// Servers returns copies of servers which are not deleted, sorted by name.
+func (c *Cloud) Servers() []Server {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var servers []Server
+	for _, server := range c.servers {
+		servers = append(servers, *server)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers
+}
+
+// DeletedServers returns IDs of servers deleted through API in order of deletion.
+func (c *Cloud) DeletedServers() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.deleted...)
+}
+
+// FailRequests makes compute API respond with status code to requests with the method (e.g. POST) and
+// path starting with prefix relative to compute endpoint (e.g. "servers" or "os-aggregates/1").
+func (c *Cloud) FailRequests(method, pathPrefix string, status int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.failures = append(c.failures, failure{method: method, path: pathPrefix, status: status})
+}
+
+// SetServerStatus changes status of the server (e.g. to SHUTOFF or ERROR).
+func (c *Cloud) SetServerStatus(id, status string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	server, ok := c.servers[id]
+	if !ok {
+		return errors.Errorf("server %q does not exist", id)
+	}
+	server.Status = status
+	return nil
+}
+
+func (c *Cloud) newID(kind string) string {
+	c.objectCount++
+	return fmt.Sprintf("%s-%d", kind, c.objectCount)
+}
+
+// serveHTTP routes requests to Keystone, Nova and Neutron handlers.
+func (c *Cloud) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if r.Method == http.MethodPost && r.URL.Path == "/identity/v2.0/tokens" {
+		c.createToken(w, r)
+		return
+	}
+	if r.Header.Get("X-Auth-Token") != tokenID {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "authentication required")
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, networkPath) {
+		c.serveNetwork(w, r, strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, networkPath), "/"), "/"))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, computePath) {
+		writeError(w, http.StatusNotFound, "itemNotFound", "unknown endpoint "+r.URL.Path)
+		return
+	}
+	relativePath := strings.Trim(strings.TrimPrefix(r.URL.Path, computePath), "/")
+	for _, failure := range c.failures {
+		if failure.method == r.Method && strings.HasPrefix(relativePath, failure.path) {
+			writeError(w, failure.status, "computeFault", fmt.Sprintf("%s %s failed in fake cloud", r.Method, relativePath))
+			return
+		}
+	}
+	path := strings.Split(relativePath, "/")
+
+	switch {
+	case r.Method == http.MethodGet && match(path, "flavors", "detail"):
+		c.listFlavors(w)
+	case r.Method == http.MethodPost && match(path, "flavors"):
+		c.createFlavor(w, r)
+	case r.Method == http.MethodGet && match(path, "os-keypairs"):
+		c.listKeypairs(w)
+	case r.Method == http.MethodPost && match(path, "os-keypairs"):
+		c.createKeypair(w, r)
+	case r.Method == http.MethodGet && match(path, "images", "detail"):
+		c.listImages(w)
+	case r.Method == http.MethodGet && match(path, "os-floating-ips"):
+		c.listFloatingIPs(w)
+	case r.Method == http.MethodGet && match(path, "os-aggregates", "*"):
+		c.getHostAggregate(w, path[1])
+	case r.Method == http.MethodGet && match(path, "os-hypervisors", "detail"):
+		c.listHypervisors(w)
+	case r.Method == http.MethodPost && match(path, "servers"):
+		c.createServer(w, r)
+	case r.Method == http.MethodGet && match(path, "servers", "*"):
+		c.getServer(w, path[1])
+	case r.Method == http.MethodDelete && match(path, "servers", "*"):
+		c.deleteServer(w, path[1])
+	case r.Method == http.MethodPost && match(path, "servers", "*", "action"):
+		c.serverAction(w, r, path[1])
+	default:
+		writeError(w, http.StatusNotFound, "itemNotFound", fmt.Sprintf("%s %s is not supported by fake cloud", r.Method, r.URL.Path))
+	}
+}
+
+// match returns true when path consists of given segments; "*" matches any segment.
+func match(path []string, segments ...string) bool {
+	if len(path) != len(segments) {
+		return false
+	}
+	for i, segment := range segments {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	writeJSON(w, status, map[string]interface{}{kind: map[string]interface{}{"code": status, "message": message}})
+}
+
+func decodeBody(r *http.Request, body interface{}) error {
+	return json.NewDecoder(r.Body).Decode(body)
+}
+
+func (c *Cloud) createToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Auth struct {
+			PasswordCredentials struct {
+				Password string `json:"password"`
+			} `json:"passwordCredentials"`
+		} `json:"auth"`
+	}
+	if err := decodeBody(r, &body); err != nil || body.Auth.PasswordCredentials.Password != password {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "The request you have made requires authentication.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access": map[string]interface{}{
+			"token": map[string]interface{}{
+				"id":      tokenID,
+				"expires": "2999-01-01T00:00:00Z",
+				"tenant":  map[string]interface{}{"id": "admin", "name": "admin"},
+			},
+			"serviceCatalog": []interface{}{
+				map[string]interface{}{
+					"name": "nova",
+					"type": "compute",
+					"endpoints": []interface{}{map[string]interface{}{
+						"region":    Region,
+						"publicURL": c.server.URL + computePath,
+					}},
+				},
+				map[string]interface{}{
+					"name": "neutron",
+					"type": "network",
+					"endpoints": []interface{}{map[string]interface{}{
+						"region":    Region,
+						"publicURL": c.server.URL + networkPath,
+					}},
+				},
+			},
+			"user": map[string]interface{}{"id": "admin", "name": "admin", "roles": []interface{}{}},
+		},
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstackfake
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/floatingips"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/images"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	networkfloatingips "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCloud(t *testing.T) {
+	Convey("When client authenticates in fake cloud", t, func() {
+		cloud := NewCloud()
+		defer cloud.Close()
+		cloud.AddFloatingIP("172.24.4.1")
+
+		provider, err := openstack.AuthenticatedClient(cloud.AuthOptions())
+		So(err, ShouldBeNil)
+		client, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: Region})
+		So(err, ShouldBeNil)
+
+		imageID, err := images.IDFromName(client, DefaultImage)
+		So(err, ShouldBeNil)
+
+		Convey("Created server should be active", func() {
+			server, err := servers.Create(client, servers.CreateOpts{Name: "test", FlavorRef: "flavor", ImageRef: imageID}).Extract()
+			So(err, ShouldBeNil)
+
+			server, err = servers.Get(client, server.ID).Extract()
+			So(err, ShouldBeNil)
+			So(server.Name, ShouldEqual, "test")
+			So(server.Status, ShouldEqual, "ACTIVE")
+
+			Convey("Floating IP should be associated with the server until it is deleted", func() {
+				err := floatingips.AssociateInstance(client, server.ID, floatingips.AssociateOpts{FloatingIP: "172.24.4.1"}).ExtractErr()
+				So(err, ShouldBeNil)
+				So(cloud.Servers()[0].FloatingIP, ShouldEqual, "172.24.4.1")
+
+				So(servers.Delete(client, server.ID).ExtractErr(), ShouldBeNil)
+				So(cloud.Servers(), ShouldBeEmpty)
+				So(cloud.DeletedServers(), ShouldResemble, []string{server.ID})
+
+				_, err = servers.Get(client, server.ID).Extract()
+				_, notFound := err.(gophercloud.ErrDefault404)
+				So(notFound, ShouldBeTrue)
+			})
+		})
+
+		Convey("Floating IP should be associated with port of the server through network API", func() {
+			network, err := openstack.NewNetworkV2(provider, gophercloud.EndpointOpts{Region: Region})
+			So(err, ShouldBeNil)
+			server, err := servers.Create(client, servers.CreateOpts{Name: "test", FlavorRef: "flavor", ImageRef: imageID}).Extract()
+			So(err, ShouldBeNil)
+
+			allPorts, err := ports.List(network, ports.ListOpts{DeviceID: server.ID}).AllPages()
+			So(err, ShouldBeNil)
+			serverPorts, err := ports.ExtractPorts(allPorts)
+			So(err, ShouldBeNil)
+			So(serverPorts, ShouldHaveLength, 1)
+
+			allFloatingIPs, err := networkfloatingips.List(network, networkfloatingips.ListOpts{FloatingIP: "172.24.4.1"}).AllPages()
+			So(err, ShouldBeNil)
+			floatingIPs, err := networkfloatingips.ExtractFloatingIPs(allFloatingIPs)
+			So(err, ShouldBeNil)
+			So(floatingIPs, ShouldHaveLength, 1)
+			So(floatingIPs[0].PortID, ShouldBeEmpty)
+
+			floatingIP, err := networkfloatingips.Update(network, floatingIPs[0].ID, networkfloatingips.UpdateOpts{PortID: &serverPorts[0].ID}).Extract()
+			So(err, ShouldBeNil)
+			So(floatingIP.PortID, ShouldEqual, serverPorts[0].ID)
+			So(floatingIP.FixedIP, ShouldEqual, serverPorts[0].FixedIPs[0].IPAddress)
+			So(cloud.Servers()[0].FloatingIP, ShouldEqual, "172.24.4.1")
+
+			Convey("And it should be visible in compute API", func() {
+				allPages, err := floatingips.List(client).AllPages()
+				So(err, ShouldBeNil)
+				computeFloatingIPs, err := floatingips.ExtractFloatingIPs(allPages)
+				So(err, ShouldBeNil)
+				So(computeFloatingIPs[0].InstanceID, ShouldEqual, server.ID)
+				So(computeFloatingIPs[0].FixedIP, ShouldEqual, floatingIP.FixedIP)
+			})
+
+			Convey("And it should be disassociated on request", func() {
+				floatingIP, err := networkfloatingips.Update(network, floatingIP.ID, networkfloatingips.UpdateOpts{}).Extract()
+				So(err, ShouldBeNil)
+				So(floatingIP.PortID, ShouldBeEmpty)
+				So(cloud.Servers()[0].FloatingIP, ShouldBeEmpty)
+			})
+
+			Convey("And association with unknown port should fail", func() {
+				unknownPort := "port-unknown"
+				_, err := networkfloatingips.Update(network, floatingIP.ID, networkfloatingips.UpdateOpts{PortID: &unknownPort}).Extract()
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("Requests should fail when failure is injected", func() {
+			cloud.FailRequests("POST", "servers", 500)
+			_, err := servers.Create(client, servers.CreateOpts{Name: "test", FlavorRef: "flavor", ImageRef: imageID}).Extract()
+			So(err, ShouldNotBeNil)
+			So(cloud.Servers(), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Authentication with invalid password should fail", t, func() {
+		cloud := NewCloud()
+		defer cloud.Close()
+
+		options := cloud.AuthOptions()
+		options.Password = "invalid"
+		_, err := openstack.AuthenticatedClient(options)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstackfake
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+func flavorJSON(flavor Flavor) map[string]interface{} {
+	return map[string]interface{}{
+		"id":    flavor.ID,
+		"name":  flavor.Name,
+		"ram":   flavor.RAM,
+		"vcpus": flavor.VCPUs,
+		"disk":  flavor.Disk,
+		"swap":  "",
+	}
+}
+
+func (c *Cloud) listFlavors(w http.ResponseWriter) {
+	flavors := []interface{}{}
+	for _, flavor := range c.flavors {
+		flavors = append(flavors, flavorJSON(flavor))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"flavors": flavors})
+}
+
+func (c *Cloud) createFlavor(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Flavor struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			RAM   int    `json:"ram"`
+			VCPUs int    `json:"vcpus"`
+			Disk  int    `json:"disk"`
+		} `json:"flavor"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return
+	}
+	flavor := Flavor(body.Flavor)
+	if flavor.ID == "" {
+		flavor.ID = c.newID("flavor")
+	}
+	c.flavors = append(c.flavors, flavor)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"flavor": flavorJSON(flavor)})
+}
+
+func keypairJSON(name string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "public_key": "ssh-rsa fake", "fingerprint": "fake"}
+}
+
+func (c *Cloud) listKeypairs(w http.ResponseWriter) {
+	keypairs := []interface{}{}
+	for _, name := range c.keypairs {
+		keypairs = append(keypairs, map[string]interface{}{"keypair": keypairJSON(name)})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keypairs": keypairs})
+}
+
+func (c *Cloud) createKeypair(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Keypair struct {
+			Name string `json:"name"`
+		} `json:"keypair"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return
+	}
+	c.keypairs = append(c.keypairs, body.Keypair.Name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keypair": keypairJSON(body.Keypair.Name)})
+}
+
+func (c *Cloud) listImages(w http.ResponseWriter) {
+	images := []interface{}{}
+	for id, name := range c.images {
+		images = append(images, map[string]interface{}{"id": id, "name": name, "status": "ACTIVE", "progress": 100})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"images": images})
+}
+
+func (c *Cloud) listFloatingIPs(w http.ResponseWriter) {
+	floatingIPs := []interface{}{}
+	for _, fip := range c.floatingIPs {
+		var fixedIP, instanceID interface{}
+		if fip.instanceID != "" {
+			fixedIP = serverFixedIP(fip.instanceID)
+			instanceID = fip.instanceID
+		}
+		floatingIPs = append(floatingIPs, map[string]interface{}{
+			"id":          fip.id,
+			"ip":          fip.ip,
+			"fixed_ip":    fixedIP,
+			"instance_id": instanceID,
+			"pool":        "public",
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"floating_ips": floatingIPs})
+}
+
+func (c *Cloud) getHostAggregate(w http.ResponseWriter, id string) {
+	aggregateID, err := strconv.Atoi(id)
+	aggregate, ok := c.aggregates[aggregateID]
+	if err != nil || !ok {
+		writeError(w, http.StatusNotFound, "itemNotFound", fmt.Sprintf("Aggregate %s could not be found.", id))
+		return
+	}
+	metadata := map[string]string{"availability_zone": aggregate.availabilityZone}
+	for key, value := range aggregate.metadata {
+		metadata[key] = value
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"aggregate": map[string]interface{}{
+		"id":                aggregateID,
+		"name":              aggregate.name,
+		"availability_zone": aggregate.availabilityZone,
+		"hosts":             []string{DefaultHypervisorHostname},
+		"metadata":          metadata,
+		"created_at":        "2018-01-01T00:00:00.000000",
+		"deleted":           false,
+	}})
+}
+
+func (c *Cloud) listHypervisors(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"hypervisors": []interface{}{map[string]interface{}{
+		"id":                   1,
+		"hypervisor_hostname":  DefaultHypervisorHostname,
+		"host_ip":              DefaultHypervisorAddress,
+		"hypervisor_type":      "QEMU",
+		"hypervisor_version":   2011001,
+		"state":                "up",
+		"status":               "enabled",
+		"running_vms":          len(c.servers),
+		"vcpus":                8,
+		"vcpus_used":           len(c.servers),
+		"memory_mb":            16384,
+		"memory_mb_used":       0,
+		"free_ram_mb":          16384,
+		"local_gb":             100,
+		"local_gb_used":        0,
+		"free_disk_gb":         100,
+		"disk_available_least": 100,
+		"current_workload":     0,
+		"cpu_info": map[string]interface{}{
+			"arch":     "x86_64",
+			"model":    "fake",
+			"vendor":   "fake",
+			"features": []string{},
+			"topology": map[string]interface{}{"cores": 4, "threads": 2, "sockets": 1},
+		},
+		"service": map[string]interface{}{"host": DefaultHypervisorHostname, "id": 1, "disabled_reason": nil},
+	}}})
+}
+
+func serverJSON(server *Server) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                                  server.ID,
+		"name":                                server.Name,
+		"status":                              server.Status,
+		"flavor":                              map[string]interface{}{"id": server.FlavorID},
+		"image":                               map[string]interface{}{"id": server.ImageID},
+		"key_name":                            server.KeyName,
+		"OS-EXT-AZ:availability_zone":         server.AvailabilityZone,
+		"OS-EXT-SRV-ATTR:host":                DefaultHypervisorHostname,
+		"OS-EXT-SRV-ATTR:hypervisor_hostname": DefaultHypervisorHostname,
+		"OS-EXT-SRV-ATTR:instance_name":       server.InstanceName,
+	}
+}
+
+func (c *Cloud) createServer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Server struct {
+			Name             string `json:"name"`
+			FlavorRef        string `json:"flavorRef"`
+			ImageRef         string `json:"imageRef"`
+			KeyName          string `json:"key_name"`
+			AvailabilityZone string `json:"availability_zone"`
+		} `json:"server"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return
+	}
+	if _, ok := c.images[body.Server.ImageRef]; !ok {
+		writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("Image %s could not be found.", body.Server.ImageRef))
+		return
+	}
+	server := &Server{
+		ID:               c.newID("server"),
+		Name:             body.Server.Name,
+		FlavorID:         body.Server.FlavorRef,
+		ImageID:          body.Server.ImageRef,
+		KeyName:          body.Server.KeyName,
+		AvailabilityZone: body.Server.AvailabilityZone,
+		Status:           c.BootStatus,
+	}
+	server.InstanceName = fmt.Sprintf("instance-%08x", c.objectCount)
+	c.servers[server.ID] = server
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"server": map[string]interface{}{
+		"id":        server.ID,
+		"adminPass": "fake",
+		"links":     []interface{}{},
+	}})
+}
+
+func (c *Cloud) getServer(w http.ResponseWriter, id string) {
+	server, ok := c.servers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "itemNotFound", fmt.Sprintf("Instance %s could not be found.", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server": serverJSON(server)})
+}
+
+func (c *Cloud) deleteServer(w http.ResponseWriter, id string) {
+	if _, ok := c.servers[id]; !ok {
+		writeError(w, http.StatusNotFound, "itemNotFound", fmt.Sprintf("Instance %s could not be found.", id))
+		return
+	}
+	// Floating IPs are disassociated from deleted servers.
+	for _, fip := range c.floatingIPs {
+		if fip.instanceID == id {
+			fip.instanceID = ""
+		}
+	}
+	delete(c.servers, id)
+	c.deleted = append(c.deleted, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serverAction handles addFloatingIp and os-stop actions.
+func (c *Cloud) serverAction(w http.ResponseWriter, r *http.Request, id string) {
+	server, ok := c.servers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "itemNotFound", fmt.Sprintf("Instance %s could not be found.", id))
+		return
+	}
+	var body map[string]map[string]interface{}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return
+	}
+
+	if _, ok := body["os-stop"]; ok {
+		server.Status = "SHUTOFF"
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if action, ok := body["addFloatingIp"]; ok {
+		address, _ := action["address"].(string)
+		for _, fip := range c.floatingIPs {
+			if fip.ip != address {
+				continue
+			}
+			if fip.instanceID != "" {
+				writeError(w, http.StatusBadRequest, "badRequest", fmt.Sprintf("Floating IP %s is already associated.", address))
+				return
+			}
+			fip.instanceID = id
+			server.FloatingIP = address
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		writeError(w, http.StatusNotFound, "itemNotFound", fmt.Sprintf("Floating IP %s could not be found.", address))
+		return
+	}
+	writeError(w, http.StatusBadRequest, "badRequest", "unsupported server action")
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstackfake
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// floatingNetworkID is an ID of external network floating IPs are allocated from.
+const floatingNetworkID = "public"
+
+// serverFixedIP returns address of the server on tenant network.
+func serverFixedIP(serverID string) string {
+	return "10.0.0." + serverID[strings.LastIndex(serverID, "-")+1:]
+}
+
+// serverPortID returns ID of the port connecting the server to tenant network. Every server has exactly one port.
+func serverPortID(serverID string) string {
+	return "port-" + serverID
+}
+
+// portServer returns server with the port or nil when there is no such server.
+func (c *Cloud) portServer(portID string) *Server {
+	for id, server := range c.servers {
+		if serverPortID(id) == portID {
+			return server
+		}
+	}
+	return nil
+}
+
+// serveNetwork routes requests to Neutron handlers. Path is relative to network endpoint.
+func (c *Cloud) serveNetwork(w http.ResponseWriter, r *http.Request, path []string) {
+	switch {
+	case r.Method == http.MethodGet && match(path, "v2.0", "floatingips"):
+		c.listNetworkFloatingIPs(w, r.URL.Query())
+	case r.Method == http.MethodGet && match(path, "v2.0", "floatingips", "*"):
+		c.getNetworkFloatingIP(w, path[2])
+	case r.Method == http.MethodPut && match(path, "v2.0", "floatingips", "*"):
+		c.updateNetworkFloatingIP(w, r, path[2])
+	case r.Method == http.MethodGet && match(path, "v2.0", "ports"):
+		c.listPorts(w, r.URL.Query())
+	default:
+		writeError(w, http.StatusNotFound, "NeutronError", fmt.Sprintf("%s %s is not supported by fake cloud", r.Method, r.URL.Path))
+	}
+}
+
+func networkFloatingIPJSON(fip *floatingIP) map[string]interface{} {
+	var portID, fixedIP interface{}
+	status := "DOWN"
+	if fip.instanceID != "" {
+		portID = serverPortID(fip.instanceID)
+		fixedIP = serverFixedIP(fip.instanceID)
+		status = "ACTIVE"
+	}
+	return map[string]interface{}{
+		"id":                  fip.id,
+		"floating_network_id": floatingNetworkID,
+		"floating_ip_address": fip.ip,
+		"port_id":             portID,
+		"fixed_ip_address":    fixedIP,
+		"tenant_id":           "admin",
+		"status":              status,
+	}
+}
+
+// listNetworkFloatingIPs lists floating IPs filtered by floating_ip_address and port_id query parameters.
+func (c *Cloud) listNetworkFloatingIPs(w http.ResponseWriter, query url.Values) {
+	floatingIPs := []interface{}{}
+	for _, fip := range c.floatingIPs {
+		if address := query.Get("floating_ip_address"); address != "" && address != fip.ip {
+			continue
+		}
+		if portID := query.Get("port_id"); portID != "" && (fip.instanceID == "" || portID != serverPortID(fip.instanceID)) {
+			continue
+		}
+		floatingIPs = append(floatingIPs, networkFloatingIPJSON(fip))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"floatingips": floatingIPs})
+}
+
+func (c *Cloud) findFloatingIP(id string) *floatingIP {
+	for _, fip := range c.floatingIPs {
+		if fip.id == id {
+			return fip
+		}
+	}
+	return nil
+}
+
+func (c *Cloud) getNetworkFloatingIP(w http.ResponseWriter, id string) {
+	fip := c.findFloatingIP(id)
+	if fip == nil {
+		writeError(w, http.StatusNotFound, "NeutronError", fmt.Sprintf("Floating IP %s could not be found.", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"floatingip": networkFloatingIPJSON(fip)})
+}
+
+// updateNetworkFloatingIP associates floating IP with port of a server or disassociates it when port_id is null.
+func (c *Cloud) updateNetworkFloatingIP(w http.ResponseWriter, r *http.Request, id string) {
+	fip := c.findFloatingIP(id)
+	if fip == nil {
+		writeError(w, http.StatusNotFound, "NeutronError", fmt.Sprintf("Floating IP %s could not be found.", id))
+		return
+	}
+	var body struct {
+		FloatingIP struct {
+			PortID *string `json:"port_id"`
+		} `json:"floatingip"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "NeutronError", err.Error())
+		return
+	}
+
+	if body.FloatingIP.PortID == nil {
+		if server, ok := c.servers[fip.instanceID]; ok {
+			server.FloatingIP = ""
+		}
+		fip.instanceID = ""
+		writeJSON(w, http.StatusOK, map[string]interface{}{"floatingip": networkFloatingIPJSON(fip)})
+		return
+	}
+
+	server := c.portServer(*body.FloatingIP.PortID)
+	if server == nil {
+		writeError(w, http.StatusNotFound, "NeutronError", fmt.Sprintf("Port %s could not be found.", *body.FloatingIP.PortID))
+		return
+	}
+	if fip.instanceID != "" && fip.instanceID != server.ID {
+		writeError(w, http.StatusConflict, "NeutronError", fmt.Sprintf("Floating IP %s is already associated.", fip.ip))
+		return
+	}
+	fip.instanceID = server.ID
+	server.FloatingIP = fip.ip
+	writeJSON(w, http.StatusOK, map[string]interface{}{"floatingip": networkFloatingIPJSON(fip)})
+}
+
+// listPorts lists ports of servers filtered by device_id query parameter.
+func (c *Cloud) listPorts(w http.ResponseWriter, query url.Values) {
+	var ids []string
+	for id := range c.servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	ports := []interface{}{}
+	for _, id := range ids {
+		server := c.servers[id]
+		if deviceID := query.Get("device_id"); deviceID != "" && deviceID != server.ID {
+			continue
+		}
+		ports = append(ports, map[string]interface{}{
+			"id":             serverPortID(server.ID),
+			"network_id":     "private",
+			"name":           "",
+			"admin_state_up": true,
+			"status":         "ACTIVE",
+			"device_id":      server.ID,
+			"device_owner":   "compute:" + server.AvailabilityZone,
+			"tenant_id":      "admin",
+			"fixed_ips":      []interface{}{map[string]interface{}{"subnet_id": "private-subnet", "ip_address": serverFixedIP(server.ID)}},
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ports": ports})
+}
@@ -266,6 +266,16 @@ func (s *Handle) Stop() error {
 	return nil
 }
 
+// Pause is not supported for Snap tasks, as stopped task is considered terminated.
+func (s *Handle) Pause() error {
+	return errors.Errorf("snap task %q cannot be paused", s.task.Name)
+}
+
+// Resume is not supported for Snap tasks.
+func (s *Handle) Resume() error {
+	return errors.Errorf("snap task %q cannot be resumed", s.task.Name)
+}
+
 // Wait blocks until the Snap task is executed at least once
 // (including hits that happened in the past).
 func (s *Handle) Wait(timeout time.Duration) (bool, error) {
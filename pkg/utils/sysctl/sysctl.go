@@ -22,12 +22,17 @@ import (
 	"github.com/pkg/errors"
 )
 
-// Get returns the value of the sysctl key specified by name.
-func Get(name string) (string, error) {
-	// "net.ipv4.tcp_syncookies" translates into "/proc/sys/net/ipv4/tcp_syncookies"
+// procfsPath translates sysctl key into procfs path.
+// "net.ipv4.tcp_syncookies" translates into "/proc/sys/net/ipv4/tcp_syncookies"
+func procfsPath(name string) string {
 	const sysctlRoot = "/proc/sys"
 	relativeSysctlPath := strings.Replace(name, ".", "/", -1)
-	sysctlPath := path.Join(sysctlRoot, relativeSysctlPath)
+	return path.Join(sysctlRoot, relativeSysctlPath)
+}
+
+// Get returns the value of the sysctl key specified by name.
+func Get(name string) (string, error) {
+	sysctlPath := procfsPath(name)
 
 	byteContent, err := ioutil.ReadFile(sysctlPath)
 	if err != nil {
@@ -40,3 +45,16 @@ func Get(name string) (string, error) {
 
 	return content, nil
 }
+
+// Set writes the value of the sysctl key specified by name.
+// Writing sysctl values usually requires root privileges.
+func Set(name string, value string) error {
+	sysctlPath := procfsPath(name)
+
+	err := ioutil.WriteFile(sysctlPath, []byte(value+"\n"), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write %q to file %q", value, sysctlPath)
+	}
+
+	return nil
+}
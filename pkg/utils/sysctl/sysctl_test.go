@@ -45,4 +45,13 @@ func TestSysctl(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Writing a non-sense sysctl value (foo.bar.baz)", t, func() {
+		err := Set("foo.bar.baz", "1")
+
+		Convey("Should return an error", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "/proc/sys/foo/bar/baz")
+		})
+	})
 }
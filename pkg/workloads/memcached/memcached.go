@@ -134,13 +134,23 @@ func (m Memcached) Launch() (executor.TaskHandle, error) {
 		return nil, err
 	}
 	address := fmt.Sprintf("%s:%d", m.conf.IP, m.conf.Port)
-	if !m.isMemcachedUp(address, time.Second*time.Duration(m.conf.Timeout)) {
+	if !executor.IsListening(task, address, time.Second*time.Duration(m.conf.Timeout), m.isMemcachedUp) {
+		// Memcached which has exited (e.g. because of wrong configuration) would fail again when retried.
+		if task.Status() == executor.TERMINATED {
+			exitCode, err := task.ExitCode()
+			if err != nil {
+				return nil, errors.Wrapf(err, "memcached has exited before it started listening on %q", address)
+			}
+			return nil, errors.Errorf("memcached has exited with code %d before it started listening on %q", exitCode, address)
+		}
+
 		if err := task.Stop(); err != nil {
 			log.Errorf("failed to stop memcached instance. Error: %q", err.Error())
 		}
 
-		return nil, errors.Errorf("failed to connect to memcached instance. Timeout on connection to %q",
-			address)
+		// Memcached is running but not reachable yet (e.g. slow start), so launch can be retried.
+		return nil, executor.Retryable(errors.Errorf("failed to connect to memcached instance. Timeout on connection to %q",
+			address))
 	}
 	return task, nil
 }
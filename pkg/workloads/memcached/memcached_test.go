@@ -88,17 +88,34 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(nil)
+						mockedTaskHandle.On("Status").Return(executor.RUNNING)
 						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
 
+						Convey("Launch should be retryable as memcached is running", func() {
+							So(executor.IsRetryable(err), ShouldBeTrue)
+						})
+						mockedExecutor.AssertExpectations(t)
+					})
+					Convey("When memcached exits before it starts listening error shall not be retryable", func() {
+						mockedTaskHandle.On("Status").Return(executor.TERMINATED)
+						mockedTaskHandle.On("ExitCode").Return(71, nil)
+						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
+						task, err := memcachedLauncher.Launch()
+						So(err, ShouldNotBeNil)
+						So(err.Error(), ShouldContainSubstring, "exited with code 71")
+						So(executor.IsRetryable(err), ShouldBeFalse)
+						So(task, ShouldBeNil)
+
 						mockedExecutor.AssertExpectations(t)
 					})
 					Convey("When test connection to memcached fails and task.Stop fails task handle shall be nil and error shall be return", func() {
 						mockedTaskHandle.On("Stop").Return(errors.New("Test error code for stop"))
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(nil)
+						mockedTaskHandle.On("Status").Return(executor.RUNNING)
 						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
@@ -110,6 +127,7 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(errors.New("Test error code for clean"))
 						mockedTaskHandle.On("EraseOutput").Return(nil)
+						mockedTaskHandle.On("Status").Return(executor.RUNNING)
 						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
@@ -121,6 +139,7 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(errors.New("Test error code for erasing output"))
+						mockedTaskHandle.On("Status").Return(executor.RUNNING)
 						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
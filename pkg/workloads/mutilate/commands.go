@@ -17,8 +17,6 @@ package mutilate
 import (
 	"fmt"
 	"time"
-
-	"github.com/intelsdi-x/swan/pkg/executor"
 )
 
 // getAgentCommand returns command for agent.
@@ -57,7 +55,7 @@ func getPopulateCommand(config Config) string {
 }
 
 // getBaseMasterCommand returns master base command for both agent and agentless mode tune & load.
-func getBaseMasterCommand(config Config, agentHandles []executor.TaskHandle) string {
+func getBaseMasterCommand(config Config, agentAddresses []string) string {
 	baseCommand := fmt.Sprint(
 		fmt.Sprintf("%s", config.PathToBinary),
 		fmt.Sprintf(" -v -s %s:%d", config.MemcachedHost, config.MemcachedPort),
@@ -78,7 +76,7 @@ func getBaseMasterCommand(config Config, agentHandles []executor.TaskHandle) str
 	}
 
 	// Check if it is NOT agentless mode.
-	if len(agentHandles) > 0 {
+	if len(agentAddresses) > 0 {
 		// Add master-only parameters.
 		baseCommand += fmt.Sprint(
 			fmt.Sprintf(" -D %d -C %d", config.MasterConnectionsDepth, config.MasterConnections),
@@ -87,8 +85,8 @@ func getBaseMasterCommand(config Config, agentHandles []executor.TaskHandle) str
 		)
 
 		// Enlist agents.
-		for _, agent := range agentHandles {
-			baseCommand += fmt.Sprintf(" -a %s", agent.Address())
+		for _, agentAddress := range agentAddresses {
+			baseCommand += fmt.Sprintf(" -a %s", agentAddress)
 		}
 	}
 
@@ -97,15 +95,15 @@ func getBaseMasterCommand(config Config, agentHandles []executor.TaskHandle) str
 
 // getLoadCommand returns master load command for both agent and agentless mode.
 func getLoadCommand(
-	config Config, qps int, duration time.Duration, agentHandles []executor.TaskHandle) string {
-	baseCommand := getBaseMasterCommand(config, agentHandles)
+	config Config, qps int, duration time.Duration, agentAddresses []string) string {
+	baseCommand := getBaseMasterCommand(config, agentAddresses)
 	return fmt.Sprintf("%s -q %d -t %d",
 		baseCommand, qps, int(duration.Seconds()))
 }
 
 // getTuneCommand returns master tune command for both agent and agentless mode.
-func getTuneCommand(config Config, slo int, agentHandles []executor.TaskHandle) (command string) {
-	baseCommand := getBaseMasterCommand(config, agentHandles)
+func getTuneCommand(config Config, slo int, agentAddresses []string) (command string) {
+	baseCommand := getBaseMasterCommand(config, agentAddresses)
 	command = fmt.Sprintf("%s --search %s:%d -t %d",
 		baseCommand,
 		config.LatencyPercentile,
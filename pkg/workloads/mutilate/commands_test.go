@@ -19,7 +19,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/intelsdi-x/swan/pkg/executor"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -101,7 +100,7 @@ func (s *MutilateTestSuite) TestGetLoadCommand() {
 	s.mutilate.config.MasterQPS = 0
 	s.mutilate.config.Records = 12345
 	s.mutilate.config.Update = "0.5"
-	command := getLoadCommand(s.mutilate.config, load, duration, []executor.TaskHandle{})
+	command := getLoadCommand(s.mutilate.config, load, duration, []string{})
 
 	s.soExpectBaseCommandOptions(command)
 
@@ -139,12 +138,7 @@ func (s *MutilateTestSuite) TestGetMultinodeLoadCommand() {
 
 	s.mutilate.config.MasterQPS = 0
 
-	s.mAgentHandle1.On("Address").Return(agentAddress1).Once()
-	s.mAgentHandle2.On("Address").Return(agentAddress2).Once()
-	command := getLoadCommand(s.mutilate.config, load, duration, []executor.TaskHandle{
-		s.mAgentHandle1,
-		s.mAgentHandle2,
-	})
+	command := getLoadCommand(s.mutilate.config, load, duration, []string{agentAddress1, agentAddress2})
 
 	s.soExpectBaseCommandOptions(command)
 
@@ -173,12 +167,7 @@ func (s *MutilateTestSuite) TestGetMultinodeLoadCommand() {
 	// Check with MasterQPS different to 0.
 	s.mutilate.config.MasterQPS = 24234
 
-	s.mAgentHandle1.On("Address").Return(agentAddress1).Once()
-	s.mAgentHandle2.On("Address").Return(agentAddress2).Once()
-	command = getLoadCommand(s.mutilate.config, load, duration, []executor.TaskHandle{
-		s.mAgentHandle1,
-		s.mAgentHandle2,
-	})
+	command = getLoadCommand(s.mutilate.config, load, duration, []string{agentAddress1, agentAddress2})
 
 	s.soExpectBaseCommandOptions(command)
 
@@ -192,7 +181,7 @@ func (s *MutilateTestSuite) TestGetTuneCommand() {
 	const slo = 300
 
 	s.mutilate.config.MasterQPS = 0
-	command := getTuneCommand(s.mutilate.config, slo, []executor.TaskHandle{})
+	command := getTuneCommand(s.mutilate.config, slo, []string{})
 
 	s.soExpectBaseCommandOptions(command)
 
@@ -219,12 +208,7 @@ func (s *MutilateTestSuite) TestGetMultinodeTuneCommand() {
 
 	s.mutilate.config.MasterQPS = 0
 
-	s.mAgentHandle1.On("Address").Return(agentAddress1).Once()
-	s.mAgentHandle2.On("Address").Return(agentAddress2).Once()
-	command := getTuneCommand(s.mutilate.config, slo, []executor.TaskHandle{
-		s.mAgentHandle1,
-		s.mAgentHandle2,
-	})
+	command := getTuneCommand(s.mutilate.config, slo, []string{agentAddress1, agentAddress2})
 
 	s.soExpectBaseCommandOptions(command)
 
@@ -252,12 +236,7 @@ func (s *MutilateTestSuite) TestGetMultinodeTuneCommand() {
 	// Check with MasterQPS different to 0.
 	s.mutilate.config.MasterQPS = 24234
 
-	s.mAgentHandle1.On("Address").Return(agentAddress1).Once()
-	s.mAgentHandle2.On("Address").Return(agentAddress2).Once()
-	command = getTuneCommand(s.mutilate.config, slo, []executor.TaskHandle{
-		s.mAgentHandle1,
-		s.mAgentHandle2,
-	})
+	command = getTuneCommand(s.mutilate.config, slo, []string{agentAddress1, agentAddress2})
 
 	s.soExpectBaseCommandOptions(command)
 
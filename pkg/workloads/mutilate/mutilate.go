@@ -16,6 +16,7 @@ package mutilate
 
 import (
 	"strconv"
+	"sync"
 	"time"
 
 	"os"
@@ -68,6 +69,7 @@ var (
 	masterAffinityFlag         = conf.NewBoolFlag("mutilate_master_affinity", "Mutilate master affinity (--affinity).", defaultMasterAffinity)
 	masterBlockingFlag         = conf.NewBoolFlag("mutilate_master_blocking", "Mutilate master blocking (--blocking -B).", defaultMasterBlocking)
 	masterQPSFlag              = conf.NewIntFlag("mutilate_master_qps", "Mutilate master QPS value (-Q).", defaultMasterQPS)
+	agentOutputArchiveFlag     = conf.NewStringFlag("mutilate_agent_output_archive", "Directory (relative to experiment directory) where raw outputs of agents are archived before they are erased.", "mutilate_agents")
 	masterKeySizeFlag          = conf.NewStringFlag("mutilate_master_keysize", "Length of memcached keys (-K).", defaultMasterKeySize)
 	masterValueSizeFlag        = conf.NewStringFlag("mutilate_master_valuesize", "Length of memcached values (-V).", defaultMasterValueSize)
 	masterInterArrivalDistFlag = conf.NewStringFlag("mutilate_master_interarrival_dist", "Inter-arrival distribution (-i).", defaultMasterInterArrivalDist)
@@ -187,6 +189,7 @@ func stopAgents(agentHandles []executor.TaskHandle) {
 	}
 }
 
+// eraseAgentOutputs erases agents outputs. They need to be archived first.
 func eraseAgentOutputs(agentHandles []executor.TaskHandle) {
 	for _, handle := range agentHandles {
 		err := handle.EraseOutput()
@@ -196,8 +199,21 @@ func eraseAgentOutputs(agentHandles []executor.TaskHandle) {
 	}
 }
 
-func (m mutilate) runRemoteAgents() ([]executor.TaskHandle, error) {
+// archiveAgentOutputs copies outputs of terminated agents alongside experiment logs.
+func archiveAgentOutputs(agentHandles []executor.TaskHandle) {
+	for _, handle := range agentHandles {
+		err := executor.ArchiveOutput(handle, agentOutputArchiveFlag.Value())
+		if err != nil {
+			logrus.Errorf("Cannot archive output of %s: %s", handle, err.Error())
+		}
+	}
+}
+
+// runRemoteAgents launches agents and returns their handles and addresses to be enlisted by master.
+// Agent run in many pods at once (e.g. as Kubernetes job) contributes address of every pod.
+func (m mutilate) runRemoteAgents() ([]executor.TaskHandle, []string, error) {
 	handles := []executor.TaskHandle{}
+	addresses := []string{}
 
 	command := getAgentCommand(m.config)
 	for _, exec := range m.agents {
@@ -207,17 +223,35 @@ func (m mutilate) runRemoteAgents() ([]executor.TaskHandle, error) {
 			logrus.Errorf(
 				"Mutilate: one of agents has failed (cmd: %q). Stopping already started %d agents",
 				command, len(handles))
-			stopAgents(handles)
-			if m.config.EraseTuneOutput {
-				eraseAgentOutputs(handles)
-			}
-			return nil, err
+			m.abortAgents(handles)
+			return nil, nil, err
 		}
-		serviceHandle := executor.NewServiceHandle(handle)
-		handles = append(handles, serviceHandle)
+		handles = append(handles, executor.NewServiceHandle(handle))
+
+		multiPodTask, ok := executor.GetMultiPodTask(handle)
+		if !ok {
+			addresses = append(addresses, handle.Address())
+			continue
+		}
+		podAddresses, err := multiPodTask.PodAddresses()
+		if err != nil {
+			logrus.Errorf("Mutilate: pods of agent %s are not running. Stopping already started %d agents", handle, len(handles))
+			m.abortAgents(handles)
+			return nil, nil, err
+		}
+		addresses = append(addresses, podAddresses...)
 	}
 
-	return handles, nil
+	return handles, addresses, nil
+}
+
+// abortAgents stops agents when cluster cannot be launched.
+func (m mutilate) abortAgents(agentHandles []executor.TaskHandle) {
+	stopAgents(agentHandles)
+	archiveAgentOutputs(agentHandles)
+	if m.config.EraseTuneOutput {
+		eraseAgentOutputs(agentHandles)
+	}
 }
 
 // Populate load the initial test data into Memcached.
@@ -273,16 +307,17 @@ func (m mutilate) getQPSAndLatencyFrom(stdoutFile *os.File) (qps int, achievedSL
 // Tune returns the maximum achieved QPS where SLI is below target SLO.
 func (m mutilate) Tune(slo int) (qps int, achievedSLI int, err error) {
 	// Run agents when specified.
-	agentHandles, err := m.runRemoteAgents()
+	agentHandles, agentAddresses, err := m.runRemoteAgents()
 	if err != nil {
 		return qps, achievedSLI, errors.Wrap(err, "executing Mutilate Agents failed")
 	}
 
 	// Run master with tuning option.
-	tuneCmd := getTuneCommand(m.config, slo, agentHandles)
+	tuneCmd := getTuneCommand(m.config, slo, agentAddresses)
 	masterHandle, err := m.master.Execute(tuneCmd)
 	if err != nil {
 		stopAgents(agentHandles)
+		archiveAgentOutputs(agentHandles)
 		if m.config.EraseTuneOutput {
 			eraseAgentOutputs(agentHandles)
 		}
@@ -294,6 +329,7 @@ func (m mutilate) Tune(slo int) (qps int, achievedSLI int, err error) {
 
 	// Blocking wait for master (agents will be killed then).
 	_, err = taskHandle.Wait(0)
+	archiveAgentOutputs(agentHandles)
 	if err != nil {
 		return qps, achievedSLI, errors.Wrap(err, "Mutilate cluster failed")
 	}
@@ -332,19 +368,61 @@ func (m mutilate) Tune(slo int) (qps int, achievedSLI int, err error) {
 // Load starts a load on the specific workload with the defined loadPoint (number of QPS).
 // The task will do the load for specified amount of time.
 func (m mutilate) Load(qps int, duration time.Duration) (executor.TaskHandle, error) {
-	agentHandles, err := m.runRemoteAgents()
+	agentHandles, agentAddresses, err := m.runRemoteAgents()
 	if err != nil {
 		return nil, err
 	}
 
-	loadCommand := getLoadCommand(m.config, qps, duration, agentHandles)
+	loadCommand := getLoadCommand(m.config, qps, duration, agentAddresses)
 	masterHandle, err := m.master.Execute(loadCommand)
 	if err != nil {
 		stopAgents(agentHandles)
+		archiveAgentOutputs(agentHandles)
 		return nil, errors.Wrapf(err,
 			"execution of Mutilate Master Load failed. command: %q",
 			loadCommand)
 	}
 
-	return executor.NewClusterTaskHandle(masterHandle, agentHandles), nil
+	if len(agentHandles) == 0 {
+		return executor.NewClusterTaskHandle(masterHandle, agentHandles), nil
+	}
+	return &archivingTaskHandle{
+		ClusterTaskHandle: executor.NewClusterTaskHandle(masterHandle, agentHandles),
+		agents:            agentHandles,
+	}, nil
+}
+
+// archivingTaskHandle archives agents outputs once master and agents are terminated.
+type archivingTaskHandle struct {
+	*executor.ClusterTaskHandle
+	agents []executor.TaskHandle
+	once   sync.Once
+}
+
+func (h *archivingTaskHandle) archive() {
+	h.once.Do(func() {
+		archiveAgentOutputs(h.agents)
+	})
+}
+
+// Stop terminates master and agents and archives agents outputs.
+func (h *archivingTaskHandle) Stop() error {
+	err := h.ClusterTaskHandle.Stop()
+	h.archive()
+	return err
+}
+
+// Wait waits for master. Agents are stopped and their outputs archived when master terminates.
+func (h *archivingTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	terminated, err := h.ClusterTaskHandle.Wait(timeout)
+	if terminated {
+		h.archive()
+	}
+	return terminated, err
+}
+
+// EraseOutput archives agents outputs before they are removed.
+func (h *archivingTaskHandle) EraseOutput() error {
+	h.archive()
+	return h.ClusterTaskHandle.EraseOutput()
 }
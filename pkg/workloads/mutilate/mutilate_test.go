@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -75,6 +77,9 @@ type MutilateTestSuite struct {
 	mMasterHandle      *executor.MockTaskHandle
 	mAgentHandle1      *executor.MockTaskHandle
 	mAgentHandle2      *executor.MockTaskHandle
+
+	// agentStdout is an output of agents which is archived when they are terminated.
+	agentStdout *os.File
 }
 
 func (s *MutilateTestSuite) SetupTest() {
@@ -109,6 +114,17 @@ func (s *MutilateTestSuite) SetupTest() {
 	// Don't want to have not erased output after tests.
 	s.config.EraseTuneOutput = true
 	s.config.ErasePopulateOutput = true
+
+	agentOutputDirectory, err := ioutil.TempDir("", "mutilate_agent")
+	s.Require().NoError(err)
+	s.agentStdout, err = os.Create(filepath.Join(agentOutputDirectory, "stdout"))
+	s.Require().NoError(err)
+}
+
+func (s *MutilateTestSuite) TearDownTest() {
+	s.agentStdout.Close()
+	os.RemoveAll(filepath.Dir(s.agentStdout.Name()))
+	os.RemoveAll(agentOutputArchiveFlag.Value())
 }
 
 // Testing successful master-only mutilate tuning case.
@@ -180,6 +196,7 @@ func (s *MutilateTestSuite) TestClusterMutilateTuning() {
 	s.mExecutorForAgent1.On("Execute", mock.AnythingOfType("string")).Return(s.mAgentHandle1, nil)
 	s.mAgentHandle1.On("Address").Return("255.255.255.001").Times(numberOfConveys)
 	s.mAgentHandle1.On("Status").Return(executor.RUNNING)
+	s.mAgentHandle1.On("StdoutFile").Return(s.agentStdout, nil)
 	// Those function shouldn't be called in normal execution path
 	s.mAgentHandle1.On("Stop").Return(nil).Times(0)
 	s.mAgentHandle1.On("EraseOutput").Return(nil).Times(0)
@@ -187,6 +204,7 @@ func (s *MutilateTestSuite) TestClusterMutilateTuning() {
 	s.mExecutorForAgent2.On("Execute", mock.AnythingOfType("string")).Return(s.mAgentHandle2, nil)
 	s.mAgentHandle2.On("Address").Return("255.255.255.002").Times(numberOfConveys)
 	s.mAgentHandle2.On("Status").Return(executor.RUNNING)
+	s.mAgentHandle2.On("StdoutFile").Return(s.agentStdout, nil)
 	// Those function shouldn't be called in normal execution path
 	s.mAgentHandle2.On("Stop").Return(nil).Times(0)
 	s.mAgentHandle2.On("EraseOutput").Return(nil).Times(0)
@@ -253,6 +271,7 @@ func (s *MutilateTestSuite) TestClusterMutilateTuningErrors() {
 			s.mAgentHandle1.On("Stop").Return(nil).Once()
 			s.mAgentHandle1.On("EraseOutput").Return(nil).Once()
 			s.mAgentHandle1.On("Status").Return(executor.RUNNING)
+			s.mAgentHandle1.On("StdoutFile").Return(s.agentStdout, nil).Once()
 
 			s.mExecutorForAgent2.On(
 				"Execute", mock.AnythingOfType("string")).Return(s.mAgentHandle2, nil).Once()
@@ -260,6 +279,7 @@ func (s *MutilateTestSuite) TestClusterMutilateTuningErrors() {
 			s.mAgentHandle2.On("Stop").Return(nil).Once()
 			s.mAgentHandle2.On("EraseOutput").Return(nil).Once()
 			s.mAgentHandle2.On("Status").Return(executor.RUNNING)
+			s.mAgentHandle2.On("StdoutFile").Return(s.agentStdout, nil).Once()
 
 			_, _, err := mutilate.Tune(s.defaultSlo)
 			So(err, ShouldNotBeNil)
@@ -272,8 +292,10 @@ func (s *MutilateTestSuite) TestClusterMutilateTuningErrors() {
 
 			s.mExecutorForAgent1.On(
 				"Execute", mock.AnythingOfType("string")).Return(s.mAgentHandle1, nil).Once()
+			s.mAgentHandle1.On("Address").Return("255.255.255.001").Once()
 			s.mAgentHandle1.On("Stop").Return(nil).Once()
 			s.mAgentHandle1.On("EraseOutput").Return(nil).Once()
+			s.mAgentHandle1.On("StdoutFile").Return(s.agentStdout, nil).Once()
 
 			s.mExecutorForAgent2.On(
 				"Execute", mock.AnythingOfType("string")).Return(nil, errors.New(errorMsg)).Once()
@@ -295,15 +317,21 @@ func (s *MutilateTestSuite) TestClusterMutilateTuningErrors() {
 				"Execute", mock.AnythingOfType("string")).Return(s.mAgentHandle1, nil).Once()
 			s.mAgentHandle1.On("Address").Return("255.255.255.001").Once()
 			s.mAgentHandle1.On("Stop").Return(nil)
+			s.mAgentHandle1.On("StdoutFile").Return(s.agentStdout, nil).Once()
 
 			s.mExecutorForAgent2.On(
 				"Execute", mock.AnythingOfType("string")).Return(s.mAgentHandle2, nil).Once()
 			s.mAgentHandle2.On("Address").Return("255.255.255.002").Once()
 			s.mAgentHandle2.On("Stop").Return(nil)
+			s.mAgentHandle2.On("StdoutFile").Return(s.agentStdout, nil).Once()
 
 			_, _, err := mutilate.Tune(s.defaultSlo)
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, errorMsg)
+
+			// Agents outputs are archived even though they are not erased.
+			_, err = os.Stat(filepath.Join(agentOutputArchiveFlag.Value(), filepath.Base(filepath.Dir(s.agentStdout.Name())), "stdout"))
+			So(err, ShouldBeNil)
 		})
 
 		So(s.mExecutor.AssertExpectations(s.T()), ShouldBeTrue)
@@ -344,6 +372,39 @@ func (s *MutilateTestSuite) TestMutilateLoad() {
 	})
 }
 
+// multiPodTaskHandle is an agent run in many pods (e.g. as Kubernetes job).
+type multiPodTaskHandle struct {
+	*executor.MockTaskHandle
+	addresses []string
+}
+
+func (h multiPodTaskHandle) PodAddresses() ([]string, error) {
+	return h.addresses, nil
+}
+
+// Testing mutilate load with agent run in many pods.
+func (s *MutilateTestSuite) TestClusterMutilateLoadWithMultiPodAgent() {
+	const load = 1000
+	const duration = 10 * time.Second
+
+	mutilate := NewCluster(s.mExecutor, []executor.Executor{s.mExecutorForAgent1}, s.config)
+	agentHandle := multiPodTaskHandle{s.mAgentHandle1, []string{"10.244.0.5", "10.244.1.7"}}
+
+	s.mExecutorForAgent1.On("Execute", mock.AnythingOfType("string")).Return(agentHandle, nil).Once()
+	s.mExecutor.On("Execute", mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "-a 10.244.0.5 -a 10.244.1.7")
+	})).Return(s.mMasterHandle, nil).Once()
+
+	Convey("When generating Load with agent run in many pods.", s.T(), func() {
+		_, err := mutilate.Load(load, duration)
+		Convey("Master should enlist every pod of the agent", func() {
+			So(err, ShouldBeNil)
+			So(s.mExecutor.AssertExpectations(s.T()), ShouldBeTrue)
+			So(s.mExecutorForAgent1.AssertExpectations(s.T()), ShouldBeTrue)
+		})
+	})
+}
+
 // Testing master-only mutilate load with excutor failure.
 func (s *MutilateTestSuite) TestMutilateLoadExecutorError() {
 	mutilate := New(s.mExecutor, s.config)